@@ -2,11 +2,14 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
 	"time"
 
+	"wazmeow/internal/domain"
+
 	"github.com/joho/godotenv"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
@@ -23,14 +26,21 @@ type Config struct {
 
 // ServerConfig holds HTTP server configuration
 type ServerConfig struct {
-	Host         string        `json:"host"`
-	Port         int           `json:"port"`
-	ReadTimeout  time.Duration `json:"read_timeout"`
-	WriteTimeout time.Duration `json:"write_timeout"`
-	IdleTimeout  time.Duration `json:"idle_timeout"`
-	APIKey       string        `json:"api_key,omitempty"`
-	EnableCORS   bool          `json:"enable_cors"`
-	TLS          TLSConfig     `json:"tls"`
+	Host            string        `json:"host"`
+	Port            int           `json:"port"`
+	ReadTimeout     time.Duration `json:"read_timeout"`
+	WriteTimeout    time.Duration `json:"write_timeout"`
+	IdleTimeout     time.Duration `json:"idle_timeout"`
+	ShutdownTimeout time.Duration `json:"shutdown_timeout"`
+	TCPKeepAlive    time.Duration `json:"tcp_keepalive"` // keepalive probe interval of accepted connections, negative disables
+	SSEHeartbeat    time.Duration `json:"sse_heartbeat"` // interval of heartbeat comments on event streams, zero disables
+	SendResponse    string        `json:"send_response"` // default shape of send responses, "full" or "minimal"
+	APIKey          string        `json:"api_key,omitempty"`
+	CredentialsKey  string        `json:"-"`          // encrypts exported session credentials
+	PublicURL       string        `json:"public_url"` // externally reachable base URL used in media links
+	EnableCORS      bool          `json:"enable_cors"`
+	MaintenanceMode bool          `json:"maintenance_mode"` // reject sends from startup until turned off
+	TLS             TLSConfig     `json:"tls"`
 }
 
 // TLSConfig holds TLS configuration
@@ -57,12 +67,33 @@ type DatabaseConfig struct {
 
 // WhatsAppConfig holds WhatsApp client configuration
 type WhatsAppConfig struct {
-	Debug       bool   `json:"debug"`
-	LogLevel    string `json:"log_level"`
-	OSName      string `json:"os_name"`
-	Timeout     int    `json:"timeout"`
-	RetryCount  int    `json:"retry_count"`
-	AutoConnect bool   `json:"auto_connect"`
+	Debug            bool   `json:"debug"`
+	LogLevel         string `json:"log_level"`
+	OSName           string `json:"os_name"`
+	Timeout          int    `json:"timeout"`
+	RetryCount       int    `json:"retry_count"`
+	AutoConnect      bool   `json:"auto_connect"`
+	MaxMessageLength int    `json:"max_message_length"` // longest text body accepted in a single send
+	MediaSaveDir     string `json:"media_save_dir"`     // local directory inbound media is saved to, empty to disable
+	EventWorkers     int    `json:"event_workers"`      // chats of one session processed concurrently
+	EventBufferSize  int    `json:"event_buffer_size"`  // events of one session waiting before the oldest is dropped
+	SessionLimit     string `json:"session_limit"`      // "reject" or "evict_lru" once the session cap is reached
+	PersistJobs      bool   `json:"persist_jobs"`       // keep pending async and offline sends across restarts
+	PairClient       string `json:"pair_client"`        // client type reported when pairing by phone code
+	PairDisplay      string `json:"pair_display"`       // "Browser (OS)" name reported when pairing by phone code
+
+	GroupBatchSize  int           `json:"group_batch_size"`  // participants per group membership request
+	GroupBatchDelay time.Duration `json:"group_batch_delay"` // pause between group membership requests
+	ProxyCheckURL   string        `json:"proxy_check_url"`   // URL requested through a session proxy to test it
+	StartupDelay    time.Duration `json:"startup_delay"`     // wait before reconnecting sessions once the app is ready
+	EventDedupTTL   time.Duration `json:"event_dedup_ttl"`   // window in which a redelivered inbound message is dropped, 0 to disable
+	EventDedupSize  int           `json:"event_dedup_size"`  // inbound message IDs remembered for deduplication
+	QRRotation      time.Duration `json:"qr_rotation"`       // how long a pairing QR code stays valid when WhatsApp does not report it
+
+	MaxCachedDevices int `json:"max_cached_devices"` // devices kept in memory before idle ones are evicted
+
+	CaptureUnknownEvents bool `json:"capture_unknown_events"` // emit events without a dedicated mapping as generic notifications
+	DailySendQuota       int  `json:"daily_send_quota"`       // messages a session may send per UTC day, 0 for no limit
 }
 
 // LoggingConfig holds logging configuration
@@ -75,10 +106,13 @@ type LoggingConfig struct {
 
 // WebhookConfig holds webhook configuration
 type WebhookConfig struct {
-	GlobalURL string        `json:"global_url"`
-	Timeout   time.Duration `json:"timeout"`
-	Retries   int           `json:"retries"`
-	Events    []string      `json:"events"`
+	GlobalURL  string        `json:"global_url"`
+	Timeout    time.Duration `json:"timeout"`
+	Retries    int           `json:"retries"`
+	MaxElapsed time.Duration `json:"max_elapsed"` // give up retrying once this much time has passed
+	Events     []string      `json:"events"`
+	Compress   bool          `json:"compress"` // gzip payloads for every session
+	Secret     string        `json:"-"`        // HMAC key signing payloads, unsigned when empty
 }
 
 // Load loads configuration from environment variables and .env file
@@ -106,13 +140,20 @@ func Load() (*Config, error) {
 
 func loadServerConfig() ServerConfig {
 	return ServerConfig{
-		Host:         getEnvOrDefault("SERVER_HOST", "0.0.0.0"),
-		Port:         getEnvAsIntOrDefault("SERVER_PORT", 8080),
-		ReadTimeout:  getEnvAsDurationOrDefault("SERVER_READ_TIMEOUT", 30*time.Second),
-		WriteTimeout: getEnvAsDurationOrDefault("SERVER_WRITE_TIMEOUT", 30*time.Second),
-		IdleTimeout:  getEnvAsDurationOrDefault("SERVER_IDLE_TIMEOUT", 120*time.Second),
-		APIKey:       os.Getenv("WAZMEOW_API_KEY"),
-		EnableCORS:   getEnvAsBoolOrDefault("SERVER_ENABLE_CORS", true),
+		Host:            getEnvOrDefault("SERVER_HOST", "0.0.0.0"),
+		Port:            getEnvAsIntOrDefault("SERVER_PORT", 8080),
+		ReadTimeout:     getEnvAsDurationOrDefault("SERVER_READ_TIMEOUT", 30*time.Second),
+		WriteTimeout:    getEnvAsDurationOrDefault("SERVER_WRITE_TIMEOUT", 30*time.Second),
+		IdleTimeout:     getEnvAsDurationOrDefault("SERVER_IDLE_TIMEOUT", 120*time.Second),
+		ShutdownTimeout: getEnvAsDurationOrDefault("SHUTDOWN_TIMEOUT", 30*time.Second),
+		TCPKeepAlive:    getEnvAsDurationOrDefault("SERVER_TCP_KEEPALIVE", 30*time.Second),
+		SSEHeartbeat:    getEnvAsDurationOrDefault("SSE_HEARTBEAT_INTERVAL", 15*time.Second),
+		SendResponse:    getEnvOrDefault("SEND_RESPONSE_FORMAT", "full"),
+		APIKey:          os.Getenv("WAZMEOW_API_KEY"),
+		PublicURL:       strings.TrimSuffix(os.Getenv("SERVER_PUBLIC_URL"), "/"),
+		CredentialsKey:  os.Getenv("WAZMEOW_CREDENTIALS_KEY"),
+		EnableCORS:      getEnvAsBoolOrDefault("SERVER_ENABLE_CORS", true),
+		MaintenanceMode: getEnvAsBoolOrDefault("SERVER_MAINTENANCE_MODE", false),
 		TLS: TLSConfig{
 			Enabled:  getEnvAsBoolOrDefault("TLS_ENABLED", false),
 			CertFile: os.Getenv("TLS_CERT_FILE"),
@@ -139,12 +180,31 @@ func loadDatabaseConfig() DatabaseConfig {
 
 func loadWhatsAppConfig() WhatsAppConfig {
 	return WhatsAppConfig{
-		Debug:       getEnvAsBoolOrDefault("WHATSAPP_DEBUG", false),
-		LogLevel:    getEnvOrDefault("WHATSAPP_LOG_LEVEL", "INFO"),
-		OSName:      getEnvOrDefault("WHATSAPP_OS_NAME", "WazMeow"),
-		Timeout:     getEnvAsIntOrDefault("WHATSAPP_TIMEOUT", 30),
-		RetryCount:  getEnvAsIntOrDefault("WHATSAPP_RETRY_COUNT", 3),
-		AutoConnect: getEnvAsBoolOrDefault("WHATSAPP_AUTO_CONNECT", true),
+		Debug:            getEnvAsBoolOrDefault("WHATSAPP_DEBUG", false),
+		LogLevel:         getEnvOrDefault("WHATSAPP_LOG_LEVEL", "INFO"),
+		OSName:           getEnvOrDefault("WHATSAPP_OS_NAME", "WazMeow"),
+		Timeout:          getEnvAsIntOrDefault("WHATSAPP_TIMEOUT", 30),
+		RetryCount:       getEnvAsIntOrDefault("WHATSAPP_RETRY_COUNT", 3),
+		AutoConnect:      getEnvAsBoolOrDefault("WHATSAPP_AUTO_CONNECT", true),
+		MaxMessageLength: getEnvAsIntOrDefault("WHATSAPP_MAX_MESSAGE_LENGTH", 4096),
+		MediaSaveDir:     os.Getenv("MEDIA_SAVE_DIR"),
+		EventWorkers:     getEnvAsIntOrDefault("WHATSAPP_EVENT_WORKERS", 4),
+		EventBufferSize:  getEnvAsIntOrDefault("WHATSAPP_EVENT_BUFFER_SIZE", 1000),
+		SessionLimit:     getEnvOrDefault("WHATSAPP_SESSION_LIMIT_POLICY", "reject"),
+		PersistJobs:      getEnvAsBoolOrDefault("WHATSAPP_PERSIST_JOBS", true),
+		PairClient:       getEnvOrDefault("WHATSAPP_PAIR_CLIENT", "chrome"),
+		PairDisplay:      getEnvOrDefault("WHATSAPP_PAIR_DISPLAY", "Chrome (Linux)"),
+		GroupBatchSize:   getEnvAsIntOrDefault("WHATSAPP_GROUP_BATCH_SIZE", 20),
+		GroupBatchDelay:  getEnvAsDurationOrDefault("WHATSAPP_GROUP_BATCH_DELAY", time.Second),
+		ProxyCheckURL:    getEnvOrDefault("WHATSAPP_PROXY_CHECK_URL", "https://web.whatsapp.com"),
+		StartupDelay:     getEnvAsDurationOrDefault("WHATSAPP_STARTUP_DELAY", 0),
+		EventDedupTTL:    getEnvAsDurationOrDefault("WHATSAPP_EVENT_DEDUP_TTL", 10*time.Minute),
+		EventDedupSize:   getEnvAsIntOrDefault("WHATSAPP_EVENT_DEDUP_SIZE", 10000),
+		QRRotation:       getEnvAsDurationOrDefault("WHATSAPP_QR_ROTATION", 20*time.Second),
+		MaxCachedDevices: getEnvAsIntOrDefault("WHATSAPP_MAX_CACHED_DEVICES", 100),
+
+		CaptureUnknownEvents: getEnvAsBoolOrDefault("WHATSAPP_CAPTURE_UNKNOWN_EVENTS", false),
+		DailySendQuota:       getEnvAsIntOrDefault("WHATSAPP_DAILY_SEND_QUOTA", 0),
 	}
 }
 
@@ -158,17 +218,20 @@ func loadLoggingConfig() LoggingConfig {
 }
 
 func loadWebhookConfig() WebhookConfig {
-	eventsStr := getEnvOrDefault("WEBHOOK_EVENTS", "message,presence,receipt")
+	eventsStr := getEnvOrDefault("WEBHOOK_EVENTS", "")
 	events := strings.Split(eventsStr, ",")
 	for i, event := range events {
 		events[i] = strings.TrimSpace(event)
 	}
 
 	return WebhookConfig{
-		GlobalURL: os.Getenv("WEBHOOK_GLOBAL_URL"),
-		Timeout:   getEnvAsDurationOrDefault("WEBHOOK_TIMEOUT", 10*time.Second),
-		Retries:   getEnvAsIntOrDefault("WEBHOOK_RETRIES", 3),
-		Events:    events,
+		GlobalURL:  os.Getenv("WEBHOOK_GLOBAL_URL"),
+		Timeout:    getEnvAsDurationOrDefault("WEBHOOK_TIMEOUT", 10*time.Second),
+		Retries:    getEnvAsIntOrDefault("WEBHOOK_RETRIES", 3),
+		MaxElapsed: getEnvAsDurationOrDefault("WEBHOOK_MAX_ELAPSED", 2*time.Minute),
+		Events:     events,
+		Compress:   getEnvAsBoolOrDefault("WEBHOOK_COMPRESS", false),
+		Secret:     os.Getenv("WEBHOOK_SECRET"),
 	}
 }
 
@@ -178,6 +241,15 @@ func (c *Config) Validate() error {
 	if c.Server.Port <= 0 || c.Server.Port > 65535 {
 		return fmt.Errorf("invalid server port: %d", c.Server.Port)
 	}
+	if c.Server.ShutdownTimeout <= 0 {
+		return fmt.Errorf("invalid shutdown timeout: %s", c.Server.ShutdownTimeout)
+	}
+	if c.Server.SSEHeartbeat < 0 {
+		return fmt.Errorf("invalid SSE heartbeat interval: %s", c.Server.SSEHeartbeat)
+	}
+	if c.Server.SendResponse != "full" && c.Server.SendResponse != "minimal" {
+		return fmt.Errorf("invalid send response format: %s", c.Server.SendResponse)
+	}
 
 	// Validate TLS config
 	if c.Server.TLS.Enabled {
@@ -197,6 +269,35 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("database name is required")
 	}
 
+	// Validate WhatsApp config
+	if c.WhatsApp.SessionLimit != "reject" && c.WhatsApp.SessionLimit != "evict_lru" {
+		return fmt.Errorf("invalid session limit policy: %s", c.WhatsApp.SessionLimit)
+	}
+	if c.WhatsApp.DailySendQuota < 0 {
+		return fmt.Errorf("invalid daily send quota: %d", c.WhatsApp.DailySendQuota)
+	}
+	if c.WhatsApp.GroupBatchSize <= 0 {
+		return fmt.Errorf("invalid group batch size: %d", c.WhatsApp.GroupBatchSize)
+	}
+	if c.WhatsApp.GroupBatchDelay < 0 {
+		return fmt.Errorf("invalid group batch delay: %s", c.WhatsApp.GroupBatchDelay)
+	}
+	if c.WhatsApp.StartupDelay < 0 {
+		return fmt.Errorf("invalid startup delay: %s", c.WhatsApp.StartupDelay)
+	}
+	if c.WhatsApp.EventDedupTTL < 0 {
+		return fmt.Errorf("invalid event dedup TTL: %s", c.WhatsApp.EventDedupTTL)
+	}
+	if c.WhatsApp.EventDedupSize <= 0 {
+		return fmt.Errorf("invalid event dedup size: %d", c.WhatsApp.EventDedupSize)
+	}
+	if c.WhatsApp.QRRotation <= 0 {
+		return fmt.Errorf("invalid QR rotation window: %s", c.WhatsApp.QRRotation)
+	}
+	if c.WhatsApp.MaxCachedDevices <= 0 {
+		return fmt.Errorf("invalid max cached devices: %d", c.WhatsApp.MaxCachedDevices)
+	}
+
 	// Validate logging config
 	if !isValidLogLevel(c.Logging.Level) {
 		return fmt.Errorf("invalid log level: %s", c.Logging.Level)
@@ -205,6 +306,19 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("invalid log format: %s", c.Logging.Format)
 	}
 
+	// Validate webhook config
+	if c.Webhook.GlobalURL != "" && !isValidWebhookURL(c.Webhook.GlobalURL) {
+		return fmt.Errorf("invalid webhook global URL %q: must be an absolute http(s) URL", c.Webhook.GlobalURL)
+	}
+	for _, event := range c.Webhook.Events {
+		if event == "" {
+			continue
+		}
+		if !domain.EventType(event).IsValid() {
+			return fmt.Errorf("invalid webhook event: %s", event)
+		}
+	}
+
 	return nil
 }
 
@@ -272,6 +386,38 @@ func (c *Config) GetDatabaseDSN() string {
 	)
 }
 
+// redactedValue replaces secrets in the redacted configuration
+const redactedValue = "[redacted]"
+
+// Redacted returns a copy of the configuration that is safe to show: keys
+// and passwords are masked and credentials are removed from URLs
+func (c *Config) Redacted() Config {
+	redacted := *c
+
+	if redacted.Server.APIKey != "" {
+		redacted.Server.APIKey = redactedValue
+	}
+	redacted.Server.CredentialsKey = ""
+	if redacted.Database.Password != "" {
+		redacted.Database.Password = redactedValue
+	}
+	redacted.Webhook.Secret = ""
+	redacted.Webhook.GlobalURL = redactURL(redacted.Webhook.GlobalURL)
+	redacted.WhatsApp.ProxyCheckURL = redactURL(redacted.WhatsApp.ProxyCheckURL)
+	redacted.Webhook.Events = append([]string(nil), c.Webhook.Events...)
+
+	return redacted
+}
+
+// redactURL masks the password of a URL that carries credentials
+func redactURL(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.User == nil {
+		return rawURL
+	}
+	return u.Redacted()
+}
+
 // Helper functions
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -307,6 +453,14 @@ func getEnvAsDurationOrDefault(key string, defaultValue time.Duration) time.Dura
 	return defaultValue
 }
 
+func isValidWebhookURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
 func isValidLogLevel(level string) bool {
 	validLevels := []string{"trace", "debug", "info", "warn", "error", "fatal", "panic"}
 	for _, validLevel := range validLevels {
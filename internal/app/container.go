@@ -25,9 +25,14 @@ type Container struct {
 
 	// Repositories
 	sessionRepo domain.Repository
+	messageRepo domain.MessageRepository
+	jobRepo     domain.JobRepository // nil when pending jobs are not persisted
+	counterRepo domain.SendCounterRepository
 
 	// Use Cases
-	createSessionUC *services.CreateSessionUseCase
+	createSessionUC      *services.CreateSessionUseCase
+	updateSessionUC      *services.UpdateSessionUseCase
+	sessionCredentialsUC *services.SessionCredentialsUseCase
 }
 
 // NewContainer creates a new dependency injection container
@@ -89,6 +94,7 @@ func (c *Container) initializeWhatsApp() error {
 		return fmt.Errorf("failed to create WhatsApp store manager: %w", err)
 	}
 
+	storeManager.SetMaxDevices(c.config.WhatsApp.MaxCachedDevices)
 	c.whatsappStoreManager = storeManager
 
 	log.Info().Msg("WhatsApp initialized successfully")
@@ -98,6 +104,11 @@ func (c *Container) initializeWhatsApp() error {
 // initializeRepositories sets up all repositories
 func (c *Container) initializeRepositories() error {
 	c.sessionRepo = repository.NewSessionRepository(c.db.DB)
+	c.messageRepo = repository.NewMessageRepository(c.db.DB)
+	c.counterRepo = repository.NewSendCounterRepository(c.db.DB)
+	if c.config.WhatsApp.PersistJobs {
+		c.jobRepo = repository.NewJobRepository(c.db.DB)
+	}
 
 	log.Info().Msg("Repositories initialized successfully")
 	return nil
@@ -106,7 +117,35 @@ func (c *Container) initializeRepositories() error {
 // initializeMultiSessionManager sets up the multi-session manager
 func (c *Container) initializeMultiSessionManager() error {
 	// Create multi-session manager
-	multiSessionManager := services.NewMultiSessionManager(c.whatsappStoreManager, c.sessionRepo)
+	webhooks := services.NewWebhookDispatcher(
+		c.config.Webhook.GlobalURL,
+		c.config.Webhook.Timeout,
+		c.config.Webhook.Retries,
+		c.config.Webhook.MaxElapsed,
+		c.config.Webhook.Compress,
+		c.config.Webhook.Secret,
+	)
+
+	multiSessionManager := services.NewMultiSessionManager(c.whatsappStoreManager, c.sessionRepo, c.messageRepo, c.jobRepo, c.counterRepo, webhooks, c.config.Server.PublicURL)
+	if c.config.Server.MaintenanceMode {
+		multiSessionManager.SetMaintenance(true)
+	}
+	multiSessionManager.SetSessionLimitPolicy(services.SessionLimitPolicy(c.config.WhatsApp.SessionLimit))
+	multiSessionManager.SetEventConcurrency(c.config.WhatsApp.EventWorkers, c.config.WhatsApp.EventBufferSize)
+	multiSessionManager.SetEventDedup(c.config.WhatsApp.EventDedupTTL, c.config.WhatsApp.EventDedupSize)
+	multiSessionManager.SetDefaultEvents(c.config.Webhook.Events)
+	multiSessionManager.SetCaptureUnknownEvents(c.config.WhatsApp.CaptureUnknownEvents)
+	multiSessionManager.SetDailySendQuota(c.config.WhatsApp.DailySendQuota)
+	multiSessionManager.SetStartupDelay(c.config.WhatsApp.StartupDelay)
+	multiSessionManager.SetQRRotation(c.config.WhatsApp.QRRotation)
+	multiSessionManager.SetProxyCheckURL(c.config.WhatsApp.ProxyCheckURL)
+	multiSessionManager.SetGroupBatching(c.config.WhatsApp.GroupBatchSize, c.config.WhatsApp.GroupBatchDelay)
+	if err := multiSessionManager.SetPairClient(c.config.WhatsApp.PairClient, c.config.WhatsApp.PairDisplay); err != nil {
+		return fmt.Errorf("failed to configure pairing client: %w", err)
+	}
+	if c.config.WhatsApp.MediaSaveDir != "" {
+		multiSessionManager.SetMediaSaveDir(c.config.WhatsApp.MediaSaveDir)
+	}
 	c.multiSessionManager = multiSessionManager
 
 	log.Info().Msg("Multi-session manager initialized successfully")
@@ -116,6 +155,13 @@ func (c *Container) initializeMultiSessionManager() error {
 // initializeUseCases sets up all use cases
 func (c *Container) initializeUseCases() error {
 	c.createSessionUC = services.NewCreateSessionUseCase(c.sessionRepo)
+	c.updateSessionUC = services.NewUpdateSessionUseCase(c.sessionRepo)
+	c.sessionCredentialsUC = services.NewSessionCredentialsUseCase(
+		c.whatsappStoreManager,
+		c.multiSessionManager,
+		c.sessionRepo,
+		c.config.Server.CredentialsKey,
+	)
 
 	log.Info().Msg("Use cases initialized successfully")
 	return nil
@@ -148,10 +194,22 @@ func (c *Container) SessionRepository() domain.Repository {
 	return c.sessionRepo
 }
 
+func (c *Container) MessageRepository() domain.MessageRepository {
+	return c.messageRepo
+}
+
 func (c *Container) CreateSessionUseCase() *services.CreateSessionUseCase {
 	return c.createSessionUC
 }
 
+func (c *Container) UpdateSessionUseCase() *services.UpdateSessionUseCase {
+	return c.updateSessionUC
+}
+
+func (c *Container) SessionCredentialsUseCase() *services.SessionCredentialsUseCase {
+	return c.sessionCredentialsUC
+}
+
 func (c *Container) MultiSessionManager() *services.MultiSessionManager {
 	return c.multiSessionManager
 }
@@ -3,6 +3,7 @@ package app
 import (
 	"context"
 	"fmt"
+	"net"
 	"net/http"
 	"time"
 
@@ -24,16 +25,30 @@ func NewServer(container *Container) *Server {
 	// Create session handler
 	sessionHandler := handlers.NewSessionHandler(
 		container.CreateSessionUseCase(),
+		container.UpdateSessionUseCase(),
+		container.SessionCredentialsUseCase(),
 		container.MultiSessionManager(),
 		container.SessionRepository(),
 	)
 
 	messageHandler := handlers.NewMessageHandler(
 		container.MultiSessionManager(),
+		container.Config().WhatsApp.MaxMessageLength,
+		container.Config().Server.SSEHeartbeat,
+		container.Config().Server.SendResponse,
 	)
 
+	contactHandler := handlers.NewContactHandler(container.MultiSessionManager())
+	groupHandler := handlers.NewGroupHandler(container.MultiSessionManager())
+	adminHandler := handlers.NewAdminHandler(container.MultiSessionManager(), container.Config())
+
+	var mediaFileHandler *handlers.MediaFileHandler
+	if dir := container.Config().WhatsApp.MediaSaveDir; dir != "" {
+		mediaFileHandler = handlers.NewMediaFileHandler(dir)
+	}
+
 	// Setup router
-	appRouter := router.NewRouter(sessionHandler, messageHandler)
+	appRouter := router.NewRouter(sessionHandler, messageHandler, contactHandler, groupHandler, adminHandler, mediaFileHandler, container.Config().Server.APIKey)
 	handler := appRouter.SetupRoutes()
 
 	server := &Server{
@@ -62,25 +77,57 @@ func (s *Server) setupHTTPServer() {
 	log.Info().Msg("HTTP server configured successfully")
 }
 
+// signalReady tells the session manager the app is ready once the database
+// answers, retrying until it does or the server shuts down
+func (s *Server) signalReady(ctx context.Context) {
+	for {
+		checkCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		err := s.container.Database().Health(checkCtx)
+		cancel()
+
+		if err == nil {
+			log.Info().Msg("Application ready, reconnecting stored sessions")
+			s.container.MultiSessionManager().MarkReady()
+			return
+		}
+
+		log.Warn().Err(err).Msg("Database not ready, delaying session reconnection")
+		select {
+		case <-time.After(time.Second):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
 // Start starts the HTTP server
 func (s *Server) Start(ctx context.Context) error {
 	cfg := s.container.Config()
 
 	// Start server in a goroutine
 	go func() {
-		var err error
+		// Keepalive probes stop load balancers from dropping idle long-lived streams
+		listenConfig := net.ListenConfig{KeepAlive: cfg.Server.TCPKeepAlive}
+		listener, err := listenConfig.Listen(context.Background(), "tcp", cfg.GetServerAddress())
+		if err != nil {
+			log.Fatal().Err(err).Msg("Server failed to listen")
+		}
+
+		// Stored sessions reconnect once requests can be served
+		go s.signalReady(ctx)
+
 		if cfg.Server.TLS.Enabled {
 			log.Info().
 				Str("address", cfg.GetServerAddress()).
 				Bool("tls", true).
 				Msg("Starting HTTPS server")
-			err = s.server.ListenAndServeTLS(cfg.Server.TLS.CertFile, cfg.Server.TLS.KeyFile)
+			err = s.server.ServeTLS(listener, cfg.Server.TLS.CertFile, cfg.Server.TLS.KeyFile)
 		} else {
 			log.Info().
 				Str("address", cfg.GetServerAddress()).
 				Bool("tls", false).
 				Msg("Starting HTTP server")
-			err = s.server.ListenAndServe()
+			err = s.server.Serve(listener)
 		}
 
 		if err != nil && err != http.ErrServerClosed {
@@ -94,7 +141,7 @@ func (s *Server) Start(ctx context.Context) error {
 	log.Info().Msg("Shutting down server...")
 
 	// Create shutdown context with timeout
-	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 
 	// Shutdown server gracefully
@@ -102,6 +149,11 @@ func (s *Server) Start(ctx context.Context) error {
 		return fmt.Errorf("server shutdown failed: %w", err)
 	}
 
+	// Disconnect WhatsApp sessions
+	if err := s.container.MultiSessionManager().Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("session shutdown failed: %w", err)
+	}
+
 	log.Info().Msg("Server stopped gracefully")
 	return nil
 }
@@ -0,0 +1,24 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// PendingJob is a queued send persisted so it survives restarts
+type PendingJob struct {
+	bun.BaseModel `bun:"table:pending_jobs,alias:j"`
+
+	ID         string     `bun:",pk" json:"id"`
+	SessionID  SessionID  `bun:"session_id,notnull" json:"session_id"`
+	Type       string     `bun:"type,notnull" json:"type"`
+	Recipient  string     `bun:"recipient,notnull" json:"recipient"`
+	Phone      string     `bun:"phone" json:"phone"`
+	MessageID  string     `bun:"message_id,notnull" json:"message_id"`
+	Payload    []byte     `bun:"payload,notnull" json:"-"` // protobuf-encoded message
+	Priority   string     `bun:"priority,notnull,default:'normal'" json:"priority"`
+	Attempts   int        `bun:"attempts,notnull,default:0" json:"attempts"`
+	EnqueuedAt time.Time  `bun:"enqueued_at,notnull" json:"enqueued_at"`
+	ExpiresAt  *time.Time `bun:"expires_at,nullzero" json:"expires_at,omitempty"`
+}
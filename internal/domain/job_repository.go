@@ -0,0 +1,15 @@
+package domain
+
+import "context"
+
+// JobRepository defines the interface for pending job persistence
+type JobRepository interface {
+	// Save stores a pending job, replacing it if already stored
+	Save(ctx context.Context, job *PendingJob) error
+
+	// Delete removes a job once it ran or was cancelled
+	Delete(ctx context.Context, id string) error
+
+	// List returns every pending job, oldest first
+	List(ctx context.Context) ([]*PendingJob, error)
+}
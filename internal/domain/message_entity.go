@@ -0,0 +1,78 @@
+package domain
+
+import (
+	"time"
+
+	"github.com/uptrace/bun"
+)
+
+// Message represents a WhatsApp message persisted for a session
+type Message struct {
+	bun.BaseModel `bun:"table:messages,alias:m"`
+
+	ID              string      `bun:",pk" json:"id"`
+	SessionID       SessionID   `bun:"session_id,pk" json:"session_id"`
+	ChatJID         string      `bun:"chat_jid,notnull" json:"chat_jid"`
+	SenderJID       string      `bun:"sender_jid" json:"sender_jid"`
+	FromMe          bool        `bun:"from_me,notnull,default:false" json:"from_me"`
+	Type            MessageType `bun:"type,notnull" json:"type"`
+	Body            string      `bun:"body" json:"body,omitempty"`
+	Caption         string      `bun:"caption" json:"caption,omitempty"`
+	MimeType        string      `bun:"mime_type" json:"mime_type,omitempty"`
+	QuotedMessageID string      `bun:"quoted_message_id" json:"quoted_message_id,omitempty"`
+
+	// Media download metadata, empty for non-media messages
+	DirectPath    string `bun:"direct_path" json:"-"`
+	MediaKey      []byte `bun:"media_key" json:"-"`
+	FileSHA256    []byte `bun:"file_sha256" json:"-"`
+	FileEncSHA256 []byte `bun:"file_enc_sha256" json:"-"`
+	FileLength    int64  `bun:"file_length" json:"file_length,omitempty"`
+
+	// Delivery status of messages sent through the API, empty otherwise
+	Status MessageStatus `bun:"status,default:''" json:"status,omitempty"`
+
+	Timestamp time.Time `bun:"timestamp,notnull" json:"timestamp"`
+	CreatedAt time.Time `bun:",nullzero,notnull,default:current_timestamp" json:"created_at"`
+}
+
+// MessageStatus is the delivery status of a sent message
+type MessageStatus string
+
+const (
+	MessageStatusFailed    MessageStatus = "failed"
+	MessageStatusSent      MessageStatus = "sent"
+	MessageStatusDelivered MessageStatus = "delivered"
+	MessageStatusRead      MessageStatus = "read"
+	MessageStatusPlayed    MessageStatus = "played"
+)
+
+// messageStatusOrder lists the statuses in the order a message progresses
+var messageStatusOrder = []MessageStatus{
+	MessageStatusFailed,
+	MessageStatusSent,
+	MessageStatusDelivered,
+	MessageStatusRead,
+	MessageStatusPlayed,
+}
+
+// Preceding returns the statuses a message moves on from to reach s
+func (s MessageStatus) Preceding() []MessageStatus {
+	for i, status := range messageStatusOrder {
+		if status == s {
+			return messageStatusOrder[:i]
+		}
+	}
+	return nil
+}
+
+// MessageStatusCount is the number of sent messages of a type in a status
+type MessageStatusCount struct {
+	Type   MessageType   `bun:"type"`
+	Status MessageStatus `bun:"status"`
+	Count  int           `bun:"count"`
+}
+
+// HasMedia reports whether the message carries downloadable media
+func (m *Message) HasMedia() bool {
+	return m.DirectPath != "" && len(m.MediaKey) > 0
+}
@@ -0,0 +1,30 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// MessageRepository defines the interface for message persistence
+type MessageRepository interface {
+	// Save stores a message, ignoring it if already stored
+	Save(ctx context.Context, message *Message) error
+
+	// SaveBatch stores multiple messages, ignoring the ones already stored
+	SaveBatch(ctx context.Context, messages []*Message) error
+
+	// GetByID retrieves a message of a session by its ID
+	GetByID(ctx context.Context, sessionID SessionID, id string) (*Message, error)
+
+	// ListByChat retrieves up to limit messages of a chat older than the
+	// (before, beforeID) cursor, newest first. Messages sharing a timestamp
+	// are ordered by ID; an empty beforeID excludes the whole second. A zero
+	// time lists from the newest message.
+	ListByChat(ctx context.Context, sessionID SessionID, chatJID string, limit int, before time.Time, beforeID string) ([]*Message, error)
+
+	// AdvanceStatus moves sent messages to status unless they already progressed past it
+	AdvanceStatus(ctx context.Context, sessionID SessionID, ids []string, status MessageStatus) error
+
+	// CountByStatus counts the sent messages of a session in [from, to) by type and status
+	CountByStatus(ctx context.Context, sessionID SessionID, from, to time.Time) ([]MessageStatusCount, error)
+}
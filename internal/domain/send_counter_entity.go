@@ -0,0 +1,15 @@
+package domain
+
+import (
+	"github.com/uptrace/bun"
+)
+
+// SendCounter counts the messages a session sent on one UTC day, for the
+// daily send quota
+type SendCounter struct {
+	bun.BaseModel `bun:"table:send_counters,alias:sc"`
+
+	SessionID SessionID `bun:"session_id,pk" json:"session_id"`
+	Day       string    `bun:"day,pk" json:"day"` // UTC date, YYYY-MM-DD
+	Count     int       `bun:"count,notnull,default:0" json:"count"`
+}
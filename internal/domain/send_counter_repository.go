@@ -0,0 +1,19 @@
+package domain
+
+import (
+	"context"
+	"time"
+)
+
+// SendCounterRepository defines the interface for daily send counter persistence
+type SendCounterRepository interface {
+	// Increment counts one more send of a session on day unless limit sends
+	// were already counted. It returns the count and whether the send was counted.
+	Increment(ctx context.Context, sessionID SessionID, day time.Time, limit int) (int, bool, error)
+
+	// Decrement gives back a send counted on day that never reached WhatsApp
+	Decrement(ctx context.Context, sessionID SessionID, day time.Time) error
+
+	// Get returns the sends counted for a session on day
+	Get(ctx context.Context, sessionID SessionID, day time.Time) (int, error)
+}
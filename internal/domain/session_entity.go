@@ -5,6 +5,7 @@ import (
 	"net/url"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 	"github.com/uptrace/bun"
@@ -67,35 +68,117 @@ func (s Status) IsValid() bool {
 	}
 }
 
+// MediaDelivery represents how inbound media is delivered in events
+type MediaDelivery string
+
+const (
+	MediaDeliveryBase64 MediaDelivery = "base64" // media bytes embedded in the event
+	MediaDeliveryURL    MediaDelivery = "url"    // link to the WazMeow download endpoint
+	MediaDeliveryS3     MediaDelivery = "s3"     // uploaded to the session's S3 bucket
+)
+
+// IsValid checks if the media delivery mode is valid
+func (m MediaDelivery) IsValid() bool {
+	switch m {
+	case MediaDeliveryBase64, MediaDeliveryURL, MediaDeliveryS3:
+		return true
+	default:
+		return false
+	}
+}
+
 // Session represents a WhatsApp session
 type Session struct {
 	bun.BaseModel `bun:"table:sessions,alias:s"`
 
-	ID              SessionID  `bun:",pk" json:"id"`
-	Name            string     `bun:",notnull,unique" json:"name"`
-	Status          Status     `bun:",default:'disconnected'" json:"status"`
-	WebhookURL      string     `bun:"webhook_url" json:"webhook_url"`
-	WAJID           string     `bun:"wa_jid" json:"wa_jid"`
-	QRCode          string     `bun:"qr_code" json:"qr_code"`
-	Events          string     `bun:",default:''" json:"events"`
-	ProxyURL        string     `bun:"proxy_url" json:"proxy_url"`
-	DeviceName      string     `bun:"device_name,default:'WazMeow'" json:"device_name"`
-	IsActive        bool       `bun:"is_active,default:true" json:"is_active"`
-	CreatedAt       time.Time  `bun:",nullzero,notnull,default:current_timestamp" json:"created_at"`
-	UpdatedAt       time.Time  `bun:",nullzero,notnull,default:current_timestamp" json:"updated_at"`
-	LastConnectedAt *time.Time `bun:"last_connected_at,nullzero" json:"last_connected_at,omitempty"`
+	ID              SessionID     `bun:",pk" json:"id"`
+	Name            string        `bun:",notnull,unique" json:"name"`
+	Status          Status        `bun:",default:'disconnected'" json:"status"`
+	WebhookURL      string        `bun:"webhook_url" json:"webhook_url"`
+	WebhookCompress bool          `bun:"webhook_compress,notnull,default:false" json:"webhook_compress"`
+	WebhookSecret   string        `bun:"webhook_secret" json:"-"` // overrides the global signing secret
+	WAJID           string        `bun:"wa_jid" json:"wa_jid"`
+	QRCode          string        `bun:"qr_code" json:"qr_code"`
+	QRGeneratedAt   *time.Time    `bun:"qr_generated_at,nullzero" json:"qr_generated_at,omitempty"`
+	QRExpiresAt     *time.Time    `bun:"qr_expires_at,nullzero" json:"qr_expires_at,omitempty"`
+	Events          string        `bun:",default:''" json:"events"`
+	ProxyURL        string        `bun:"proxy_url" json:"proxy_url"`
+	DeviceName      string        `bun:"device_name,default:'WazMeow'" json:"device_name"`
+	Allowlist       string        `bun:"recipient_allowlist,default:''" json:"recipient_allowlist"`
+	IgnoredChats    string        `bun:"ignored_chats,default:''" json:"ignored_chats"`
+	Timezone        string        `bun:"timezone,default:''" json:"timezone"`
+	MediaDelivery   MediaDelivery `bun:"media_delivery,notnull,default:'base64'" json:"media_delivery"`
+	CaptureHistory  bool          `bun:"capture_history,notnull,default:false" json:"capture_history"`
+	IsActive        bool          `bun:"is_active,default:true" json:"is_active"`
+	CreatedAt       time.Time     `bun:",nullzero,notnull,default:current_timestamp" json:"created_at"`
+	UpdatedAt       time.Time     `bun:",nullzero,notnull,default:current_timestamp" json:"updated_at"`
+	LastConnectedAt *time.Time    `bun:"last_connected_at,nullzero" json:"last_connected_at,omitempty"`
+
+	// Privacy flags applied after every connect
+	SendReadReceipts  bool `bun:"send_read_receipts,notnull,default:true" json:"send_read_receipts"`
+	BroadcastPresence bool `bun:"broadcast_presence,notnull,default:true" json:"broadcast_presence"`
+	// Set while the account's read receipts are off because send_read_receipts
+	// turned them off, so turning it back on can restore them
+	ReadReceiptsDisabled bool `bun:"read_receipts_disabled,notnull,default:false" json:"-"`
+
+	// Disappearing timer given to outbound messages in chats without one, 0 for none
+	DefaultEphemeralSeconds int `bun:"default_ephemeral_seconds,notnull,default:0" json:"default_ephemeral_seconds"`
+
+	// Messages sent per UTC day: 0 uses the instance default, -1 is unlimited
+	DailySendQuota int `bun:"daily_send_quota,notnull,default:0" json:"daily_send_quota"`
+
+	// Bucket inbound media is uploaded to when media delivery is s3
+	S3Enabled   bool   `bun:"s3_enabled,notnull,default:false" json:"s3_enabled"`
+	S3Endpoint  string `bun:"s3_endpoint" json:"s3_endpoint"`
+	S3Region    string `bun:"s3_region" json:"s3_region"`
+	S3Bucket    string `bun:"s3_bucket" json:"s3_bucket"`
+	S3AccessKey string `bun:"s3_access_key" json:"-"`
+	S3SecretKey string `bun:"s3_secret_key" json:"-"`
+	S3PathStyle bool   `bun:"s3_path_style,notnull,default:false" json:"s3_path_style"`
+	S3PublicURL string `bun:"s3_public_url" json:"s3_public_url"`
+	// Days uploaded media is meant to be kept, for the bucket's lifecycle rules; 0 keeps it
+	S3RetentionDays int `bun:"s3_retention_days,notnull,default:0" json:"s3_retention_days"`
+}
+
+const (
+	// MinSessionNameLength is the minimum length of a trimmed session name
+	MinSessionNameLength = 1
+	// MaxSessionNameLength is the maximum length of a trimmed session name
+	MaxSessionNameLength = 255
+)
+
+// NormalizeSessionName trims surrounding whitespace from a session name
+// and checks its length, returning the name as it must be stored
+func NormalizeSessionName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	length := utf8.RuneCountInString(name)
+
+	switch {
+	case length == 0:
+		return "", ErrInvalidSessionName("name cannot be empty")
+	case length < MinSessionNameLength:
+		return "", ErrInvalidSessionName(fmt.Sprintf("name must be at least %d characters", MinSessionNameLength))
+	case length > MaxSessionNameLength:
+		return "", ErrInvalidSessionName(fmt.Sprintf("name cannot exceed %d characters", MaxSessionNameLength))
+	}
+
+	return name, nil
 }
 
 // NewSession creates a new session with the given name
 func NewSession(name string) *Session {
 	now := time.Now()
 	return &Session{
-		ID:        NewSessionID(),
-		Name:      strings.TrimSpace(name),
-		Status:    StatusDisconnected,
-		IsActive:  true,
-		CreatedAt: now,
-		UpdatedAt: now,
+		ID:            NewSessionID(),
+		Name:          strings.TrimSpace(name),
+		Status:        StatusDisconnected,
+		MediaDelivery: MediaDeliveryBase64,
+		IsActive:      true,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+
+		SendReadReceipts:  true,
+		BroadcastPresence: true,
 	}
 }
 
@@ -136,8 +219,9 @@ func RestoreSession(
 
 // Business methods
 func (s *Session) UpdateName(name string) error {
-	if name == "" {
-		return NewValidationError("name cannot be empty")
+	name, err := NormalizeSessionName(name)
+	if err != nil {
+		return err
 	}
 	s.Name = name
 	s.UpdatedAt = time.Now()
@@ -158,23 +242,250 @@ func (s *Session) SetWAJID(wajid string) {
 	s.UpdatedAt = time.Now()
 }
 
-func (s *Session) SetQRCode(qrCode string) {
+func (s *Session) SetQRCode(qrCode string, expiresAt time.Time) {
 	s.QRCode = qrCode
+	s.QRGeneratedAt = nil
+	s.QRExpiresAt = nil
+	if qrCode != "" {
+		now := time.Now()
+		s.QRGeneratedAt = &now
+		s.QRExpiresAt = &expiresAt
+	}
 	s.UpdatedAt = time.Now()
 }
 
+// SetProxyURL sets the proxy the session's WhatsApp connection goes through.
+// Only http, https and socks5 proxies are supported, an empty URL clears it.
 func (s *Session) SetProxyURL(proxyURL string) error {
+	proxyURL = strings.TrimSpace(proxyURL)
 	if proxyURL != "" {
 		// Validate proxy URL format
-		if _, err := url.Parse(proxyURL); err != nil {
+		parsed, err := url.Parse(proxyURL)
+		if err != nil || parsed.Host == "" {
 			return NewValidationError("invalid proxy URL format")
 		}
+		switch parsed.Scheme {
+		case "http", "https", "socks5":
+		default:
+			return NewValidationError("unsupported proxy scheme: " + parsed.Scheme + " (use http, https or socks5)")
+		}
 	}
 	s.ProxyURL = proxyURL
 	s.UpdatedAt = time.Now()
 	return nil
 }
 
+// SetWebhookURL sets the URL the session's events are posted to, empty
+// meaning the global webhook
+func (s *Session) SetWebhookURL(webhookURL string) error {
+	webhookURL = strings.TrimSpace(webhookURL)
+	if webhookURL != "" {
+		parsed, err := url.Parse(webhookURL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return NewValidationError("invalid webhook URL: must be an absolute http(s) URL")
+		}
+	}
+	s.WebhookURL = webhookURL
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+// SetMediaDelivery sets how inbound media is delivered
+func (s *Session) SetMediaDelivery(mode MediaDelivery) error {
+	if !mode.IsValid() {
+		return NewValidationError("invalid media delivery mode: " + string(mode))
+	}
+	s.MediaDelivery = mode
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+// S3Config holds the bucket settings of a session
+type S3Config struct {
+	Enabled   bool
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	PathStyle bool
+	PublicURL string
+
+	RetentionDays int
+}
+
+// S3Config returns the session's bucket settings
+func (s *Session) S3Config() S3Config {
+	return S3Config{
+		Enabled:   s.S3Enabled,
+		Endpoint:  s.S3Endpoint,
+		Region:    s.S3Region,
+		Bucket:    s.S3Bucket,
+		AccessKey: s.S3AccessKey,
+		SecretKey: s.S3SecretKey,
+		PathStyle: s.S3PathStyle,
+		PublicURL: s.S3PublicURL,
+
+		RetentionDays: s.S3RetentionDays,
+	}
+}
+
+// SetS3Config sets the bucket inbound media is uploaded to. An enabled
+// config needs an endpoint, a bucket and both keys.
+func (s *Session) SetS3Config(cfg S3Config) error {
+	cfg.Endpoint = strings.TrimRight(strings.TrimSpace(cfg.Endpoint), "/")
+	cfg.PublicURL = strings.TrimRight(strings.TrimSpace(cfg.PublicURL), "/")
+	cfg.Bucket = strings.TrimSpace(cfg.Bucket)
+	cfg.Region = strings.TrimSpace(cfg.Region)
+
+	for _, value := range []string{cfg.Endpoint, cfg.PublicURL} {
+		if value == "" {
+			continue
+		}
+		parsed, err := url.Parse(value)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return NewValidationError("invalid S3 URL: " + value + " (must be an absolute http(s) URL)")
+		}
+	}
+
+	if cfg.RetentionDays < 0 {
+		return NewValidationError("s3 retention days cannot be negative")
+	}
+
+	if cfg.Enabled && (cfg.Endpoint == "" || cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "") {
+		return NewValidationError("s3_endpoint, s3_bucket, s3_access_key and s3_secret_key are required to enable S3")
+	}
+
+	s.S3Enabled = cfg.Enabled
+	s.S3Endpoint = cfg.Endpoint
+	s.S3Region = cfg.Region
+	s.S3Bucket = cfg.Bucket
+	s.S3AccessKey = cfg.AccessKey
+	s.S3SecretKey = cfg.SecretKey
+	s.S3PathStyle = cfg.PathStyle
+	s.S3PublicURL = cfg.PublicURL
+	s.S3RetentionDays = cfg.RetentionDays
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+// SetDefaultEphemeral sets the disappearing timer of outbound messages in
+// chats that have none. Only the durations WhatsApp offers are accepted, 0
+// turns the default off.
+func (s *Session) SetDefaultEphemeral(seconds int) error {
+	switch seconds {
+	case 0, 24 * 60 * 60, 7 * 24 * 60 * 60, 90 * 24 * 60 * 60:
+	default:
+		return NewValidationError(fmt.Sprintf("invalid default ephemeral duration: %d seconds (use 0, 86400, 604800 or 7776000)", seconds))
+	}
+	s.DefaultEphemeralSeconds = seconds
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+// SetDailySendQuota sets how many messages the session may send per UTC
+// day. 0 uses the instance default and -1 lifts the limit.
+func (s *Session) SetDailySendQuota(quota int) error {
+	if quota < -1 {
+		return NewValidationError(fmt.Sprintf("invalid daily send quota: %d (use -1 for unlimited, 0 for the default)", quota))
+	}
+	s.DailySendQuota = quota
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+// SetTimezone sets the IANA timezone used to format response timestamps.
+// An empty name clears it.
+func (s *Session) SetTimezone(name string) error {
+	name = strings.TrimSpace(name)
+	if name != "" {
+		if _, err := time.LoadLocation(name); err != nil {
+			return NewValidationError("invalid timezone: " + name)
+		}
+	}
+	s.Timezone = name
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
+// Location returns the session timezone, falling back to UTC when unset
+func (s *Session) Location() *time.Location {
+	if s.Timezone == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(s.Timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// AllowedRecipients returns the recipient JIDs this session may message.
+// An empty list means every recipient is allowed.
+func (s *Session) AllowedRecipients() []string {
+	return splitList(s.Allowlist)
+}
+
+// SetAllowedRecipients replaces the recipient allowlist
+func (s *Session) SetAllowedRecipients(recipients []string) {
+	s.Allowlist = joinList(recipients)
+	s.UpdatedAt = time.Now()
+}
+
+// IsRecipientAllowed checks whether the given recipient JID may be messaged
+func (s *Session) IsRecipientAllowed(jid string) bool {
+	allowed := s.AllowedRecipients()
+	if len(allowed) == 0 {
+		return true
+	}
+	for _, recipient := range allowed {
+		if recipient == jid {
+			return true
+		}
+	}
+	return false
+}
+
+// IgnoredChatList returns the chat JIDs whose events are not delivered
+func (s *Session) IgnoredChatList() []string {
+	return splitList(s.IgnoredChats)
+}
+
+// IgnoreChat adds a chat JID to the ignore list
+func (s *Session) IgnoreChat(jid string) {
+	s.IgnoredChats = joinList(append(s.IgnoredChatList(), jid))
+	s.UpdatedAt = time.Now()
+}
+
+// UnignoreChat removes a chat JID from the ignore list
+func (s *Session) UnignoreChat(jid string) {
+	remaining := make([]string, 0)
+	for _, chat := range s.IgnoredChatList() {
+		if chat != jid {
+			remaining = append(remaining, chat)
+		}
+	}
+	s.IgnoredChats = joinList(remaining)
+	s.UpdatedAt = time.Now()
+}
+
+// SubscribedEvents returns the event types the session emits, empty meaning all
+func (s *Session) SubscribedEvents() []string {
+	return splitList(s.Events)
+}
+
+// SetSubscribedEvents replaces the event types the session emits
+func (s *Session) SetSubscribedEvents(events []string) error {
+	for _, event := range events {
+		if !EventType(strings.TrimSpace(event)).IsValid() {
+			return NewValidationError("invalid event type: " + event)
+		}
+	}
+	s.Events = joinList(events)
+	s.UpdatedAt = time.Now()
+	return nil
+}
+
 func (s *Session) Activate() {
 	s.IsActive = true
 	s.UpdatedAt = time.Now()
@@ -248,18 +559,63 @@ func (s *Session) HasError() bool {
 // ToMap converts session to map for serialization
 func (s *Session) ToMap() map[string]interface{} {
 	return map[string]interface{}{
-		"id":                s.ID.String(),
-		"name":              s.Name,
-		"status":            string(s.Status),
-		"webhook_url":       s.WebhookURL,
-		"wa_jid":            s.WAJID,
-		"qr_code":           s.QRCode,
-		"events":            s.Events,
-		"proxy_url":         s.ProxyURL,
-		"device_name":       s.DeviceName,
-		"is_active":         s.IsActive,
-		"created_at":        s.CreatedAt,
-		"updated_at":        s.UpdatedAt,
-		"last_connected_at": s.LastConnectedAt,
+		"id":                        s.ID.String(),
+		"name":                      s.Name,
+		"status":                    string(s.Status),
+		"webhook_url":               s.WebhookURL,
+		"webhook_compress":          s.WebhookCompress,
+		"wa_jid":                    s.WAJID,
+		"qr_code":                   s.QRCode,
+		"qr_generated_at":           s.QRGeneratedAt,
+		"qr_expires_at":             s.QRExpiresAt,
+		"events":                    s.Events,
+		"proxy_url":                 s.ProxyURL,
+		"device_name":               s.DeviceName,
+		"recipient_allowlist":       s.AllowedRecipients(),
+		"ignored_chats":             s.IgnoredChatList(),
+		"timezone":                  s.Timezone,
+		"media_delivery":            string(s.MediaDelivery),
+		"capture_history":           s.CaptureHistory,
+		"send_read_receipts":        s.SendReadReceipts,
+		"broadcast_presence":        s.BroadcastPresence,
+		"default_ephemeral_seconds": s.DefaultEphemeralSeconds,
+		"daily_send_quota":          s.DailySendQuota,
+		"s3_enabled":                s.S3Enabled,
+		"s3_endpoint":               s.S3Endpoint,
+		"s3_region":                 s.S3Region,
+		"s3_bucket":                 s.S3Bucket,
+		"s3_path_style":             s.S3PathStyle,
+		"s3_public_url":             s.S3PublicURL,
+		"s3_retention_days":         s.S3RetentionDays,
+		"is_active":                 s.IsActive,
+		"created_at":                s.CreatedAt,
+		"updated_at":                s.UpdatedAt,
+		"last_connected_at":         s.LastConnectedAt,
+	}
+}
+
+// splitList parses a comma-separated column value into its trimmed, non-empty items
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// joinList serializes items into a comma-separated column value, dropping blanks and duplicates
+func joinList(items []string) string {
+	seen := make(map[string]bool, len(items))
+	cleaned := make([]string, 0, len(items))
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" || seen[item] {
+			continue
+		}
+		seen[item] = true
+		cleaned = append(cleaned, item)
 	}
+	return strings.Join(cleaned, ",")
 }
@@ -1,6 +1,9 @@
 package domain
 
-import "context"
+import (
+	"context"
+	"time"
+)
 
 // Repository defines the interface for session persistence
 type Repository interface {
@@ -40,12 +43,43 @@ type Repository interface {
 	// SetWAJID sets the WhatsApp JID for a session
 	SetWAJID(ctx context.Context, id SessionID, wajid string) error
 
-	// SetQRCode sets the QR code for a session
-	SetQRCode(ctx context.Context, id SessionID, qrCode string) error
+	// SetQRCode sets the QR code for a session and when it expires
+	SetQRCode(ctx context.Context, id SessionID, qrCode string, expiresAt time.Time) error
 
 	// ClearQRCode clears the QR code for a session
 	ClearQRCode(ctx context.Context, id SessionID) error
 
+	// ClearExpiredQRCode clears the QR code for a session if it expired by
+	// the given time, leaving a newer code in place
+	ClearExpiredQRCode(ctx context.Context, id SessionID, now time.Time) error
+
+	// SetAllowlist sets the recipient allowlist for a session
+	SetAllowlist(ctx context.Context, id SessionID, recipients []string) error
+
+	// SetIgnoredChats sets the chats whose events are not delivered for a session
+	SetIgnoredChats(ctx context.Context, id SessionID, chats []string) error
+
+	// SetEvents sets the event types a session emits, all when empty
+	SetEvents(ctx context.Context, id SessionID, events []string) error
+
+	// SetWebhook sets the webhook URL, the event types it receives and the
+	// signing secret for a session
+	SetWebhook(ctx context.Context, id SessionID, webhookURL string, events []string, secret string) error
+
+	// SetS3Config sets the media bucket settings and the media delivery mode
+	// for a session
+	SetS3Config(ctx context.Context, id SessionID, cfg S3Config, mediaDelivery MediaDelivery) error
+
+	// SetTimezone sets the response timezone for a session
+	SetTimezone(ctx context.Context, id SessionID, timezone string) error
+
+	// SetProxyURL sets the proxy URL for a session
+	SetProxyURL(ctx context.Context, id SessionID, proxyURL string) error
+
+	// SetReadReceiptsDisabled records whether the session turned the
+	// account's read receipts off
+	SetReadReceiptsDisabled(ctx context.Context, id SessionID, disabled bool) error
+
 	// GetConnectedSessions retrieves all connected sessions
 	GetConnectedSessions(ctx context.Context) ([]*Session, error)
 
@@ -8,16 +8,34 @@ import (
 type EventType string
 
 const (
-	EventTypeMessage      EventType = "message"
-	EventTypePresence     EventType = "presence"
-	EventTypeReceipt      EventType = "receipt"
-	EventTypeCall         EventType = "call"
-	EventTypeGroup        EventType = "group"
-	EventTypeContact      EventType = "contact"
-	EventTypeStatus       EventType = "status"
-	EventTypeNotification EventType = "notification"
+	EventTypeMessage       EventType = "message"
+	EventTypeMessageEdit   EventType = "message_edit"
+	EventTypeMessageRevoke EventType = "message_revoke"
+	EventTypePresence      EventType = "presence"
+	EventTypeReceipt       EventType = "receipt"
+	EventTypeCall          EventType = "call"
+	EventTypeGroup         EventType = "group"
+	EventTypeContact       EventType = "contact"
+	EventTypeStatus        EventType = "status"
+	EventTypeNotification  EventType = "notification"
+	EventTypeHistorySync   EventType = "history_sync"
+	EventTypeSendResult    EventType = "send_result"
+	EventTypeChatState     EventType = "chat_state"
 )
 
+// IsValid checks if the event type is known
+func (t EventType) IsValid() bool {
+	switch t {
+	case EventTypeMessage, EventTypeMessageEdit, EventTypeMessageRevoke,
+		EventTypePresence, EventTypeReceipt, EventTypeCall, EventTypeGroup,
+		EventTypeContact, EventTypeStatus, EventTypeNotification,
+		EventTypeHistorySync, EventTypeSendResult, EventTypeChatState:
+		return true
+	default:
+		return false
+	}
+}
+
 // MessageType represents the type of message
 type MessageType string
 
@@ -30,6 +48,7 @@ const (
 	MessageTypeSticker  MessageType = "sticker"
 	MessageTypeLocation MessageType = "location"
 	MessageTypeContact  MessageType = "contact"
+	MessageTypeUnknown  MessageType = "unknown"
 )
 
 // PresenceType represents the presence status
@@ -54,6 +73,9 @@ type MessageEvent struct {
 	Timestamp   time.Time      `json:"timestamp"`
 	Body        string         `json:"body,omitempty"`
 	MediaURL    string         `json:"media_url,omitempty"`
+	MediaData   string         `json:"media_data,omitempty"` // base64 media bytes
+	MediaPath   string         `json:"media_path,omitempty"` // path of the saved media file, relative to MEDIA_SAVE_DIR
+	MediaFile   string         `json:"media_file,omitempty"` // URL serving the saved media file
 	MimeType    string         `json:"mime_type,omitempty"`
 	Caption     string         `json:"caption,omitempty"`
 	IsGroup     bool           `json:"is_group"`
@@ -63,6 +85,49 @@ type MessageEvent struct {
 	Quoted      *QuotedMessage `json:"quoted,omitempty"`
 	Mentions    []string       `json:"mentions,omitempty"`
 	RawEvent    interface{}    `json:"raw_event,omitempty"`
+
+	// Media lets the media be downloaded later without the message being stored
+	Media *MediaReference `json:"media,omitempty"`
+}
+
+// MediaReference holds what is needed to download the media of a message.
+// Byte fields are base64 in JSON.
+type MediaReference struct {
+	DirectPath    string      `json:"direct_path"`
+	MediaKey      []byte      `json:"media_key"`
+	FileSHA256    []byte      `json:"file_sha256"`
+	FileEncSHA256 []byte      `json:"file_enc_sha256"`
+	FileLength    uint64      `json:"file_length"`
+	MediaType     MessageType `json:"media_type"`
+	MimeType      string      `json:"mime_type,omitempty"`
+}
+
+// MessageEditEvent represents an edit of a previously sent message
+type MessageEditEvent struct {
+	SessionID       SessionID   `json:"session_id"`
+	EventType       EventType   `json:"event_type"`
+	MessageID       string      `json:"message_id"`        // ID of the protocol message carrying the edit
+	TargetMessageID string      `json:"target_message_id"` // ID of the message being edited
+	From            string      `json:"from"`
+	To              string      `json:"to"`
+	Timestamp       time.Time   `json:"timestamp"`
+	Body            string      `json:"body"`
+	IsGroup         bool        `json:"is_group"`
+	IsFromMe        bool        `json:"is_from_me"`
+	MessageType     MessageType `json:"message_type"`
+}
+
+// MessageRevokeEvent represents a message deleted for everyone
+type MessageRevokeEvent struct {
+	SessionID       SessionID `json:"session_id"`
+	EventType       EventType `json:"event_type"`
+	MessageID       string    `json:"message_id"`        // ID of the protocol message carrying the revoke
+	TargetMessageID string    `json:"target_message_id"` // ID of the message being revoked
+	From            string    `json:"from"`
+	To              string    `json:"to"`
+	Timestamp       time.Time `json:"timestamp"`
+	IsGroup         bool      `json:"is_group"`
+	IsFromMe        bool      `json:"is_from_me"`
 }
 
 // QuotedMessage represents a quoted message
@@ -151,6 +216,42 @@ type NotificationEvent struct {
 	Content   interface{} `json:"content"`
 }
 
+// HistorySyncEvent summarizes a processed history sync payload
+type HistorySyncEvent struct {
+	SessionID     SessionID `json:"session_id"`
+	EventType     EventType `json:"event_type"`
+	SyncType      string    `json:"sync_type"`
+	ChunkOrder    uint32    `json:"chunk_order"`
+	Progress      uint32    `json:"progress"`
+	Conversations int       `json:"conversations"`
+	Messages      int       `json:"messages"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
+// SendResultEvent reports the outcome of an asynchronous send job
+type SendResultEvent struct {
+	SessionID SessionID `json:"session_id"`
+	EventType EventType `json:"event_type"`
+	JobID     string    `json:"job_id"`
+	MessageID string    `json:"message_id"`
+	Status    string    `json:"status"` // "sent" or "failed"
+	Phone     string    `json:"phone"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// ChatStateEvent reports a chat list change made on another device,
+// such as archiving, muting, pinning or marking a chat as read
+type ChatStateEvent struct {
+	SessionID    SessionID  `json:"session_id"`
+	EventType    EventType  `json:"event_type"`
+	Chat         string     `json:"chat"`
+	Action       string     `json:"action"` // "archived", "unarchived", "muted", "unmuted", "pinned", "unpinned", "read", "unread"
+	MutedUntil   *time.Time `json:"muted_until,omitempty"`
+	FromFullSync bool       `json:"from_full_sync"`
+	Timestamp    time.Time  `json:"timestamp"`
+}
+
 // Event is a generic interface for all WhatsApp events
 type Event interface {
 	GetSessionID() SessionID
@@ -163,6 +264,14 @@ func (e MessageEvent) GetSessionID() SessionID { return e.SessionID }
 func (e MessageEvent) GetEventType() EventType { return e.EventType }
 func (e MessageEvent) GetTimestamp() time.Time { return e.Timestamp }
 
+func (e MessageEditEvent) GetSessionID() SessionID { return e.SessionID }
+func (e MessageEditEvent) GetEventType() EventType { return e.EventType }
+func (e MessageEditEvent) GetTimestamp() time.Time { return e.Timestamp }
+
+func (e MessageRevokeEvent) GetSessionID() SessionID { return e.SessionID }
+func (e MessageRevokeEvent) GetEventType() EventType { return e.EventType }
+func (e MessageRevokeEvent) GetTimestamp() time.Time { return e.Timestamp }
+
 func (e PresenceEvent) GetSessionID() SessionID { return e.SessionID }
 func (e PresenceEvent) GetEventType() EventType { return e.EventType }
 func (e PresenceEvent) GetTimestamp() time.Time { return e.Timestamp }
@@ -190,3 +299,15 @@ func (e StatusEvent) GetTimestamp() time.Time { return e.Timestamp }
 func (e NotificationEvent) GetSessionID() SessionID { return e.SessionID }
 func (e NotificationEvent) GetEventType() EventType { return e.EventType }
 func (e NotificationEvent) GetTimestamp() time.Time { return e.Timestamp }
+
+func (e SendResultEvent) GetSessionID() SessionID { return e.SessionID }
+func (e SendResultEvent) GetEventType() EventType { return e.EventType }
+func (e SendResultEvent) GetTimestamp() time.Time { return e.Timestamp }
+
+func (e HistorySyncEvent) GetSessionID() SessionID { return e.SessionID }
+func (e HistorySyncEvent) GetEventType() EventType { return e.EventType }
+func (e HistorySyncEvent) GetTimestamp() time.Time { return e.Timestamp }
+
+func (e ChatStateEvent) GetSessionID() SessionID { return e.SessionID }
+func (e ChatStateEvent) GetEventType() EventType { return e.EventType }
+func (e ChatStateEvent) GetTimestamp() time.Time { return e.Timestamp }
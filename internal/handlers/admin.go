@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"wazmeow/internal/app/config"
+	"wazmeow/internal/services"
+
+	"github.com/rs/zerolog/log"
+)
+
+// AdminHandler handles instance-wide administrative HTTP requests
+type AdminHandler struct {
+	multiSessionManager *services.MultiSessionManager
+	config              *config.Config
+}
+
+// NewAdminHandler creates a new admin handler
+func NewAdminHandler(multiSessionManager *services.MultiSessionManager, cfg *config.Config) *AdminHandler {
+	return &AdminHandler{
+		multiSessionManager: multiSessionManager,
+		config:              cfg,
+	}
+}
+
+// GetConfig handles GET /admin/config. It returns the loaded configuration
+// with secrets redacted, along with which optional features it enables.
+func (h *AdminHandler) GetConfig(w http.ResponseWriter, r *http.Request) {
+	cfg := h.config
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"config": cfg.Redacted(),
+		"features": map[string]any{
+			"api_key":           cfg.Server.APIKey != "",
+			"tls":               cfg.Server.TLS.Enabled,
+			"maintenance":       h.multiSessionManager.InMaintenance(),
+			"global_webhook":    cfg.Webhook.GlobalURL != "",
+			"webhook_signing":   cfg.Webhook.Secret != "",
+			"credential_export": cfg.Server.CredentialsKey != "",
+			"media_save":        cfg.WhatsApp.MediaSaveDir != "",
+			"persist_jobs":      cfg.WhatsApp.PersistJobs,
+			"unknown_events":    cfg.WhatsApp.CaptureUnknownEvents,
+		},
+	})
+}
+
+// MaintenanceRequest represents a maintenance mode toggle request
+type MaintenanceRequest struct {
+	Enabled *bool `json:"enabled"`
+}
+
+// GetMaintenance handles GET /admin/maintenance
+func (h *AdminHandler) GetMaintenance(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"enabled": h.multiSessionManager.InMaintenance(),
+	})
+}
+
+// SetMaintenance handles PUT /admin/maintenance
+func (h *AdminHandler) SetMaintenance(w http.ResponseWriter, r *http.Request) {
+	var req MaintenanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+
+	if req.Enabled == nil {
+		http.Error(w, "enabled is required", http.StatusBadRequest)
+		return
+	}
+
+	h.multiSessionManager.SetMaintenance(*req.Enabled)
+
+	log.Info().Bool("enabled", *req.Enabled).Msg("Maintenance mode updated via API")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"enabled": *req.Enabled,
+	})
+}
+
+// DisconnectAll handles POST /admin/disconnect-all
+func (h *AdminHandler) DisconnectAll(w http.ResponseWriter, r *http.Request) {
+	count := h.multiSessionManager.DisconnectAll()
+
+	log.Warn().Int("count", count).Msg("All sessions disconnected via API")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"disconnected": count,
+	})
+}
+
+// ListDeadLetters handles GET /admin/webhooks/dead-letters
+func (h *AdminHandler) ListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	letters := h.multiSessionManager.WebhookDeadLetters()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"dead_letters": letters,
+		"count":        len(letters),
+	})
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"wazmeow/internal/domain"
+	"wazmeow/internal/services"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// ContactHandler handles contact-related HTTP requests
+type ContactHandler struct {
+	multiSessionManager *services.MultiSessionManager
+	mediaHelper         *MediaHelper
+}
+
+// NewContactHandler creates a new contact handler
+func NewContactHandler(multiSessionManager *services.MultiSessionManager) *ContactHandler {
+	return &ContactHandler{
+		multiSessionManager: multiSessionManager,
+		mediaHelper:         NewMediaHelper(),
+	}
+}
+
+// GetContactVCard handles GET /sessions/{sessionID}/contacts/{jid}/vcard
+func (h *ContactHandler) GetContactVCard(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionID")
+
+	sessionID := domain.SessionID(sessionIDStr)
+	if !sessionID.IsValid() {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	jid, err := parsePhoneToJID(chi.URLParam(r, "jid"))
+	if err != nil || jid.Server == types.GroupServer {
+		http.Error(w, "Invalid contact JID", http.StatusBadRequest)
+		return
+	}
+	jid = jid.ToNonAD()
+
+	client, err := h.multiSessionManager.GetClient(sessionID)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to get session client")
+		http.Error(w, "Session not found or not connected", http.StatusNotFound)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	contact, err := client.Store.Contacts.GetContact(ctx, jid)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionIDStr).Str("jid", jid.String()).Msg("Failed to get contact")
+		http.Error(w, "Failed to get contact", http.StatusInternalServerError)
+		return
+	}
+	if !contact.Found {
+		http.Error(w, "Contact not found", http.StatusNotFound)
+		return
+	}
+
+	card := VCard{
+		Name:         contactDisplayName(contact, jid),
+		Organization: contact.BusinessName,
+	}
+	if jid.Server == types.DefaultUserServer {
+		card.Phone = "+" + jid.User
+		card.WAID = jid.User
+	}
+
+	// Business contacts may publish an address and email, fetched live when possible
+	if contact.BusinessName != "" && client.IsConnected() {
+		profile, err := client.GetBusinessProfile(jid)
+		if err != nil {
+			log.Debug().Err(err).Str("session_id", sessionIDStr).Str("jid", jid.String()).Msg("Failed to get business profile")
+		} else if profile != nil {
+			card.Email = profile.Email
+			card.Address = profile.Address
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/vcard; charset=utf-8")
+	w.Write([]byte(h.mediaHelper.BuildVCard(card)))
+}
+
+// contactDisplayName picks the best available name for a contact
+func contactDisplayName(contact types.ContactInfo, jid types.JID) string {
+	switch {
+	case contact.FullName != "":
+		return contact.FullName
+	case contact.BusinessName != "":
+		return contact.BusinessName
+	case contact.PushName != "":
+		return contact.PushName
+	case contact.FirstName != "":
+		return contact.FirstName
+	default:
+		return jid.User
+	}
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"wazmeow/internal/domain"
+	"wazmeow/internal/services"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// GroupHandler handles group-related HTTP requests
+type GroupHandler struct {
+	multiSessionManager *services.MultiSessionManager
+}
+
+// NewGroupHandler creates a new group handler
+func NewGroupHandler(multiSessionManager *services.MultiSessionManager) *GroupHandler {
+	return &GroupHandler{
+		multiSessionManager: multiSessionManager,
+	}
+}
+
+// CreateGroupRequest represents a request to create a group
+type CreateGroupRequest struct {
+	Subject      string   `json:"subject" validate:"required,max=100"`
+	Participants []string `json:"participants"` // phone numbers or JIDs, the session account is added implicitly
+}
+
+// ListGroupsResponse represents the groups a session is a participant of
+type ListGroupsResponse struct {
+	SessionID string               `json:"session_id"`
+	Groups    []services.GroupInfo `json:"groups"`
+	Total     int                  `json:"total"`
+}
+
+// CreateGroup handles POST /group/{sessionId}/create
+func (h *GroupHandler) CreateGroup(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionId")
+
+	sessionID, err := domain.ParseSessionID(sessionIDStr)
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	var req CreateGroupRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Participants) > maxGroupParticipants {
+		http.Error(w, fmt.Sprintf("At most %d participants per request", maxGroupParticipants), http.StatusBadRequest)
+		return
+	}
+
+	participants, ok := parseGroupParticipants(w, req.Participants)
+	if !ok {
+		return
+	}
+
+	group, err := h.multiSessionManager.CreateGroup(r.Context(), sessionID, req.Subject, participants)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to create group")
+
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, "Session not found", http.StatusNotFound)
+		case *domain.ValidationError:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case *domain.BusinessError:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to create group", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(group)
+}
+
+// ListGroups handles GET /group/{sessionId}/list
+func (h *GroupHandler) ListGroups(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionId")
+
+	sessionID, err := domain.ParseSessionID(sessionIDStr)
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	groups, err := h.multiSessionManager.ListJoinedGroups(r.Context(), sessionID)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to list groups")
+
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, "Session not found", http.StatusNotFound)
+		case *domain.BusinessError:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to list groups", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ListGroupsResponse{
+		SessionID: sessionIDStr,
+		Groups:    groups,
+		Total:     len(groups),
+	})
+}
+
+// UpdateParticipants handles POST /group/{sessionId}/{groupJid}/participants
+func (h *GroupHandler) UpdateParticipants(w http.ResponseWriter, r *http.Request) {
+	sessionID, err := domain.ParseSessionID(chi.URLParam(r, "sessionId"))
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	updateGroupParticipants(w, r, h.multiSessionManager, sessionID, chi.URLParam(r, "groupJid"))
+}
+
+// parseGroupParticipants parses participant phone numbers or JIDs, answering
+// 400 and returning false on the first invalid one
+func parseGroupParticipants(w http.ResponseWriter, phones []string) ([]types.JID, bool) {
+	participants := make([]types.JID, 0, len(phones))
+	for _, p := range phones {
+		jid, err := parsePhoneToJID(p)
+		if err != nil || jid.Server == types.GroupServer {
+			http.Error(w, fmt.Sprintf("Invalid participant: %s", p), http.StatusBadRequest)
+			return nil, false
+		}
+		participants = append(participants, jid.ToNonAD())
+	}
+	return participants, true
+}
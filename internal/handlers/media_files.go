@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+)
+
+// MediaFileHandler serves inbound media saved to the media save directory
+type MediaFileHandler struct {
+	files http.Handler
+}
+
+// NewMediaFileHandler creates a handler serving the files under dir
+func NewMediaFileHandler(dir string) *MediaFileHandler {
+	return &MediaFileHandler{
+		files: http.FileServer(http.Dir(dir)),
+	}
+}
+
+// ServeHTTP handles GET /media/files/{session}/{chat}/{file}
+func (h *MediaFileHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Only files are served, never directory listings or in-progress writes
+	name := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+	if name == "" || strings.HasPrefix(name, ".") {
+		http.NotFound(w, r)
+		return
+	}
+
+	h.files.ServeHTTP(w, r)
+}
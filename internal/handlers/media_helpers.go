@@ -2,16 +2,28 @@ package handlers
 
 import (
 	"bytes"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"image"
+	_ "image/gif" // registers the GIF decoder
 	"image/jpeg"
 	"image/png"
+	"mime"
 	"net/http"
 	"os"
 	"strings"
 
 	"github.com/nfnt/resize"
 	"github.com/vincent-petithory/dataurl"
+	_ "golang.org/x/image/webp" // registers the WebP decoder
+)
+
+const (
+	// maxOptimizedImageDimension is the longest side WhatsApp keeps without further downscaling
+	maxOptimizedImageDimension = 1600
+	// defaultOptimizeQuality is the JPEG quality used when none is requested
+	defaultOptimizeQuality = 85
 )
 
 // MediaHelper provides utility functions for media handling
@@ -22,14 +34,51 @@ func NewMediaHelper() *MediaHelper {
 	return &MediaHelper{}
 }
 
-// DecodeDataURL decodes a data URL and returns the raw data
+// maxDataURLBytes bounds the decoded size of a data URL, WhatsApp's largest media limit
+const maxDataURLBytes = 100 * 1024 * 1024
+
+// DecodeDataURL decodes a data URL and returns the raw data. Errors tell the
+// prefix and MIME type apart from the base64 payload, which is most often
+// broken by clients truncating large bodies.
 func (m *MediaHelper) DecodeDataURL(dataURL string) ([]byte, string, error) {
-	parsed, err := dataurl.DecodeString(dataURL)
+	if !strings.HasPrefix(dataURL, "data:") {
+		return nil, "", fmt.Errorf("invalid data URL prefix: must start with 'data:'")
+	}
+
+	header, payload, found := strings.Cut(dataURL[len("data:"):], ",")
+	if !found {
+		return nil, "", fmt.Errorf("invalid data URL: missing ',' between media type and data")
+	}
+
+	mediaType, isBase64 := strings.CutSuffix(header, ";base64")
+	if !isBase64 {
+		// Percent-encoded data URLs are rare for media, leave them to the library
+		parsed, err := dataurl.DecodeString(dataURL)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to decode data URL: %w", err)
+		}
+		return parsed.Data, parsed.MediaType.String(), nil
+	}
+
+	mimeType, params, err := mime.ParseMediaType(mediaType)
 	if err != nil {
-		return nil, "", fmt.Errorf("failed to decode data URL: %w", err)
+		return nil, "", fmt.Errorf("invalid data URL media type %q: %w", mediaType, err)
 	}
-	
-	return parsed.Data, parsed.MediaType.String(), nil
+
+	if size := base64.StdEncoding.DecodedLen(len(payload)); size > maxDataURLBytes {
+		return nil, "", fmt.Errorf("data URL payload too large: about %d bytes, limit is %d", size, maxDataURLBytes)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(payload)
+	if err != nil {
+		var corrupt base64.CorruptInputError
+		if errors.As(err, &corrupt) && int(corrupt) >= len(payload)-4 {
+			return nil, "", fmt.Errorf("invalid base64 data at byte %d of %d: payload looks truncated", int(corrupt), len(payload))
+		}
+		return nil, "", fmt.Errorf("invalid base64 data: %w", err)
+	}
+
+	return data, mime.FormatMediaType(mimeType, params), nil
 }
 
 // ValidateImageFormat validates if the data URL is a valid image format
@@ -69,9 +118,10 @@ func (m *MediaHelper) DetectMimeType(data []byte) string {
 	return http.DetectContentType(data)
 }
 
-// GenerateThumbnail generates a 72x72 thumbnail for images
+// GenerateThumbnail generates a 72x72 thumbnail for images. JPEG, PNG, WebP
+// and the first frame of a GIF are supported; other formats fail to decode.
 func (m *MediaHelper) GenerateThumbnail(imageData []byte) ([]byte, error) {
-	// Decode the image
+	// Decode the image, only the first frame of an animated GIF
 	reader := bytes.NewReader(imageData)
 	img, format, err := image.Decode(reader)
 	if err != nil {
@@ -89,6 +139,9 @@ func (m *MediaHelper) GenerateThumbnail(imageData []byte) ([]byte, error) {
 		err = jpeg.Encode(&buf, thumbnail, &jpeg.Options{Quality: 75})
 	case "png":
 		err = png.Encode(&buf, thumbnail)
+	case "gif", "webp":
+		// WhatsApp shows still JPEG thumbnails, whatever the source format
+		err = jpeg.Encode(&buf, thumbnail, &jpeg.Options{Quality: 75})
 	default:
 		// Default to JPEG for unknown formats
 		err = jpeg.Encode(&buf, thumbnail, &jpeg.Options{Quality: 75})
@@ -101,6 +154,35 @@ func (m *MediaHelper) GenerateThumbnail(imageData []byte) ([]byte, error) {
 	return buf.Bytes(), nil
 }
 
+// OptimizeImage resizes an image so its longest side is at most 1600px and
+// re-encodes it as JPEG with the given quality, returning the new data and MIME type
+func (m *MediaHelper) OptimizeImage(imageData []byte, quality int) ([]byte, string, error) {
+	if quality == 0 {
+		quality = defaultOptimizeQuality
+	}
+	if quality < 1 || quality > 100 {
+		return nil, "", fmt.Errorf("invalid quality: %d (must be between 1 and 100)", quality)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(imageData))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	// Only downscale; smaller images are just re-encoded
+	bounds := img.Bounds()
+	if bounds.Dx() > maxOptimizedImageDimension || bounds.Dy() > maxOptimizedImageDimension {
+		img = resize.Thumbnail(maxOptimizedImageDimension, maxOptimizedImageDimension, img, resize.Lanczos3)
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, "", fmt.Errorf("failed to encode optimized image: %w", err)
+	}
+
+	return buf.Bytes(), "image/jpeg", nil
+}
+
 // GenerateVideoThumbnail generates a thumbnail for video (placeholder implementation)
 func (m *MediaHelper) GenerateVideoThumbnail(videoData []byte) ([]byte, error) {
 	// For now, return empty bytes as video thumbnail generation is complex
@@ -165,11 +247,50 @@ func (m *MediaHelper) IsValidCoordinate(lat, lng float64) error {
 	return nil
 }
 
+// VCard holds the contact fields rendered into a vCard
+type VCard struct {
+	Name         string
+	Phone        string
+	WAID         string // WhatsApp user ID, lets clients open a chat from the number
+	Organization string
+	Email        string
+	Address      string
+}
+
 // FormatVCard creates a vCard string for contact sharing
 func (m *MediaHelper) FormatVCard(name, phone string) string {
-	return fmt.Sprintf(`BEGIN:VCARD
-VERSION:3.0
-FN:%s
-TEL:%s
-END:VCARD`, name, phone)
+	return m.BuildVCard(VCard{Name: name, Phone: phone})
+}
+
+// BuildVCard renders a vCard 3.0 string, omitting empty fields
+func (m *MediaHelper) BuildVCard(card VCard) string {
+	var b strings.Builder
+	b.WriteString("BEGIN:VCARD\nVERSION:3.0\n")
+	fmt.Fprintf(&b, "FN:%s\n", escapeVCardValue(card.Name))
+	if card.Organization != "" {
+		fmt.Fprintf(&b, "ORG:%s\n", escapeVCardValue(card.Organization))
+	}
+	if card.WAID != "" {
+		fmt.Fprintf(&b, "TEL;type=CELL;waid=%s:%s\n", card.WAID, card.Phone)
+	} else if card.Phone != "" {
+		fmt.Fprintf(&b, "TEL:%s\n", card.Phone)
+	}
+	if card.Email != "" {
+		fmt.Fprintf(&b, "EMAIL:%s\n", escapeVCardValue(card.Email))
+	}
+	if card.Address != "" {
+		fmt.Fprintf(&b, "ADR:;;%s;;;;\n", escapeVCardValue(card.Address))
+	}
+	b.WriteString("END:VCARD")
+	return b.String()
+}
+
+// escapeVCardValue escapes characters with special meaning in vCard values
+func escapeVCardValue(value string) string {
+	return strings.NewReplacer(
+		"\\", "\\\\",
+		",", "\\,",
+		";", "\\;",
+		"\n", "\\n",
+	).Replace(value)
 }
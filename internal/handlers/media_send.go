@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"syscall"
+	"time"
+
+	"wazmeow/internal/domain"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// remoteMediaTimeout bounds the download of media given by URL
+const remoteMediaTimeout = 60 * time.Second
+
+// errNonPublicMediaHost rejects media URLs that resolve to an address of
+// this host or its private network
+var errNonPublicMediaHost = errors.New("media host is not a public address")
+
+// remoteMediaClient downloads media given by URL. Its dialer checks every
+// address it connects to, redirects included, so a URL cannot reach loopback,
+// private or link-local services such as cloud metadata endpoints.
+var remoteMediaClient = &http.Client{
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 10 * time.Second,
+			Control: rejectNonPublicAddress,
+		}).DialContext,
+		TLSHandshakeTimeout: 10 * time.Second,
+	},
+}
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), not covered by IsPrivate
+var sharedAddressSpace = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// rejectNonPublicAddress refuses connections to addresses that are not
+// publicly routable
+func rejectNonPublicAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() ||
+		sharedAddressSpace.Contains(ip) {
+		return errNonPublicMediaHost
+	}
+	return nil
+}
+
+// SendMediaMessage handles POST /message/{sessionId}/send/media. The media's
+// MIME type picks the message type: images, videos and OGG audio are sent as
+// such, anything else as a document. Audio in other formats, such as MP3 or
+// M4A, is not converted and arrives as a document, because WhatsApp only
+// plays OGG/Opus as audio messages. The request is handed to the endpoint of
+// that type, so validation, upload and response are the same.
+func (h *MessageHandler) SendMediaMessage(w http.ResponseWriter, r *http.Request) {
+	// Reject new sends while maintenance mode is on
+	if !h.checkMaintenance(w) {
+		return
+	}
+
+	sessionID, err := domain.ParseSessionID(chi.URLParam(r, "sessionId"))
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	var req SendMediaMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+
+	if req.Phone == "" {
+		http.Error(w, "Phone number is required", http.StatusBadRequest)
+		return
+	}
+	if req.Media == "" {
+		http.Error(w, "Media is required", http.StatusBadRequest)
+		return
+	}
+
+	// Check the session before downloading anything for it
+	if _, ok := h.requireConnectedSession(w, sessionID, false); !ok {
+		return
+	}
+
+	dataURL, mimeType, err := h.resolveMedia(r.Context(), req.Media)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to resolve media")
+		http.Error(w, fmt.Sprintf("Invalid media: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	var (
+		body    any
+		handler http.HandlerFunc
+	)
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		body = SendImageMessageRequest{Phone: req.Phone, Image: dataURL, Caption: req.Caption, ID: req.ID, QuotedMessageID: req.QuotedMessageID, QuotedPhone: req.QuotedPhone, Async: req.Async, Priority: req.Priority}
+		handler = h.SendImageMessage
+	case strings.HasPrefix(mimeType, "video/"):
+		body = SendVideoMessageRequest{Phone: req.Phone, Video: dataURL, Caption: req.Caption, ID: req.ID, QuotedMessageID: req.QuotedMessageID, QuotedPhone: req.QuotedPhone, Async: req.Async, Priority: req.Priority}
+		handler = h.SendVideoMessage
+	case mimeType == "audio/ogg":
+		body = SendAudioMessageRequest{Phone: req.Phone, Audio: dataURL, ID: req.ID, QuotedMessageID: req.QuotedMessageID, QuotedPhone: req.QuotedPhone, Async: req.Async, Priority: req.Priority}
+		handler = h.SendAudioMessage
+	default:
+		// The document endpoint takes the real type separately from the payload
+		_, payload, _ := strings.Cut(dataURL, ",")
+		body = SendDocumentMessageRequest{
+			Phone:           req.Phone,
+			Document:        "data:application/octet-stream;base64," + payload,
+			Filename:        mediaFilename(req.Filename, req.Media, mimeType),
+			Mimetype:        mimeType,
+			ID:              req.ID,
+			QuotedMessageID: req.QuotedMessageID,
+			QuotedPhone:     req.QuotedPhone,
+			Async:           req.Async,
+			Priority:        req.Priority,
+		}
+		handler = h.SendDocumentMessage
+	}
+
+	encoded, err := json.Marshal(body)
+	if err != nil {
+		http.Error(w, "Failed to prepare media message", http.StatusInternalServerError)
+		return
+	}
+
+	log.Debug().Str("mime_type", mimeType).Msg("Routing media send by MIME type")
+
+	r.Body = io.NopCloser(bytes.NewReader(encoded))
+	r.ContentLength = int64(len(encoded))
+	handler(w, r)
+}
+
+// resolveMedia returns media as a base64 data URL along with its bare MIME
+// type, downloading it first when given as an http(s) URL
+func (h *MessageHandler) resolveMedia(ctx context.Context, media string) (string, string, error) {
+	if strings.HasPrefix(media, "data:") {
+		header, _, found := strings.Cut(media[len("data:"):], ",")
+		if !found {
+			return "", "", fmt.Errorf("missing ',' between media type and data")
+		}
+		mimeType, _, err := mime.ParseMediaType(strings.TrimSuffix(header, ";base64"))
+		if err != nil {
+			return "", "", fmt.Errorf("invalid media type %q: %w", header, err)
+		}
+		if !strings.HasSuffix(header, ";base64") {
+			// Normalize percent-encoded data URLs, the typed endpoints expect base64
+			data, _, err := h.mediaHelper.DecodeDataURL(media)
+			if err != nil {
+				return "", "", err
+			}
+			return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data), mimeType, nil
+		}
+		return media, mimeType, nil
+	}
+
+	parsed, err := url.Parse(media)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", "", fmt.Errorf("media must be a data URL or an http(s) URL")
+	}
+
+	data, mimeType, err := fetchRemoteMedia(ctx, parsed.String())
+	if err != nil {
+		return "", "", err
+	}
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data), mimeType, nil
+}
+
+// fetchRemoteMedia downloads media from a URL, taking its MIME type from the
+// response or, when missing or generic, from the content itself
+func fetchRemoteMedia(ctx context.Context, mediaURL string) ([]byte, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, remoteMediaTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, mediaURL, nil)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to create media request: %w", err)
+	}
+
+	resp, err := remoteMediaClient.Do(req)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download media: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("failed to download media: status %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDataURLBytes+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read media: %w", err)
+	}
+	if len(data) > maxDataURLBytes {
+		return nil, "", fmt.Errorf("media too large: limit is %d bytes", maxDataURLBytes)
+	}
+
+	mimeType, _, err := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if err != nil || mimeType == "application/octet-stream" {
+		mimeType, _, _ = mime.ParseMediaType(http.DetectContentType(data))
+	}
+
+	return data, mimeType, nil
+}
+
+// mediaFilename returns the file name of media sent as a document: the given
+// one, else the last segment of its URL, else one derived from its MIME type
+func mediaFilename(filename, media, mimeType string) string {
+	if filename != "" {
+		return filename
+	}
+
+	if parsed, err := url.Parse(media); err == nil && !strings.HasPrefix(media, "data:") {
+		if name := path.Base(parsed.Path); name != "" && name != "." && name != "/" {
+			return name
+		}
+	}
+
+	if extensions, err := mime.ExtensionsByType(mimeType); err == nil && len(extensions) > 0 {
+		return "file" + extensions[0]
+	}
+	return "file"
+}
@@ -3,9 +3,14 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"wazmeow/internal/domain"
 	"wazmeow/internal/services"
@@ -22,18 +27,1660 @@ import (
 type MessageHandler struct {
 	multiSessionManager *services.MultiSessionManager
 	mediaHelper         *MediaHelper
+	maxMessageLength    int
+	sseHeartbeat        time.Duration
+	sendResponse        string
 }
 
-// NewMessageHandler creates a new message handler
-func NewMessageHandler(multiSessionManager *services.MultiSessionManager) *MessageHandler {
+// NewMessageHandler creates a new message handler. Text bodies longer than
+// maxMessageLength characters are rejected or split; zero disables the limit.
+// Event streams send a heartbeat comment every sseHeartbeat; zero disables it.
+// Sends answer in the sendResponse shape, "full" or "minimal", unless the
+// request asks for the other one.
+func NewMessageHandler(multiSessionManager *services.MultiSessionManager, maxMessageLength int, sseHeartbeat time.Duration, sendResponse string) *MessageHandler {
 	return &MessageHandler{
 		multiSessionManager: multiSessionManager,
 		mediaHelper:         NewMediaHelper(),
+		maxMessageLength:    maxMessageLength,
+		sseHeartbeat:        sseHeartbeat,
+		sendResponse:        sendResponse,
 	}
 }
 
 // SendTextMessage sends a text message
 func (h *MessageHandler) SendTextMessage(w http.ResponseWriter, r *http.Request) {
+	// Reject new sends while maintenance mode is on
+	if !h.checkMaintenance(w) {
+		return
+	}
+
+	sessionIDStr := chi.URLParam(r, "sessionId")
+
+	sessionID, err := domain.ParseSessionID(sessionIDStr)
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	var req SendTextMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+
+	// Validate required fields
+	if req.Phone == "" {
+		http.Error(w, "Phone number is required", http.StatusBadRequest)
+		return
+	}
+	if req.Message == "" {
+		http.Error(w, "Message is required", http.StatusBadRequest)
+		return
+	}
+
+	// Enforce the maximum text length, splitting the body when asked to
+	chunks := []string{req.Message}
+	if h.maxMessageLength > 0 && utf8.RuneCountInString(req.Message) > h.maxMessageLength {
+		if !req.AutoSplit {
+			http.Error(w, fmt.Sprintf("Message exceeds maximum length of %d characters", h.maxMessageLength), http.StatusRequestEntityTooLarge)
+			return
+		}
+		// Queued parts could be sent out of order, so split texts are sent right away
+		if req.Async || req.QueueIfOffline {
+			http.Error(w, "auto_split cannot be combined with async or queue_if_offline", http.StatusBadRequest)
+			return
+		}
+		chunks = splitText(req.Message, h.maxMessageLength)
+	}
+
+	// Get session client
+	client, ok := h.requireConnectedSession(w, sessionID, req.QueueIfOffline)
+	if !ok {
+		return
+	}
+
+	// Parse recipient JID
+	recipient, err := parsePhoneToJID(req.Phone)
+	if err != nil {
+		log.Error().Err(err).Str("phone", req.Phone).Msg("Failed to parse phone number")
+		http.Error(w, "Invalid phone number format", http.StatusBadRequest)
+		return
+	}
+
+	// Messages to the session's own number go to its self-chat
+	recipient = selfChatJID(client, recipient)
+
+	// Enforce the session's recipient allowlist
+	if !h.checkRecipientAllowed(w, sessionID, recipient) {
+		return
+	}
+
+	// Fail fast while WhatsApp is rate limiting this session
+	if !h.checkCooldown(w, sessionID) {
+		return
+	}
+
+	if len(chunks) > 1 {
+		if req.ID != "" && !h.checkMessageIDUnused(w, r, sessionID, req.ID) {
+			return
+		}
+		h.sendTextChunks(w, r, sessionID, client, recipient, req, chunks)
+		return
+	}
+
+	// Generate message ID if not provided
+	messageID := req.ID
+	if messageID == "" {
+		messageID = client.GenerateMessageID()
+	} else if !h.checkMessageIDUnused(w, r, sessionID, messageID) {
+		return
+	}
+
+	// Create text message
+	msg := &waE2E.Message{
+		ExtendedTextMessage: &waE2E.ExtendedTextMessage{
+			Text: proto.String(req.Message),
+		},
+	}
+
+	// Reply to another message when asked to
+	if !h.applyQuote(w, sessionID, client, recipient, msg, req.QuotedMessageID, req.QuotedPhone) {
+		return
+	}
+
+	logOutgoingMessage(sessionID, recipient, messageID, msg)
+
+	// Collect receipts for this message when the caller wants to track it
+	trackingToken, ok := h.startTracking(w, r, sessionID, messageID)
+	if !ok {
+		return
+	}
+
+	// Hold the send until the session reconnects when asked to
+	if req.QueueIfOffline && !client.IsConnected() {
+		h.enqueueOfflineSend(w, sessionID, recipient, req.Phone, messageID, msg, trackingToken, req.Priority)
+		return
+	}
+
+	// Hand off to the send queue when the caller wants the result by webhook
+	if req.Async {
+		h.enqueueSend(w, sessionID, recipient, req.Phone, messageID, msg, trackingToken, req.Priority)
+		return
+	}
+
+	// Send message
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	resp, err := h.multiSessionManager.SendMessage(ctx, sessionID, client, recipient, msg, messageID)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("session_id", sessionIDStr).
+			Str("phone", req.Phone).
+			Msg("Failed to send text message")
+		if trackingToken != "" {
+			h.multiSessionManager.UntrackMessage(sessionID, messageID)
+		}
+		if h.writeRateLimited(w, sessionID, err) {
+			return
+		}
+		http.Error(w, fmt.Sprintf("Failed to send message: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Create response
+	response := MessageResponse{
+		MessageID:     resp.ID,
+		Status:        "sent",
+		Timestamp:     h.responseTime(r, sessionID, resp.Timestamp),
+		Phone:         req.Phone,
+		Recipient:     recipient.String(),
+		SessionID:     sessionIDStr,
+		TrackingToken: trackingToken,
+	}
+
+	log.Info().
+		Str("session_id", sessionIDStr).
+		Str("phone", req.Phone).
+		Str("message_id", resp.ID).
+		Msg("Text message sent successfully")
+
+	h.writeSendResponse(w, r, response)
+}
+
+// sendTextChunks sends the parts of a split text in order and writes all resulting message IDs
+func (h *MessageHandler) sendTextChunks(w http.ResponseWriter, r *http.Request, sessionID domain.SessionID, client *whatsmeow.Client, recipient types.JID, req SendTextMessageRequest, chunks []string) {
+	messageIDs := make([]string, 0, len(chunks))
+	var lastTimestamp time.Time
+
+	for i, chunk := range chunks {
+		// A caller-provided ID applies to the first part only
+		messageID := client.GenerateMessageID()
+		if i == 0 && req.ID != "" {
+			messageID = req.ID
+		}
+
+		msg := &waE2E.Message{
+			ExtendedTextMessage: &waE2E.ExtendedTextMessage{
+				Text: proto.String(chunk),
+			},
+		}
+
+		// Only the first part replies to the quoted message
+		if i == 0 && !h.applyQuote(w, sessionID, client, recipient, msg, req.QuotedMessageID, req.QuotedPhone) {
+			return
+		}
+
+		logOutgoingMessage(sessionID, recipient, messageID, msg)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		resp, err := h.multiSessionManager.SendMessage(ctx, sessionID, client, recipient, msg, messageID)
+		cancel()
+
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str("session_id", sessionID.String()).
+				Str("phone", req.Phone).
+				Strs("sent_message_ids", messageIDs).
+				Msg("Failed to send split text message")
+			if h.writeRateLimited(w, sessionID, err) {
+				return
+			}
+			http.Error(w, fmt.Sprintf("Failed to send part %d of %d: %v", i+1, len(chunks), err), http.StatusInternalServerError)
+			return
+		}
+
+		messageIDs = append(messageIDs, resp.ID)
+		lastTimestamp = resp.Timestamp
+	}
+
+	log.Info().
+		Str("session_id", sessionID.String()).
+		Str("phone", req.Phone).
+		Int("parts", len(messageIDs)).
+		Msg("Split text message sent successfully")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(SplitMessageResponse{
+		MessageIDs: messageIDs,
+		Status:     "sent",
+		Timestamp:  h.responseTime(r, sessionID, lastTimestamp),
+		Phone:      req.Phone,
+		SessionID:  sessionID.String(),
+	})
+}
+
+// DownloadMedia handles GET /message/{sessionId}/media/{messageId} and its
+// alias GET /message/{sessionId}/download/{messageId}
+func (h *MessageHandler) DownloadMedia(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionId")
+
+	sessionID, err := domain.ParseSessionID(sessionIDStr)
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	messageID := chi.URLParam(r, "messageId")
+
+	if _, ok := h.requireConnectedSession(w, sessionID, false); !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	data, mimeType, err := h.multiSessionManager.DownloadMedia(ctx, sessionID, messageID)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("session_id", sessionIDStr).
+			Str("message_id", messageID).
+			Msg("Failed to download media")
+
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, "Message not found", http.StatusNotFound)
+		case *domain.BusinessError:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to download media", http.StatusBadGateway)
+		}
+		return
+	}
+
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+
+	w.Header().Set("Content-Type", mimeType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// GetMessage handles GET /message/{sessionId}/{messageId}
+func (h *MessageHandler) GetMessage(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionId")
+
+	sessionID, err := domain.ParseSessionID(sessionIDStr)
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	messageID := chi.URLParam(r, "messageId")
+
+	message, quoted, err := h.multiSessionManager.GetMessage(r.Context(), sessionID, messageID)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("session_id", sessionIDStr).
+			Str("message_id", messageID).
+			Msg("Failed to get message")
+
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, "Message not found", http.StatusNotFound)
+		default:
+			http.Error(w, "Failed to get message", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	response := h.storedMessageResponse(r, message)
+	if quoted != nil {
+		response.Quoted = h.storedMessageResponse(r, quoted)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// GetChatHistory handles GET /message/{sessionId}/history. It returns a page
+// of a chat's stored messages, newest first, with the cursor of the next
+// page in next_before and next_before_id.
+func (h *MessageHandler) GetChatHistory(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionId")
+
+	sessionID, err := domain.ParseSessionID(sessionIDStr)
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	query := r.URL.Query()
+	if query.Get("chat") == "" {
+		http.Error(w, "chat is required", http.StatusBadRequest)
+		return
+	}
+	chat, err := parsePhoneToJID(query.Get("chat"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Invalid chat: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	limit := services.DefaultHistoryLimit
+	if value := query.Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 {
+			http.Error(w, fmt.Sprintf("Invalid limit, expected a number from 1 to %d", services.MaxHistoryLimit), http.StatusBadRequest)
+			return
+		}
+		limit = min(limit, services.MaxHistoryLimit)
+	}
+
+	var before time.Time
+	if value := query.Get("before"); value != "" {
+		before, err = time.Parse(time.RFC3339, value)
+		if err != nil {
+			http.Error(w, "Invalid before, expected an RFC 3339 timestamp", http.StatusBadRequest)
+			return
+		}
+	}
+
+	beforeID := query.Get("before_id")
+	if beforeID != "" && before.IsZero() {
+		http.Error(w, "before_id requires before", http.StatusBadRequest)
+		return
+	}
+
+	messages, err := h.multiSessionManager.ListChatMessages(r.Context(), sessionID, chat.String(), limit, before, beforeID)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("session_id", sessionIDStr).
+			Str("chat", chat.String()).
+			Msg("Failed to list chat history")
+
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, "Session not found", http.StatusNotFound)
+		default:
+			http.Error(w, "Failed to list chat history", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// A full page may be followed by older messages
+	var nextBefore, nextBeforeID string
+	if len(messages) == limit {
+		last := messages[len(messages)-1]
+		nextBefore = last.Timestamp.UTC().Format(time.RFC3339Nano)
+		nextBeforeID = last.ID
+	}
+
+	items := make([]*StoredMessageResponse, 0, len(messages))
+	for _, message := range messages {
+		items = append(items, h.storedMessageResponse(r, message))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"session_id":     sessionIDStr,
+		"chat":           chat.String(),
+		"messages":       items,
+		"next_before":    nextBefore,
+		"next_before_id": nextBeforeID,
+	})
+}
+
+// storedMessageResponse builds the response for a stored message
+func (h *MessageHandler) storedMessageResponse(r *http.Request, message *domain.Message) *StoredMessageResponse {
+	message.Timestamp = h.responseTime(r, message.SessionID, message.Timestamp)
+	return &StoredMessageResponse{
+		Message:  message,
+		HasMedia: message.HasMedia(),
+	}
+}
+
+// GetMessageStatus handles GET /message/{sessionId}/{messageId}/status.
+// It returns the receipts of a message sent with track=true, or streams them
+// as server-sent events when the client accepts text/event-stream.
+func (h *MessageHandler) GetMessageStatus(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionId")
+
+	sessionID, err := domain.ParseSessionID(sessionIDStr)
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	messageID := chi.URLParam(r, "messageId")
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		http.Error(w, "Tracking token is required", http.StatusBadRequest)
+		return
+	}
+
+	if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		h.streamMessageStatus(w, r, sessionID, messageID, token)
+		return
+	}
+
+	tracked, err := h.multiSessionManager.GetTrackedMessage(sessionID, messageID, token)
+	if err != nil {
+		http.Error(w, "Tracked message not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tracked)
+}
+
+// streamMessageStatus writes the current receipt state of a tracked message as
+// a "status" event, then each following receipt as a "receipt" event
+func (h *MessageHandler) streamMessageStatus(w http.ResponseWriter, r *http.Request, sessionID domain.SessionID, messageID, token string) {
+	timeout := 10 * time.Minute
+	if value := r.URL.Query().Get("timeout"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 || parsed > time.Hour {
+			http.Error(w, "Invalid timeout, expected a duration up to 1h", http.StatusBadRequest)
+			return
+		}
+		timeout = parsed
+	}
+
+	tracked, receipts, cancelSubscription, err := h.multiSessionManager.SubscribeReceipts(sessionID, messageID, token)
+	if err != nil {
+		http.Error(w, "Tracked message not found", http.StatusNotFound)
+		return
+	}
+	defer cancelSubscription()
+
+	rc := http.NewResponseController(w)
+	// Let the stream outlive the server write timeout (best effort)
+	_ = rc.SetWriteDeadline(time.Now().Add(timeout + 10*time.Second))
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	writeEvent := func(event string, data any) bool {
+		payload, err := json.Marshal(data)
+		if err != nil {
+			return false
+		}
+		if _, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload); err != nil {
+			return false
+		}
+		return rc.Flush() == nil
+	}
+
+	if !writeEvent("status", tracked) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	// Heartbeat comments keep proxies from closing a quiet stream
+	var heartbeat <-chan time.Time
+	if h.sseHeartbeat > 0 {
+		ticker := time.NewTicker(h.sseHeartbeat)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+
+	for {
+		select {
+		case receipt, open := <-receipts:
+			if !open || !writeEvent("receipt", receipt) {
+				return
+			}
+		case <-heartbeat:
+			if _, err := fmt.Fprint(w, ": heartbeat\n\n"); err != nil || rc.Flush() != nil {
+				return
+			}
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// parsePhoneToJID parses a recipient given either as a full JID, such as a
+// group (…@g.us) or user (…@s.whatsapp.net) JID, or as a phone number
+func parsePhoneToJID(phone string) (types.JID, error) {
+	phone = strings.TrimSpace(phone)
+
+	// Full JIDs are passed through as given
+	if strings.Contains(phone, "@") {
+		jid, err := types.ParseJID(phone)
+		if err != nil {
+			return types.JID{}, fmt.Errorf("invalid JID %s: %w", phone, err)
+		}
+		if jid.User == "" {
+			return types.JID{}, fmt.Errorf("invalid JID: %s", phone)
+		}
+		return jid, nil
+	}
+
+	// Keep only the digits, dropping a leading + and any separators
+	var cleanPhone strings.Builder
+	for _, char := range phone {
+		if char >= '0' && char <= '9' {
+			cleanPhone.WriteRune(char)
+		}
+	}
+
+	// Ensure we have a valid phone number
+	if cleanPhone.Len() == 0 {
+		return types.JID{}, fmt.Errorf("phone number contains no digits: %q", phone)
+	}
+	if cleanPhone.Len() < 10 {
+		return types.JID{}, fmt.Errorf("phone number too short: %s", phone)
+	}
+
+	// Create JID for individual chat
+	return types.NewJID(cleanPhone.String(), types.DefaultUserServer), nil
+}
+
+// selfChatJID maps a recipient that is the session's own account, by phone
+// number or LID, to the account's plain JID so the message lands in the
+// self-chat instead of being addressed to a device or an unknown LID
+func selfChatJID(client *whatsmeow.Client, recipient types.JID) types.JID {
+	own := client.Store.ID
+	if own == nil {
+		return recipient
+	}
+
+	isSelf := (recipient.Server == types.DefaultUserServer && recipient.User == own.User) ||
+		(recipient.Server == types.HiddenUserServer && !client.Store.LID.IsEmpty() && recipient.User == client.Store.LID.User)
+	if !isSelf {
+		return recipient
+	}
+
+	return own.ToNonAD()
+}
+
+// checkRecipientAllowed verifies the recipient against the session's allowlist,
+// writing an error response and returning false when the send must not proceed
+func (h *MessageHandler) checkRecipientAllowed(w http.ResponseWriter, sessionID domain.SessionID, recipient types.JID) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	allowed, err := h.multiSessionManager.IsRecipientAllowed(ctx, sessionID, recipient.ToNonAD().String())
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionID.String()).Msg("Failed to check recipient allowlist")
+		http.Error(w, "Failed to check recipient allowlist", http.StatusInternalServerError)
+		return false
+	}
+
+	if !allowed {
+		log.Warn().
+			Str("session_id", sessionID.String()).
+			Str("recipient", recipient.String()).
+			Msg("Recipient not in session allowlist")
+		http.Error(w, "Recipient is not in the session allowlist", http.StatusForbidden)
+		return false
+	}
+
+	return true
+}
+
+// applyQuote makes msg a reply to quotedID when one is given, writing an error
+// response and returning false when the quote cannot be built
+func (h *MessageHandler) applyQuote(w http.ResponseWriter, sessionID domain.SessionID, client *whatsmeow.Client, recipient types.JID, msg *waE2E.Message, quotedID, quotedPhone string) bool {
+	if quotedID == "" {
+		return true
+	}
+
+	var quotedSender types.JID
+	if quotedPhone != "" {
+		sender, err := parsePhoneToJID(quotedPhone)
+		if err != nil {
+			http.Error(w, "Invalid quoted phone number format", http.StatusBadRequest)
+			return false
+		}
+		quotedSender = selfChatJID(client, sender)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := h.multiSessionManager.QuoteMessage(ctx, sessionID, client, recipient, msg, quotedID, quotedSender); err != nil {
+		http.Error(w, fmt.Sprintf("Cannot quote message: %v", err), http.StatusBadRequest)
+		return false
+	}
+
+	return true
+}
+
+// startTracking registers a message for receipt tracking when the request
+// has track=true, returning the tracking token. It writes an error response
+// and returns false when tracking was requested but could not be started.
+func (h *MessageHandler) startTracking(w http.ResponseWriter, r *http.Request, sessionID domain.SessionID, messageID string) (string, bool) {
+	if r.URL.Query().Get("track") != "true" {
+		return "", true
+	}
+
+	token, err := h.multiSessionManager.TrackMessage(sessionID, messageID)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionID.String()).Msg("Failed to start receipt tracking")
+		http.Error(w, "Failed to start receipt tracking", http.StatusServiceUnavailable)
+		return "", false
+	}
+
+	return token, true
+}
+
+// requireConnectedSession returns the client of a session that is connected
+// and logged in. Otherwise it writes a 409 carrying the session's status and
+// returns false. allowOffline also lets through a session that has a client
+// but is offline, for sends held until it reconnects.
+func (h *MessageHandler) requireConnectedSession(w http.ResponseWriter, sessionID domain.SessionID, allowOffline bool) (*whatsmeow.Client, bool) {
+	status := h.multiSessionManager.GetSessionStatus(sessionID)
+
+	client, err := h.multiSessionManager.GetClient(sessionID)
+	if err == nil && (allowOffline || (status == services.StatusConnected && client.IsConnected() && client.IsLoggedIn())) {
+		return client, true
+	}
+
+	log.Warn().
+		Str("session_id", sessionID.String()).
+		Str("status", string(status)).
+		Msg("Message operation rejected, session not connected")
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: "session not connected", Status: string(status)})
+	return nil, false
+}
+
+// checkMaintenance writes a 503 and returns false while maintenance mode is on
+func (h *MessageHandler) checkMaintenance(w http.ResponseWriter) bool {
+	if !h.multiSessionManager.InMaintenance() {
+		return true
+	}
+
+	writeJSONError(w, http.StatusServiceUnavailable, "maintenance")
+	return false
+}
+
+// enqueueSend queues a message for asynchronous delivery and writes a 202 with the job ID
+func (h *MessageHandler) enqueueSend(w http.ResponseWriter, sessionID domain.SessionID, recipient types.JID, phone, messageID string, msg *waE2E.Message, trackingToken, priority string) {
+	sendPriority, err := services.ParseSendPriority(priority)
+	if err != nil {
+		if trackingToken != "" {
+			h.multiSessionManager.UntrackMessage(sessionID, messageID)
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	jobID, err := h.multiSessionManager.EnqueueSend(&services.SendJob{
+		SessionID: sessionID,
+		Recipient: recipient,
+		Phone:     phone,
+		MessageID: messageID,
+		Message:   msg,
+		Priority:  sendPriority,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionID.String()).Msg("Failed to enqueue message")
+		if trackingToken != "" {
+			h.multiSessionManager.UntrackMessage(sessionID, messageID)
+		}
+		http.Error(w, "Failed to enqueue message", http.StatusServiceUnavailable)
+		return
+	}
+
+	log.Info().
+		Str("session_id", sessionID.String()).
+		Str("job_id", jobID).
+		Str("message_id", messageID).
+		Msg("Message enqueued for async send")
+
+	writeAccepted(w, AsyncMessageResponse{
+		JobID:         jobID,
+		MessageID:     messageID,
+		Status:        "queued",
+		Phone:         phone,
+		SessionID:     sessionID.String(),
+		TrackingToken: trackingToken,
+	})
+}
+
+// enqueueOfflineSend holds a message until the session reconnects and writes a 202 with the job ID
+func (h *MessageHandler) enqueueOfflineSend(w http.ResponseWriter, sessionID domain.SessionID, recipient types.JID, phone, messageID string, msg *waE2E.Message, trackingToken, priority string) {
+	sendPriority, err := services.ParseSendPriority(priority)
+	if err != nil {
+		if trackingToken != "" {
+			h.multiSessionManager.UntrackMessage(sessionID, messageID)
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	jobID, err := h.multiSessionManager.EnqueueOfflineSend(&services.SendJob{
+		SessionID: sessionID,
+		Recipient: recipient,
+		Phone:     phone,
+		MessageID: messageID,
+		Message:   msg,
+		Priority:  sendPriority,
+	})
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionID.String()).Msg("Failed to hold message for offline session")
+		if trackingToken != "" {
+			h.multiSessionManager.UntrackMessage(sessionID, messageID)
+		}
+		http.Error(w, "Failed to enqueue message", http.StatusServiceUnavailable)
+		return
+	}
+
+	log.Info().
+		Str("session_id", sessionID.String()).
+		Str("job_id", jobID).
+		Str("message_id", messageID).
+		Msg("Session offline, message held until reconnect")
+
+	writeAccepted(w, AsyncMessageResponse{
+		JobID:         jobID,
+		MessageID:     messageID,
+		Status:        "pending_reconnect",
+		Phone:         phone,
+		SessionID:     sessionID.String(),
+		TrackingToken: trackingToken,
+	})
+}
+
+// writeAccepted writes the 202 response for a send handed off to the queue
+func writeAccepted(w http.ResponseWriter, response AsyncMessageResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(response)
+}
+
+// responseTime converts a send timestamp to the session timezone when the
+// request asked for session-local timestamps
+func (h *MessageHandler) responseTime(r *http.Request, sessionID domain.SessionID, t time.Time) time.Time {
+	if !wantsSessionTimezone(r) {
+		return t
+	}
+
+	loc, err := h.multiSessionManager.GetSessionLocation(r.Context(), sessionID)
+	if err != nil {
+		log.Warn().Err(err).Str("session_id", sessionID.String()).Msg("Failed to resolve session timezone")
+		return t
+	}
+
+	return t.In(loc)
+}
+
+// checkCooldown rejects sends with 429 while the session is cooling down after
+// a WhatsApp rate limit or has used up its daily send quota, returning false
+// when the send must not proceed. The quota itself is counted per message by
+// the send.
+func (h *MessageHandler) checkCooldown(w http.ResponseWriter, sessionID domain.SessionID) bool {
+	if remaining := h.multiSessionManager.CooldownRemaining(sessionID); remaining > 0 {
+		writeTooManyRequests(w, remaining)
+		return false
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if usage, exhausted := h.multiSessionManager.SendQuotaExhausted(ctx, sessionID); exhausted {
+		writeSendQuotaExceeded(w, usage)
+		return false
+	}
+	return true
+}
+
+// writeRateLimited answers a rate-limit send error with 429 and starts the
+// session cooldown, and a send refused by the daily send quota with 429
+// until the quota resets. It returns false, writing nothing, for other errors.
+func (h *MessageHandler) writeRateLimited(w http.ResponseWriter, sessionID domain.SessionID, err error) bool {
+	var quotaErr *services.SendQuotaError
+	if errors.As(err, &quotaErr) {
+		writeSendQuotaExceeded(w, &quotaErr.Usage)
+		return true
+	}
+	if !services.IsRateLimitError(err) {
+		return false
+	}
+
+	writeTooManyRequests(w, h.multiSessionManager.StartCooldown(sessionID))
+	return true
+}
+
+// writeTooManyRequests writes a 429 with a Retry-After header in seconds
+func writeTooManyRequests(w http.ResponseWriter, retryAfter time.Duration) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	http.Error(w, fmt.Sprintf("Rate limited by WhatsApp, retry in %d seconds", seconds), http.StatusTooManyRequests)
+}
+
+// writeSendQuotaExceeded writes a 429 with a Retry-After header until the
+// session's daily send quota resets
+func writeSendQuotaExceeded(w http.ResponseWriter, usage *services.SendQuotaUsage) {
+	seconds := int(math.Ceil(time.Until(usage.ResetsAt).Seconds()))
+	w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	http.Error(w, fmt.Sprintf("Daily send quota of %d messages reached, resets at %s",
+		usage.Limit, usage.ResetsAt.Format(time.RFC3339)), http.StatusTooManyRequests)
+}
+
+// SendImageMessage sends an image message
+func (h *MessageHandler) SendImageMessage(w http.ResponseWriter, r *http.Request) {
+	// Reject new sends while maintenance mode is on
+	if !h.checkMaintenance(w) {
+		return
+	}
+
+	sessionIDStr := chi.URLParam(r, "sessionId")
+
+	sessionID, err := domain.ParseSessionID(sessionIDStr)
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	var req SendImageMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+
+	// Validate required fields
+	if req.Phone == "" {
+		http.Error(w, "Phone number is required", http.StatusBadRequest)
+		return
+	}
+	if req.Image == "" {
+		http.Error(w, "Image is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get session client
+	client, ok := h.requireConnectedSession(w, sessionID, false)
+	if !ok {
+		return
+	}
+
+	// Parse recipient JID
+	recipient, err := parsePhoneToJID(req.Phone)
+	if err != nil {
+		log.Error().Err(err).Str("phone", req.Phone).Msg("Failed to parse phone number")
+		http.Error(w, "Invalid phone number format", http.StatusBadRequest)
+		return
+	}
+
+	// Messages to the session's own number go to its self-chat
+	recipient = selfChatJID(client, recipient)
+
+	// Enforce the session's recipient allowlist
+	if !h.checkRecipientAllowed(w, sessionID, recipient) {
+		return
+	}
+
+	// Fail fast while WhatsApp is rate limiting this session
+	if !h.checkCooldown(w, sessionID) {
+		return
+	}
+
+	// Generate message ID if not provided
+	messageID := req.ID
+	if messageID == "" {
+		messageID = client.GenerateMessageID()
+	} else if !h.checkMessageIDUnused(w, r, sessionID, messageID) {
+		return
+	}
+
+	// Validate image format
+	if err := h.mediaHelper.ValidateImageFormat(req.Image); err != nil {
+		log.Error().Err(err).Msg("Invalid image format")
+		http.Error(w, "Invalid image format", http.StatusBadRequest)
+		return
+	}
+
+	// Decode data URL
+	imageData, mimeType, err := h.mediaHelper.DecodeDataURL(req.Image)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to decode image data")
+		http.Error(w, fmt.Sprintf("Invalid image data: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// Optimize image before upload if requested
+	if req.Optimize {
+		originalSize := len(imageData)
+		imageData, mimeType, err = h.mediaHelper.OptimizeImage(imageData, req.Quality)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to optimize image")
+			http.Error(w, fmt.Sprintf("Failed to optimize image: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		log.Debug().
+			Int("original_size", originalSize).
+			Int("optimized_size", len(imageData)).
+			Msg("Image optimized")
+	}
+
+	// Generate thumbnail (optional - continue if fails)
+	thumbnailData, err := h.mediaHelper.GenerateThumbnail(imageData)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to generate thumbnail, continuing without thumbnail")
+		thumbnailData = []byte{} // Empty thumbnail
+	}
+
+	// Upload image to WhatsApp
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	uploaded, err := client.Upload(ctx, imageData, whatsmeow.MediaImage)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to upload image")
+		h.writeUploadError(w, sessionID, err, "image")
+		return
+	}
+
+	// Create image message
+	msg := &waE2E.Message{
+		ImageMessage: &waE2E.ImageMessage{
+			URL:           proto.String(uploaded.URL),
+			DirectPath:    proto.String(uploaded.DirectPath),
+			MediaKey:      uploaded.MediaKey,
+			Mimetype:      proto.String(mimeType),
+			FileEncSHA256: uploaded.FileEncSHA256,
+			FileSHA256:    uploaded.FileSHA256,
+			FileLength:    proto.Uint64(uint64(len(imageData))),
+			Caption:       proto.String(req.Caption),
+			JPEGThumbnail: thumbnailData,
+		},
+	}
+
+	// Reply to another message when asked to
+	if !h.applyQuote(w, sessionID, client, recipient, msg, req.QuotedMessageID, req.QuotedPhone) {
+		return
+	}
+
+	logOutgoingMessage(sessionID, recipient, messageID, msg)
+
+	// Hand off to the send queue when the caller wants the result by webhook
+	if req.Async {
+		h.enqueueSend(w, sessionID, recipient, req.Phone, messageID, msg, "", req.Priority)
+		return
+	}
+
+	// Send message
+	resp, err := h.multiSessionManager.SendMessage(ctx, sessionID, client, recipient, msg, messageID)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("session_id", sessionIDStr).
+			Str("phone", req.Phone).
+			Msg("Failed to send image message")
+		if h.writeRateLimited(w, sessionID, err) {
+			return
+		}
+		http.Error(w, fmt.Sprintf("Failed to send message: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Create response
+	response := MessageResponse{
+		MessageID: resp.ID,
+		Status:    "sent",
+		Timestamp: h.responseTime(r, sessionID, resp.Timestamp),
+		Phone:     req.Phone,
+		Recipient: recipient.String(),
+		SessionID: sessionIDStr,
+	}
+
+	log.Info().
+		Str("session_id", sessionIDStr).
+		Str("phone", req.Phone).
+		Str("message_id", resp.ID).
+		Msg("Image message sent successfully")
+
+	h.writeSendResponse(w, r, response)
+}
+
+// SendAudioMessage sends an audio message
+func (h *MessageHandler) SendAudioMessage(w http.ResponseWriter, r *http.Request) {
+	// Reject new sends while maintenance mode is on
+	if !h.checkMaintenance(w) {
+		return
+	}
+
+	sessionIDStr := chi.URLParam(r, "sessionId")
+
+	sessionID, err := domain.ParseSessionID(sessionIDStr)
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	var req SendAudioMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+
+	// Validate required fields
+	if req.Phone == "" {
+		http.Error(w, "Phone number is required", http.StatusBadRequest)
+		return
+	}
+	if req.Audio == "" {
+		http.Error(w, "Audio is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get session client
+	client, ok := h.requireConnectedSession(w, sessionID, false)
+	if !ok {
+		return
+	}
+
+	// Parse recipient JID
+	recipient, err := parsePhoneToJID(req.Phone)
+	if err != nil {
+		log.Error().Err(err).Str("phone", req.Phone).Msg("Failed to parse phone number")
+		http.Error(w, "Invalid phone number format", http.StatusBadRequest)
+		return
+	}
+
+	// Messages to the session's own number go to its self-chat
+	recipient = selfChatJID(client, recipient)
+
+	// Enforce the session's recipient allowlist
+	if !h.checkRecipientAllowed(w, sessionID, recipient) {
+		return
+	}
+
+	// Fail fast while WhatsApp is rate limiting this session
+	if !h.checkCooldown(w, sessionID) {
+		return
+	}
+
+	// Validate audio format
+	if err := h.mediaHelper.ValidateAudioFormat(req.Audio); err != nil {
+		log.Error().Err(err).Msg("Invalid audio format")
+		http.Error(w, "Invalid audio format: must be data:audio/ogg;base64", http.StatusBadRequest)
+		return
+	}
+
+	// Decode data URL
+	audioData, _, err := h.mediaHelper.DecodeDataURL(req.Audio)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to decode audio data")
+		http.Error(w, fmt.Sprintf("Invalid audio data: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// Generate message ID if not provided
+	messageID := req.ID
+	if messageID == "" {
+		messageID = client.GenerateMessageID()
+	} else if !h.checkMessageIDUnused(w, r, sessionID, messageID) {
+		return
+	}
+
+	// Upload audio to WhatsApp
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	uploaded, err := client.Upload(ctx, audioData, whatsmeow.MediaAudio)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to upload audio")
+		h.writeUploadError(w, sessionID, err, "audio")
+		return
+	}
+
+	// Create audio message (PTT - Push to Talk)
+	ptt := true
+	msg := &waE2E.Message{
+		AudioMessage: &waE2E.AudioMessage{
+			URL:           proto.String(uploaded.URL),
+			DirectPath:    proto.String(uploaded.DirectPath),
+			MediaKey:      uploaded.MediaKey,
+			Mimetype:      proto.String(h.mediaHelper.GetAudioMimeType()),
+			FileEncSHA256: uploaded.FileEncSHA256,
+			FileSHA256:    uploaded.FileSHA256,
+			FileLength:    proto.Uint64(uint64(len(audioData))),
+			PTT:           &ptt,
+		},
+	}
+
+	// Reply to another message when asked to
+	if !h.applyQuote(w, sessionID, client, recipient, msg, req.QuotedMessageID, req.QuotedPhone) {
+		return
+	}
+
+	logOutgoingMessage(sessionID, recipient, messageID, msg)
+
+	// Hand off to the send queue when the caller wants the result by webhook
+	if req.Async {
+		h.enqueueSend(w, sessionID, recipient, req.Phone, messageID, msg, "", req.Priority)
+		return
+	}
+
+	// Send message
+	resp, err := h.multiSessionManager.SendMessage(ctx, sessionID, client, recipient, msg, messageID)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("session_id", sessionIDStr).
+			Str("phone", req.Phone).
+			Msg("Failed to send audio message")
+		if h.writeRateLimited(w, sessionID, err) {
+			return
+		}
+		http.Error(w, fmt.Sprintf("Failed to send message: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Create response
+	response := MessageResponse{
+		MessageID: resp.ID,
+		Status:    "sent",
+		Timestamp: h.responseTime(r, sessionID, resp.Timestamp),
+		Phone:     req.Phone,
+		Recipient: recipient.String(),
+		SessionID: sessionIDStr,
+	}
+
+	log.Info().
+		Str("session_id", sessionIDStr).
+		Str("phone", req.Phone).
+		Str("message_id", resp.ID).
+		Msg("Audio message sent successfully")
+
+	h.writeSendResponse(w, r, response)
+}
+
+// SendVideoMessage sends a video message
+func (h *MessageHandler) SendVideoMessage(w http.ResponseWriter, r *http.Request) {
+	// Reject new sends while maintenance mode is on
+	if !h.checkMaintenance(w) {
+		return
+	}
+
+	sessionIDStr := chi.URLParam(r, "sessionId")
+
+	sessionID, err := domain.ParseSessionID(sessionIDStr)
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	var req SendVideoMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+
+	// Validate required fields
+	if req.Phone == "" {
+		http.Error(w, "Phone number is required", http.StatusBadRequest)
+		return
+	}
+	if req.Video == "" {
+		http.Error(w, "Video is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get session client
+	client, ok := h.requireConnectedSession(w, sessionID, false)
+	if !ok {
+		return
+	}
+
+	// Parse recipient JID
+	recipient, err := parsePhoneToJID(req.Phone)
+	if err != nil {
+		log.Error().Err(err).Str("phone", req.Phone).Msg("Failed to parse phone number")
+		http.Error(w, "Invalid phone number format", http.StatusBadRequest)
+		return
+	}
+
+	// Messages to the session's own number go to its self-chat
+	recipient = selfChatJID(client, recipient)
+
+	// Enforce the session's recipient allowlist
+	if !h.checkRecipientAllowed(w, sessionID, recipient) {
+		return
+	}
+
+	// Fail fast while WhatsApp is rate limiting this session
+	if !h.checkCooldown(w, sessionID) {
+		return
+	}
+
+	// Validate video format
+	if err := h.mediaHelper.ValidateVideoFormat(req.Video); err != nil {
+		log.Error().Err(err).Msg("Invalid video format")
+		http.Error(w, "Invalid video format: must be data:video/*", http.StatusBadRequest)
+		return
+	}
+
+	// Decode data URL
+	videoData, mimeType, err := h.mediaHelper.DecodeDataURL(req.Video)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to decode video data")
+		http.Error(w, fmt.Sprintf("Invalid video data: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// Generate message ID if not provided
+	messageID := req.ID
+	if messageID == "" {
+		messageID = client.GenerateMessageID()
+	} else if !h.checkMessageIDUnused(w, r, sessionID, messageID) {
+		return
+	}
+
+	// Upload video to WhatsApp
+	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second) // Longer timeout for videos
+	defer cancel()
+
+	uploaded, err := client.Upload(ctx, videoData, whatsmeow.MediaVideo)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to upload video")
+		h.writeUploadError(w, sessionID, err, "video")
+		return
+	}
+
+	// Generate video thumbnail (placeholder for now)
+	thumbnailData, _ := h.mediaHelper.GenerateVideoThumbnail(videoData)
+
+	// Create video message
+	msg := &waE2E.Message{
+		VideoMessage: &waE2E.VideoMessage{
+			URL:           proto.String(uploaded.URL),
+			DirectPath:    proto.String(uploaded.DirectPath),
+			MediaKey:      uploaded.MediaKey,
+			Mimetype:      proto.String(mimeType),
+			FileEncSHA256: uploaded.FileEncSHA256,
+			FileSHA256:    uploaded.FileSHA256,
+			FileLength:    proto.Uint64(uint64(len(videoData))),
+			Caption:       proto.String(req.Caption),
+			JPEGThumbnail: thumbnailData,
+		},
+	}
+
+	// Reply to another message when asked to
+	if !h.applyQuote(w, sessionID, client, recipient, msg, req.QuotedMessageID, req.QuotedPhone) {
+		return
+	}
+
+	logOutgoingMessage(sessionID, recipient, messageID, msg)
+
+	// Hand off to the send queue when the caller wants the result by webhook
+	if req.Async {
+		h.enqueueSend(w, sessionID, recipient, req.Phone, messageID, msg, "", req.Priority)
+		return
+	}
+
+	// Send message
+	resp, err := h.multiSessionManager.SendMessage(ctx, sessionID, client, recipient, msg, messageID)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("session_id", sessionIDStr).
+			Str("phone", req.Phone).
+			Msg("Failed to send video message")
+		if h.writeRateLimited(w, sessionID, err) {
+			return
+		}
+		http.Error(w, fmt.Sprintf("Failed to send message: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Create response
+	response := MessageResponse{
+		MessageID: resp.ID,
+		Status:    "sent",
+		Timestamp: h.responseTime(r, sessionID, resp.Timestamp),
+		Phone:     req.Phone,
+		Recipient: recipient.String(),
+		SessionID: sessionIDStr,
+	}
+
+	log.Info().
+		Str("session_id", sessionIDStr).
+		Str("phone", req.Phone).
+		Str("message_id", resp.ID).
+		Msg("Video message sent successfully")
+
+	h.writeSendResponse(w, r, response)
+}
+
+// SendDocumentMessage sends a document message
+func (h *MessageHandler) SendDocumentMessage(w http.ResponseWriter, r *http.Request) {
+	// Reject new sends while maintenance mode is on
+	if !h.checkMaintenance(w) {
+		return
+	}
+
+	sessionIDStr := chi.URLParam(r, "sessionId")
+
+	sessionID, err := domain.ParseSessionID(sessionIDStr)
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	var req SendDocumentMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+
+	// Validate required fields
+	if req.Phone == "" {
+		http.Error(w, "Phone number is required", http.StatusBadRequest)
+		return
+	}
+	if req.Document == "" {
+		http.Error(w, "Document is required", http.StatusBadRequest)
+		return
+	}
+	if req.Filename == "" {
+		http.Error(w, "Filename is required", http.StatusBadRequest)
+		return
+	}
+
+	// Get session client
+	client, ok := h.requireConnectedSession(w, sessionID, false)
+	if !ok {
+		return
+	}
+
+	// Parse recipient JID
+	recipient, err := parsePhoneToJID(req.Phone)
+	if err != nil {
+		log.Error().Err(err).Str("phone", req.Phone).Msg("Failed to parse phone number")
+		http.Error(w, "Invalid phone number format", http.StatusBadRequest)
+		return
+	}
+
+	// Messages to the session's own number go to its self-chat
+	recipient = selfChatJID(client, recipient)
+
+	// Enforce the session's recipient allowlist
+	if !h.checkRecipientAllowed(w, sessionID, recipient) {
+		return
+	}
+
+	// Fail fast while WhatsApp is rate limiting this session
+	if !h.checkCooldown(w, sessionID) {
+		return
+	}
+
+	// Validate document format
+	if err := h.mediaHelper.ValidateDocumentFormat(req.Document); err != nil {
+		log.Error().Err(err).Msg("Invalid document format")
+		http.Error(w, "Invalid document format: must be data:application/octet-stream;base64", http.StatusBadRequest)
+		return
+	}
+
+	// Decode data URL
+	documentData, _, err := h.mediaHelper.DecodeDataURL(req.Document)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to decode document data")
+		http.Error(w, fmt.Sprintf("Invalid document data: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	// Generate message ID if not provided
+	messageID := req.ID
+	if messageID == "" {
+		messageID = client.GenerateMessageID()
+	} else if !h.checkMessageIDUnused(w, r, sessionID, messageID) {
+		return
+	}
+
+	// Upload document to WhatsApp
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	uploaded, err := client.Upload(ctx, documentData, whatsmeow.MediaDocument)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to upload document")
+		h.writeUploadError(w, sessionID, err, "document")
+		return
+	}
+
+	// Determine MIME type
+	mimeType := req.Mimetype
+	if mimeType == "" {
+		mimeType = h.mediaHelper.DetectMimeType(documentData)
+	}
+
+	// Create document message
+	msg := &waE2E.Message{
+		DocumentMessage: &waE2E.DocumentMessage{
+			URL:           proto.String(uploaded.URL),
+			DirectPath:    proto.String(uploaded.DirectPath),
+			MediaKey:      uploaded.MediaKey,
+			Mimetype:      proto.String(mimeType),
+			FileEncSHA256: uploaded.FileEncSHA256,
+			FileSHA256:    uploaded.FileSHA256,
+			FileLength:    proto.Uint64(uint64(len(documentData))),
+			FileName:      proto.String(req.Filename),
+		},
+	}
+
+	// Reply to another message when asked to
+	if !h.applyQuote(w, sessionID, client, recipient, msg, req.QuotedMessageID, req.QuotedPhone) {
+		return
+	}
+
+	logOutgoingMessage(sessionID, recipient, messageID, msg)
+
+	// Hand off to the send queue when the caller wants the result by webhook
+	if req.Async {
+		h.enqueueSend(w, sessionID, recipient, req.Phone, messageID, msg, "", req.Priority)
+		return
+	}
+
+	// Send message
+	resp, err := h.multiSessionManager.SendMessage(ctx, sessionID, client, recipient, msg, messageID)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("session_id", sessionIDStr).
+			Str("phone", req.Phone).
+			Msg("Failed to send document message")
+		if h.writeRateLimited(w, sessionID, err) {
+			return
+		}
+		http.Error(w, fmt.Sprintf("Failed to send message: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Create response
+	response := MessageResponse{
+		MessageID: resp.ID,
+		Status:    "sent",
+		Timestamp: h.responseTime(r, sessionID, resp.Timestamp),
+		Phone:     req.Phone,
+		Recipient: recipient.String(),
+		SessionID: sessionIDStr,
+	}
+
+	log.Info().
+		Str("session_id", sessionIDStr).
+		Str("phone", req.Phone).
+		Str("message_id", resp.ID).
+		Str("filename", req.Filename).
+		Msg("Document message sent successfully")
+
+	h.writeSendResponse(w, r, response)
+}
+
+// SendLocationMessage sends a location message
+func (h *MessageHandler) SendLocationMessage(w http.ResponseWriter, r *http.Request) {
+	// Reject new sends while maintenance mode is on
+	if !h.checkMaintenance(w) {
+		return
+	}
+
+	sessionIDStr := chi.URLParam(r, "sessionId")
+
+	sessionID, err := domain.ParseSessionID(sessionIDStr)
+	if err != nil {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	var req SendLocationMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
+		return
+	}
+
+	// Validate required fields
+	if req.Phone == "" {
+		http.Error(w, "Phone number is required", http.StatusBadRequest)
+		return
+	}
+
+	// Validate coordinates
+	if err := h.mediaHelper.IsValidCoordinate(req.Latitude, req.Longitude); err != nil {
+		log.Error().Err(err).Msg("Invalid coordinates")
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// Get session client
+	client, ok := h.requireConnectedSession(w, sessionID, req.QueueIfOffline)
+	if !ok {
+		return
+	}
+
+	// Parse recipient JID
+	recipient, err := parsePhoneToJID(req.Phone)
+	if err != nil {
+		log.Error().Err(err).Str("phone", req.Phone).Msg("Failed to parse phone number")
+		http.Error(w, "Invalid phone number format", http.StatusBadRequest)
+		return
+	}
+
+	// Messages to the session's own number go to its self-chat
+	recipient = selfChatJID(client, recipient)
+
+	// Enforce the session's recipient allowlist
+	if !h.checkRecipientAllowed(w, sessionID, recipient) {
+		return
+	}
+
+	// Fail fast while WhatsApp is rate limiting this session
+	if !h.checkCooldown(w, sessionID) {
+		return
+	}
+
+	// Generate message ID if not provided
+	messageID := req.ID
+	if messageID == "" {
+		messageID = client.GenerateMessageID()
+	} else if !h.checkMessageIDUnused(w, r, sessionID, messageID) {
+		return
+	}
+
+	// Create location message
+	msg := &waE2E.Message{
+		LocationMessage: &waE2E.LocationMessage{
+			DegreesLatitude:  proto.Float64(req.Latitude),
+			DegreesLongitude: proto.Float64(req.Longitude),
+			Name:             proto.String(req.Name),
+			Address:          proto.String(req.Address),
+		},
+	}
+
+	// Reply to another message when asked to
+	if !h.applyQuote(w, sessionID, client, recipient, msg, req.QuotedMessageID, req.QuotedPhone) {
+		return
+	}
+
+	logOutgoingMessage(sessionID, recipient, messageID, msg)
+
+	// Hold the send until the session reconnects when asked to
+	if req.QueueIfOffline && !client.IsConnected() {
+		h.enqueueOfflineSend(w, sessionID, recipient, req.Phone, messageID, msg, "", req.Priority)
+		return
+	}
+
+	// Hand off to the send queue when the caller wants the result by webhook
+	if req.Async {
+		h.enqueueSend(w, sessionID, recipient, req.Phone, messageID, msg, "", req.Priority)
+		return
+	}
+
+	// Send message
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	resp, err := h.multiSessionManager.SendMessage(ctx, sessionID, client, recipient, msg, messageID)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("session_id", sessionIDStr).
+			Str("phone", req.Phone).
+			Msg("Failed to send location message")
+		if h.writeRateLimited(w, sessionID, err) {
+			return
+		}
+		http.Error(w, fmt.Sprintf("Failed to send message: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	// Create response
+	response := MessageResponse{
+		MessageID: resp.ID,
+		Status:    "sent",
+		Timestamp: h.responseTime(r, sessionID, resp.Timestamp),
+		Phone:     req.Phone,
+		Recipient: recipient.String(),
+		SessionID: sessionIDStr,
+	}
+
+	log.Info().
+		Str("session_id", sessionIDStr).
+		Str("phone", req.Phone).
+		Str("message_id", resp.ID).
+		Float64("latitude", req.Latitude).
+		Float64("longitude", req.Longitude).
+		Msg("Location message sent successfully")
+
+	h.writeSendResponse(w, r, response)
+}
+
+// SendProductMessage sends a product from a business catalog
+func (h *MessageHandler) SendProductMessage(w http.ResponseWriter, r *http.Request) {
+	// Reject new sends while maintenance mode is on
+	if !h.checkMaintenance(w) {
+		return
+	}
+
 	sessionIDStr := chi.URLParam(r, "sessionId")
 
 	sessionID, err := domain.ParseSessionID(sessionIDStr)
@@ -42,7 +1689,7 @@ func (h *MessageHandler) SendTextMessage(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	var req SendTextMessageRequest
+	var req SendProductMessageRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
 		return
@@ -53,51 +1700,170 @@ func (h *MessageHandler) SendTextMessage(w http.ResponseWriter, r *http.Request)
 		http.Error(w, "Phone number is required", http.StatusBadRequest)
 		return
 	}
-	if req.Message == "" {
-		http.Error(w, "Message is required", http.StatusBadRequest)
+	if req.ProductID == "" {
+		http.Error(w, "Product ID is required", http.StatusBadRequest)
+		return
+	}
+	if req.Price < 0 {
+		http.Error(w, "Price must not be negative", http.StatusBadRequest)
+		return
+	}
+	if req.Price > 0 && len(req.CurrencyCode) != 3 {
+		http.Error(w, "A 3-letter currency code is required with a price", http.StatusBadRequest)
 		return
 	}
 
 	// Get session client
-	client, err := h.multiSessionManager.GetClient(sessionID)
+	client, ok := h.requireConnectedSession(w, sessionID, false)
+	if !ok {
+		return
+	}
+
+	// Only business accounts have a catalog to send products from
+	isBusiness, err := h.multiSessionManager.IsBusinessAccount(sessionID)
 	if err != nil {
-		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to get session client")
-		http.Error(w, "Session not found or not connected", http.StatusNotFound)
+		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to check business account")
+
+		switch err.(type) {
+		case *domain.BusinessError:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to check business account", http.StatusInternalServerError)
+		}
+		return
+	}
+	if !isBusiness {
+		http.Error(w, "Session is not a WhatsApp Business account", http.StatusConflict)
 		return
 	}
 
 	// Parse recipient JID
-	recipient, err := h.parsePhoneToJID(req.Phone)
+	recipient, err := parsePhoneToJID(req.Phone)
 	if err != nil {
 		log.Error().Err(err).Str("phone", req.Phone).Msg("Failed to parse phone number")
 		http.Error(w, "Invalid phone number format", http.StatusBadRequest)
 		return
 	}
 
+	// Messages to the session's own number go to its self-chat
+	recipient = selfChatJID(client, recipient)
+
+	// Catalog owner defaults to the session's own account
+	businessJID := client.Store.ID.ToNonAD()
+	if req.BusinessJID != "" {
+		if businessJID, err = parsePhoneToJID(req.BusinessJID); err != nil {
+			http.Error(w, "Invalid business JID", http.StatusBadRequest)
+			return
+		}
+	}
+
+	// Enforce the session's recipient allowlist
+	if !h.checkRecipientAllowed(w, sessionID, recipient) {
+		return
+	}
+
+	// Fail fast while WhatsApp is rate limiting this session
+	if !h.checkCooldown(w, sessionID) {
+		return
+	}
+
 	// Generate message ID if not provided
 	messageID := req.ID
 	if messageID == "" {
 		messageID = client.GenerateMessageID()
+	} else if !h.checkMessageIDUnused(w, r, sessionID, messageID) {
+		return
 	}
 
-	// Create text message
+	product := &waE2E.ProductMessage_ProductSnapshot{
+		ProductID:   proto.String(req.ProductID),
+		Title:       proto.String(req.Title),
+		Description: proto.String(req.Description),
+		RetailerID:  proto.String(req.RetailerID),
+		URL:         proto.String(req.URL),
+	}
+	if req.Price > 0 {
+		product.CurrencyCode = proto.String(strings.ToUpper(req.CurrencyCode))
+		product.PriceAmount1000 = proto.Int64(int64(math.Round(req.Price * 1000)))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	// Upload the product image when given
+	if req.Image != "" {
+		if err := h.mediaHelper.ValidateImageFormat(req.Image); err != nil {
+			http.Error(w, "Invalid image format", http.StatusBadRequest)
+			return
+		}
+
+		imageData, mimeType, err := h.mediaHelper.DecodeDataURL(req.Image)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to decode product image data")
+			http.Error(w, fmt.Sprintf("Invalid image data: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		thumbnailData, err := h.mediaHelper.GenerateThumbnail(imageData)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to generate thumbnail, continuing without thumbnail")
+			thumbnailData = []byte{}
+		}
+
+		uploaded, err := client.Upload(ctx, imageData, whatsmeow.MediaImage)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to upload product image")
+			h.writeUploadError(w, sessionID, err, "image")
+			return
+		}
+
+		product.ProductImage = &waE2E.ImageMessage{
+			URL:           proto.String(uploaded.URL),
+			DirectPath:    proto.String(uploaded.DirectPath),
+			MediaKey:      uploaded.MediaKey,
+			Mimetype:      proto.String(mimeType),
+			FileEncSHA256: uploaded.FileEncSHA256,
+			FileSHA256:    uploaded.FileSHA256,
+			FileLength:    proto.Uint64(uint64(len(imageData))),
+			JPEGThumbnail: thumbnailData,
+		}
+		product.ProductImageCount = proto.Uint32(1)
+	}
+
+	// Create product message
 	msg := &waE2E.Message{
-		ExtendedTextMessage: &waE2E.ExtendedTextMessage{
-			Text: proto.String(req.Message),
+		ProductMessage: &waE2E.ProductMessage{
+			Product:          product,
+			BusinessOwnerJID: proto.String(businessJID.String()),
+			Body:             proto.String(req.Body),
+			Footer:           proto.String(req.Footer),
 		},
 	}
 
-	// Send message
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
+	// Reply to another message when asked to
+	if !h.applyQuote(w, sessionID, client, recipient, msg, req.QuotedMessageID, req.QuotedPhone) {
+		return
+	}
+
+	logOutgoingMessage(sessionID, recipient, messageID, msg)
+
+	// Hand off to the send queue when the caller wants the result by webhook
+	if req.Async {
+		h.enqueueSend(w, sessionID, recipient, req.Phone, messageID, msg, "", req.Priority)
+		return
+	}
 
-	resp, err := client.SendMessage(ctx, recipient, msg, whatsmeow.SendRequestExtra{ID: messageID})
+	// Send message
+	resp, err := h.multiSessionManager.SendMessage(ctx, sessionID, client, recipient, msg, messageID)
 	if err != nil {
 		log.Error().
 			Err(err).
 			Str("session_id", sessionIDStr).
 			Str("phone", req.Phone).
-			Msg("Failed to send text message")
+			Msg("Failed to send product message")
+		if h.writeRateLimited(w, sessionID, err) {
+			return
+		}
 		http.Error(w, fmt.Sprintf("Failed to send message: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -106,8 +1872,9 @@ func (h *MessageHandler) SendTextMessage(w http.ResponseWriter, r *http.Request)
 	response := MessageResponse{
 		MessageID: resp.ID,
 		Status:    "sent",
-		Timestamp: resp.Timestamp,
+		Timestamp: h.responseTime(r, sessionID, resp.Timestamp),
 		Phone:     req.Phone,
+		Recipient: recipient.String(),
 		SessionID: sessionIDStr,
 	}
 
@@ -115,44 +1882,19 @@ func (h *MessageHandler) SendTextMessage(w http.ResponseWriter, r *http.Request)
 		Str("session_id", sessionIDStr).
 		Str("phone", req.Phone).
 		Str("message_id", resp.ID).
-		Msg("Text message sent successfully")
+		Str("product_id", req.ProductID).
+		Msg("Product message sent successfully")
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	h.writeSendResponse(w, r, response)
 }
 
-// parsePhoneToJID converts a phone number to WhatsApp JID
-func (h *MessageHandler) parsePhoneToJID(phone string) (types.JID, error) {
-	// Remove any non-numeric characters except +
-	cleanPhone := ""
-	for _, char := range phone {
-		if char >= '0' && char <= '9' {
-			cleanPhone += string(char)
-		}
-	}
-
-	// Remove leading + if present
-	if len(phone) > 0 && phone[0] == '+' {
-		// Keep the clean phone as is
-	}
-
-	// Ensure we have a valid phone number
-	if len(cleanPhone) < 10 {
-		return types.JID{}, fmt.Errorf("phone number too short: %s", phone)
-	}
-
-	// Create JID for individual chat
-	jid := types.JID{
-		User:   cleanPhone,
-		Server: "s.whatsapp.net",
+// SendContactMessage sends a contact message
+func (h *MessageHandler) SendContactMessage(w http.ResponseWriter, r *http.Request) {
+	// Reject new sends while maintenance mode is on
+	if !h.checkMaintenance(w) {
+		return
 	}
 
-	return jid, nil
-}
-
-// SendImageMessage sends an image message
-func (h *MessageHandler) SendImageMessage(w http.ResponseWriter, r *http.Request) {
 	sessionIDStr := chi.URLParam(r, "sessionId")
 
 	sessionID, err := domain.ParseSessionID(sessionIDStr)
@@ -161,7 +1903,7 @@ func (h *MessageHandler) SendImageMessage(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	var req SendImageMessageRequest
+	var req SendContactMessageRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
 		return
@@ -172,89 +1914,94 @@ func (h *MessageHandler) SendImageMessage(w http.ResponseWriter, r *http.Request
 		http.Error(w, "Phone number is required", http.StatusBadRequest)
 		return
 	}
-	if req.Image == "" {
-		http.Error(w, "Image is required", http.StatusBadRequest)
+	if req.ContactPhone == "" {
+		http.Error(w, "Contact phone is required", http.StatusBadRequest)
+		return
+	}
+	if req.ContactName == "" {
+		http.Error(w, "Contact name is required", http.StatusBadRequest)
 		return
 	}
 
 	// Get session client
-	client, err := h.multiSessionManager.GetClient(sessionID)
-	if err != nil {
-		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to get session client")
-		http.Error(w, "Session not found or not connected", http.StatusNotFound)
+	client, ok := h.requireConnectedSession(w, sessionID, req.QueueIfOffline)
+	if !ok {
 		return
 	}
 
 	// Parse recipient JID
-	recipient, err := h.parsePhoneToJID(req.Phone)
+	recipient, err := parsePhoneToJID(req.Phone)
 	if err != nil {
 		log.Error().Err(err).Str("phone", req.Phone).Msg("Failed to parse phone number")
 		http.Error(w, "Invalid phone number format", http.StatusBadRequest)
 		return
 	}
 
+	// Messages to the session's own number go to its self-chat
+	recipient = selfChatJID(client, recipient)
+
+	// Enforce the session's recipient allowlist
+	if !h.checkRecipientAllowed(w, sessionID, recipient) {
+		return
+	}
+
+	// Fail fast while WhatsApp is rate limiting this session
+	if !h.checkCooldown(w, sessionID) {
+		return
+	}
+
 	// Generate message ID if not provided
 	messageID := req.ID
 	if messageID == "" {
 		messageID = client.GenerateMessageID()
-	}
-
-	// Validate image format
-	if err := h.mediaHelper.ValidateImageFormat(req.Image); err != nil {
-		log.Error().Err(err).Msg("Invalid image format")
-		http.Error(w, "Invalid image format", http.StatusBadRequest)
+	} else if !h.checkMessageIDUnused(w, r, sessionID, messageID) {
 		return
 	}
 
-	// Decode data URL
-	imageData, mimeType, err := h.mediaHelper.DecodeDataURL(req.Image)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to decode image data")
-		http.Error(w, "Invalid image data", http.StatusBadRequest)
-		return
+	// Create vCard
+	vcard := h.mediaHelper.FormatVCard(req.ContactName, req.ContactPhone)
+
+	// Create contact message
+	msg := &waE2E.Message{
+		ContactMessage: &waE2E.ContactMessage{
+			DisplayName: proto.String(req.ContactName),
+			Vcard:       proto.String(vcard),
+		},
 	}
 
-	// Generate thumbnail (optional - continue if fails)
-	thumbnailData, err := h.mediaHelper.GenerateThumbnail(imageData)
-	if err != nil {
-		log.Warn().Err(err).Msg("Failed to generate thumbnail, continuing without thumbnail")
-		thumbnailData = []byte{} // Empty thumbnail
+	// Reply to another message when asked to
+	if !h.applyQuote(w, sessionID, client, recipient, msg, req.QuotedMessageID, req.QuotedPhone) {
+		return
 	}
 
-	// Upload image to WhatsApp
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
+	logOutgoingMessage(sessionID, recipient, messageID, msg)
 
-	uploaded, err := client.Upload(ctx, imageData, whatsmeow.MediaImage)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to upload image")
-		http.Error(w, fmt.Sprintf("Failed to upload image: %v", err), http.StatusInternalServerError)
+	// Hold the send until the session reconnects when asked to
+	if req.QueueIfOffline && !client.IsConnected() {
+		h.enqueueOfflineSend(w, sessionID, recipient, req.Phone, messageID, msg, "", req.Priority)
 		return
 	}
 
-	// Create image message
-	msg := &waE2E.Message{
-		ImageMessage: &waE2E.ImageMessage{
-			URL:           proto.String(uploaded.URL),
-			DirectPath:    proto.String(uploaded.DirectPath),
-			MediaKey:      uploaded.MediaKey,
-			Mimetype:      proto.String(mimeType),
-			FileEncSHA256: uploaded.FileEncSHA256,
-			FileSHA256:    uploaded.FileSHA256,
-			FileLength:    proto.Uint64(uint64(len(imageData))),
-			Caption:       proto.String(req.Caption),
-			JPEGThumbnail: thumbnailData,
-		},
+	// Hand off to the send queue when the caller wants the result by webhook
+	if req.Async {
+		h.enqueueSend(w, sessionID, recipient, req.Phone, messageID, msg, "", req.Priority)
+		return
 	}
 
 	// Send message
-	resp, err := client.SendMessage(ctx, recipient, msg, whatsmeow.SendRequestExtra{ID: messageID})
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	resp, err := h.multiSessionManager.SendMessage(ctx, sessionID, client, recipient, msg, messageID)
 	if err != nil {
 		log.Error().
 			Err(err).
 			Str("session_id", sessionIDStr).
 			Str("phone", req.Phone).
-			Msg("Failed to send image message")
+			Msg("Failed to send contact message")
+		if h.writeRateLimited(w, sessionID, err) {
+			return
+		}
 		http.Error(w, fmt.Sprintf("Failed to send message: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -263,8 +2010,9 @@ func (h *MessageHandler) SendImageMessage(w http.ResponseWriter, r *http.Request
 	response := MessageResponse{
 		MessageID: resp.ID,
 		Status:    "sent",
-		Timestamp: resp.Timestamp,
+		Timestamp: h.responseTime(r, sessionID, resp.Timestamp),
 		Phone:     req.Phone,
+		Recipient: recipient.String(),
 		SessionID: sessionIDStr,
 	}
 
@@ -272,15 +2020,21 @@ func (h *MessageHandler) SendImageMessage(w http.ResponseWriter, r *http.Request
 		Str("session_id", sessionIDStr).
 		Str("phone", req.Phone).
 		Str("message_id", resp.ID).
-		Msg("Image message sent successfully")
+		Str("contact_name", req.ContactName).
+		Str("contact_phone", req.ContactPhone).
+		Msg("Contact message sent successfully")
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	h.writeSendResponse(w, r, response)
 }
 
-// SendAudioMessage sends an audio message
-func (h *MessageHandler) SendAudioMessage(w http.ResponseWriter, r *http.Request) {
+// SendReactionMessage reacts to a message with an emoji, or removes the
+// session's reaction when the emoji is empty
+func (h *MessageHandler) SendReactionMessage(w http.ResponseWriter, r *http.Request) {
+	// Reject new sends while maintenance mode is on
+	if !h.checkMaintenance(w) {
+		return
+	}
+
 	sessionIDStr := chi.URLParam(r, "sessionId")
 
 	sessionID, err := domain.ParseSessionID(sessionIDStr)
@@ -289,7 +2043,7 @@ func (h *MessageHandler) SendAudioMessage(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	var req SendAudioMessageRequest
+	var req SendReactionMessageRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
 		return
@@ -300,82 +2054,69 @@ func (h *MessageHandler) SendAudioMessage(w http.ResponseWriter, r *http.Request
 		http.Error(w, "Phone number is required", http.StatusBadRequest)
 		return
 	}
-	if req.Audio == "" {
-		http.Error(w, "Audio is required", http.StatusBadRequest)
+	if req.MessageID == "" {
+		http.Error(w, "Message ID is required", http.StatusBadRequest)
 		return
 	}
 
 	// Get session client
-	client, err := h.multiSessionManager.GetClient(sessionID)
-	if err != nil {
-		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to get session client")
-		http.Error(w, "Session not found or not connected", http.StatusNotFound)
+	client, ok := h.requireConnectedSession(w, sessionID, false)
+	if !ok {
 		return
 	}
 
 	// Parse recipient JID
-	recipient, err := h.parsePhoneToJID(req.Phone)
+	recipient, err := parsePhoneToJID(req.Phone)
 	if err != nil {
 		log.Error().Err(err).Str("phone", req.Phone).Msg("Failed to parse phone number")
 		http.Error(w, "Invalid phone number format", http.StatusBadRequest)
 		return
 	}
 
-	// Validate audio format
-	if err := h.mediaHelper.ValidateAudioFormat(req.Audio); err != nil {
-		log.Error().Err(err).Msg("Invalid audio format")
-		http.Error(w, "Invalid audio format: must be data:audio/ogg;base64", http.StatusBadRequest)
-		return
-	}
+	// Messages to the session's own number go to its self-chat
+	recipient = selfChatJID(client, recipient)
 
-	// Decode data URL
-	audioData, _, err := h.mediaHelper.DecodeDataURL(req.Audio)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to decode audio data")
-		http.Error(w, "Invalid audio data", http.StatusBadRequest)
+	// Enforce the session's recipient allowlist
+	if !h.checkRecipientAllowed(w, sessionID, recipient) {
 		return
 	}
 
-	// Generate message ID if not provided
-	messageID := req.ID
-	if messageID == "" {
-		messageID = client.GenerateMessageID()
+	// Fail fast while WhatsApp is rate limiting this session
+	if !h.checkCooldown(w, sessionID) {
+		return
 	}
 
-	// Upload audio to WhatsApp
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
-
-	uploaded, err := client.Upload(ctx, audioData, whatsmeow.MediaAudio)
+	sender, err := h.reactionSender(r.Context(), sessionID, recipient, req)
 	if err != nil {
-		log.Error().Err(err).Msg("Failed to upload audio")
-		http.Error(w, fmt.Sprintf("Failed to upload audio: %v", err), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	// Create audio message (PTT - Push to Talk)
-	ptt := true
-	msg := &waE2E.Message{
-		AudioMessage: &waE2E.AudioMessage{
-			URL:           proto.String(uploaded.URL),
-			DirectPath:    proto.String(uploaded.DirectPath),
-			MediaKey:      uploaded.MediaKey,
-			Mimetype:      proto.String(h.mediaHelper.GetAudioMimeType()),
-			FileEncSHA256: uploaded.FileEncSHA256,
-			FileSHA256:    uploaded.FileSHA256,
-			FileLength:    proto.Uint64(uint64(len(audioData))),
-			PTT:           &ptt,
-		},
+	messageID := client.GenerateMessageID()
+	msg := client.BuildReaction(recipient, sender, req.MessageID, req.Reaction)
+
+	logOutgoingMessage(sessionID, recipient, messageID, msg)
+
+	// Hand off to the send queue when the caller wants the result by webhook
+	if req.Async {
+		h.enqueueSend(w, sessionID, recipient, req.Phone, messageID, msg, "", req.Priority)
+		return
 	}
 
 	// Send message
-	resp, err := client.SendMessage(ctx, recipient, msg, whatsmeow.SendRequestExtra{ID: messageID})
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	resp, err := h.multiSessionManager.SendMessage(ctx, sessionID, client, recipient, msg, messageID)
 	if err != nil {
 		log.Error().
 			Err(err).
 			Str("session_id", sessionIDStr).
 			Str("phone", req.Phone).
-			Msg("Failed to send audio message")
+			Msg("Failed to send reaction")
+		if h.writeRateLimited(w, sessionID, err) {
+			return
+		}
 		http.Error(w, fmt.Sprintf("Failed to send message: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -384,8 +2125,9 @@ func (h *MessageHandler) SendAudioMessage(w http.ResponseWriter, r *http.Request
 	response := MessageResponse{
 		MessageID: resp.ID,
 		Status:    "sent",
-		Timestamp: resp.Timestamp,
+		Timestamp: h.responseTime(r, sessionID, resp.Timestamp),
 		Phone:     req.Phone,
+		Recipient: recipient.String(),
 		SessionID: sessionIDStr,
 	}
 
@@ -393,15 +2135,23 @@ func (h *MessageHandler) SendAudioMessage(w http.ResponseWriter, r *http.Request
 		Str("session_id", sessionIDStr).
 		Str("phone", req.Phone).
 		Str("message_id", resp.ID).
-		Msg("Audio message sent successfully")
+		Str("target_message_id", req.MessageID).
+		Bool("removed", req.Reaction == "").
+		Msg("Reaction sent successfully")
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	h.writeSendResponse(w, r, response)
 }
 
-// SendVideoMessage sends a video message
-func (h *MessageHandler) SendVideoMessage(w http.ResponseWriter, r *http.Request) {
+// messageEditWindow is how long after sending WhatsApp accepts an edit
+const messageEditWindow = 15 * time.Minute
+
+// EditMessage replaces the text of a message the session sent
+func (h *MessageHandler) EditMessage(w http.ResponseWriter, r *http.Request) {
+	// Reject new sends while maintenance mode is on
+	if !h.checkMaintenance(w) {
+		return
+	}
+
 	sessionIDStr := chi.URLParam(r, "sessionId")
 
 	sessionID, err := domain.ParseSessionID(sessionIDStr)
@@ -410,7 +2160,7 @@ func (h *MessageHandler) SendVideoMessage(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	var req SendVideoMessageRequest
+	var req EditMessageRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
 		return
@@ -421,85 +2171,75 @@ func (h *MessageHandler) SendVideoMessage(w http.ResponseWriter, r *http.Request
 		http.Error(w, "Phone number is required", http.StatusBadRequest)
 		return
 	}
-	if req.Video == "" {
-		http.Error(w, "Video is required", http.StatusBadRequest)
+	if req.MessageID == "" {
+		http.Error(w, "Message ID is required", http.StatusBadRequest)
+		return
+	}
+	if strings.TrimSpace(req.NewText) == "" {
+		http.Error(w, "New text is required", http.StatusBadRequest)
 		return
 	}
 
 	// Get session client
-	client, err := h.multiSessionManager.GetClient(sessionID)
-	if err != nil {
-		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to get session client")
-		http.Error(w, "Session not found or not connected", http.StatusNotFound)
+	client, ok := h.requireConnectedSession(w, sessionID, false)
+	if !ok {
 		return
 	}
 
 	// Parse recipient JID
-	recipient, err := h.parsePhoneToJID(req.Phone)
+	recipient, err := parsePhoneToJID(req.Phone)
 	if err != nil {
 		log.Error().Err(err).Str("phone", req.Phone).Msg("Failed to parse phone number")
 		http.Error(w, "Invalid phone number format", http.StatusBadRequest)
 		return
 	}
 
-	// Validate video format
-	if err := h.mediaHelper.ValidateVideoFormat(req.Video); err != nil {
-		log.Error().Err(err).Msg("Invalid video format")
-		http.Error(w, "Invalid video format: must be data:video/*", http.StatusBadRequest)
-		return
-	}
+	// Messages to the session's own number go to its self-chat
+	recipient = selfChatJID(client, recipient)
 
-	// Decode data URL
-	videoData, mimeType, err := h.mediaHelper.DecodeDataURL(req.Video)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to decode video data")
-		http.Error(w, "Invalid video data", http.StatusBadRequest)
+	// Enforce the session's recipient allowlist
+	if !h.checkRecipientAllowed(w, sessionID, recipient) {
 		return
 	}
 
-	// Generate message ID if not provided
-	messageID := req.ID
-	if messageID == "" {
-		messageID = client.GenerateMessageID()
+	// Fail fast while WhatsApp is rate limiting this session
+	if !h.checkCooldown(w, sessionID) {
+		return
 	}
 
-	// Upload video to WhatsApp
-	ctx, cancel := context.WithTimeout(context.Background(), 120*time.Second) // Longer timeout for videos
-	defer cancel()
-
-	uploaded, err := client.Upload(ctx, videoData, whatsmeow.MediaVideo)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to upload video")
-		http.Error(w, fmt.Sprintf("Failed to upload video: %v", err), http.StatusInternalServerError)
-		return
+	// Catch edits WhatsApp would drop when the original is known
+	if stored, _, err := h.multiSessionManager.GetMessage(r.Context(), sessionID, req.MessageID); err == nil {
+		if !stored.FromMe {
+			http.Error(w, "Only messages sent by this session can be edited", http.StatusBadRequest)
+			return
+		}
+		if time.Since(stored.Timestamp) > messageEditWindow {
+			http.Error(w, "Messages can only be edited within 15 minutes of sending", http.StatusBadRequest)
+			return
+		}
 	}
 
-	// Generate video thumbnail (placeholder for now)
-	thumbnailData, _ := h.mediaHelper.GenerateVideoThumbnail(videoData)
+	messageID := client.GenerateMessageID()
+	msg := client.BuildEdit(recipient, req.MessageID, &waE2E.Message{
+		Conversation: proto.String(req.NewText),
+	})
 
-	// Create video message
-	msg := &waE2E.Message{
-		VideoMessage: &waE2E.VideoMessage{
-			URL:           proto.String(uploaded.URL),
-			DirectPath:    proto.String(uploaded.DirectPath),
-			MediaKey:      uploaded.MediaKey,
-			Mimetype:      proto.String(mimeType),
-			FileEncSHA256: uploaded.FileEncSHA256,
-			FileSHA256:    uploaded.FileSHA256,
-			FileLength:    proto.Uint64(uint64(len(videoData))),
-			Caption:       proto.String(req.Caption),
-			JPEGThumbnail: thumbnailData,
-		},
-	}
+	logOutgoingMessage(sessionID, recipient, messageID, msg)
 
 	// Send message
-	resp, err := client.SendMessage(ctx, recipient, msg, whatsmeow.SendRequestExtra{ID: messageID})
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	resp, err := h.multiSessionManager.SendMessage(ctx, sessionID, client, recipient, msg, messageID)
 	if err != nil {
 		log.Error().
 			Err(err).
 			Str("session_id", sessionIDStr).
 			Str("phone", req.Phone).
-			Msg("Failed to send video message")
+			Msg("Failed to edit message")
+		if h.writeRateLimited(w, sessionID, err) {
+			return
+		}
 		http.Error(w, fmt.Sprintf("Failed to send message: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -508,8 +2248,9 @@ func (h *MessageHandler) SendVideoMessage(w http.ResponseWriter, r *http.Request
 	response := MessageResponse{
 		MessageID: resp.ID,
 		Status:    "sent",
-		Timestamp: resp.Timestamp,
+		Timestamp: h.responseTime(r, sessionID, resp.Timestamp),
 		Phone:     req.Phone,
+		Recipient: recipient.String(),
 		SessionID: sessionIDStr,
 	}
 
@@ -517,15 +2258,19 @@ func (h *MessageHandler) SendVideoMessage(w http.ResponseWriter, r *http.Request
 		Str("session_id", sessionIDStr).
 		Str("phone", req.Phone).
 		Str("message_id", resp.ID).
-		Msg("Video message sent successfully")
+		Str("edited_message_id", req.MessageID).
+		Msg("Message edited successfully")
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	h.writeSendResponse(w, r, response)
 }
 
-// SendDocumentMessage sends a document message
-func (h *MessageHandler) SendDocumentMessage(w http.ResponseWriter, r *http.Request) {
+// RevokeMessage deletes a message the session sent for everyone in the chat
+func (h *MessageHandler) RevokeMessage(w http.ResponseWriter, r *http.Request) {
+	// Reject new sends while maintenance mode is on
+	if !h.checkMaintenance(w) {
+		return
+	}
+
 	sessionIDStr := chi.URLParam(r, "sessionId")
 
 	sessionID, err := domain.ParseSessionID(sessionIDStr)
@@ -534,7 +2279,7 @@ func (h *MessageHandler) SendDocumentMessage(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	var req SendDocumentMessageRequest
+	var req RevokeMessageRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
 		return
@@ -545,91 +2290,58 @@ func (h *MessageHandler) SendDocumentMessage(w http.ResponseWriter, r *http.Requ
 		http.Error(w, "Phone number is required", http.StatusBadRequest)
 		return
 	}
-	if req.Document == "" {
-		http.Error(w, "Document is required", http.StatusBadRequest)
-		return
-	}
-	if req.Filename == "" {
-		http.Error(w, "Filename is required", http.StatusBadRequest)
+	if req.MessageID == "" {
+		http.Error(w, "Message ID is required", http.StatusBadRequest)
 		return
 	}
 
 	// Get session client
-	client, err := h.multiSessionManager.GetClient(sessionID)
-	if err != nil {
-		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to get session client")
-		http.Error(w, "Session not found or not connected", http.StatusNotFound)
+	client, ok := h.requireConnectedSession(w, sessionID, false)
+	if !ok {
 		return
 	}
 
 	// Parse recipient JID
-	recipient, err := h.parsePhoneToJID(req.Phone)
+	recipient, err := parsePhoneToJID(req.Phone)
 	if err != nil {
 		log.Error().Err(err).Str("phone", req.Phone).Msg("Failed to parse phone number")
 		http.Error(w, "Invalid phone number format", http.StatusBadRequest)
 		return
 	}
 
-	// Validate document format
-	if err := h.mediaHelper.ValidateDocumentFormat(req.Document); err != nil {
-		log.Error().Err(err).Msg("Invalid document format")
-		http.Error(w, "Invalid document format: must be data:application/octet-stream;base64", http.StatusBadRequest)
-		return
-	}
+	// Messages to the session's own number go to its self-chat
+	recipient = selfChatJID(client, recipient)
 
-	// Decode data URL
-	documentData, _, err := h.mediaHelper.DecodeDataURL(req.Document)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to decode document data")
-		http.Error(w, "Invalid document data", http.StatusBadRequest)
+	// Enforce the session's recipient allowlist
+	if !h.checkRecipientAllowed(w, sessionID, recipient) {
 		return
 	}
 
-	// Generate message ID if not provided
-	messageID := req.ID
-	if messageID == "" {
-		messageID = client.GenerateMessageID()
-	}
-
-	// Upload document to WhatsApp
-	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
-	defer cancel()
-
-	uploaded, err := client.Upload(ctx, documentData, whatsmeow.MediaDocument)
-	if err != nil {
-		log.Error().Err(err).Msg("Failed to upload document")
-		http.Error(w, fmt.Sprintf("Failed to upload document: %v", err), http.StatusInternalServerError)
+	// Fail fast while WhatsApp is rate limiting this session
+	if !h.checkCooldown(w, sessionID) {
 		return
 	}
 
-	// Determine MIME type
-	mimeType := req.Mimetype
-	if mimeType == "" {
-		mimeType = h.mediaHelper.DetectMimeType(documentData)
-	}
+	// An empty sender revokes one of the session's own messages
+	messageID := client.GenerateMessageID()
+	msg := client.BuildRevoke(recipient, types.EmptyJID, req.MessageID)
 
-	// Create document message
-	msg := &waE2E.Message{
-		DocumentMessage: &waE2E.DocumentMessage{
-			URL:           proto.String(uploaded.URL),
-			DirectPath:    proto.String(uploaded.DirectPath),
-			MediaKey:      uploaded.MediaKey,
-			Mimetype:      proto.String(mimeType),
-			FileEncSHA256: uploaded.FileEncSHA256,
-			FileSHA256:    uploaded.FileSHA256,
-			FileLength:    proto.Uint64(uint64(len(documentData))),
-			FileName:      proto.String(req.Filename),
-		},
-	}
+	logOutgoingMessage(sessionID, recipient, messageID, msg)
 
 	// Send message
-	resp, err := client.SendMessage(ctx, recipient, msg, whatsmeow.SendRequestExtra{ID: messageID})
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	resp, err := h.multiSessionManager.SendMessage(ctx, sessionID, client, recipient, msg, messageID)
 	if err != nil {
 		log.Error().
 			Err(err).
 			Str("session_id", sessionIDStr).
 			Str("phone", req.Phone).
-			Msg("Failed to send document message")
+			Msg("Failed to revoke message")
+		if h.writeRateLimited(w, sessionID, err) {
+			return
+		}
 		http.Error(w, fmt.Sprintf("Failed to send message: %v", err), http.StatusInternalServerError)
 		return
 	}
@@ -638,8 +2350,9 @@ func (h *MessageHandler) SendDocumentMessage(w http.ResponseWriter, r *http.Requ
 	response := MessageResponse{
 		MessageID: resp.ID,
 		Status:    "sent",
-		Timestamp: resp.Timestamp,
+		Timestamp: h.responseTime(r, sessionID, resp.Timestamp),
 		Phone:     req.Phone,
+		Recipient: recipient.String(),
 		SessionID: sessionIDStr,
 	}
 
@@ -647,16 +2360,48 @@ func (h *MessageHandler) SendDocumentMessage(w http.ResponseWriter, r *http.Requ
 		Str("session_id", sessionIDStr).
 		Str("phone", req.Phone).
 		Str("message_id", resp.ID).
-		Str("filename", req.Filename).
-		Msg("Document message sent successfully")
+		Str("revoked_message_id", req.MessageID).
+		Msg("Message revoked successfully")
 
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	h.writeSendResponse(w, r, response)
 }
 
-// SendLocationMessage sends a location message
-func (h *MessageHandler) SendLocationMessage(w http.ResponseWriter, r *http.Request) {
+// reactionSender returns the sender of the message a reaction targets. The
+// session's own messages need no sender, in a direct chat it is the chat
+// itself, and in a group it is the given participant or, failing that, the
+// sender of the stored message.
+func (h *MessageHandler) reactionSender(ctx context.Context, sessionID domain.SessionID, chat types.JID, req SendReactionMessageRequest) (types.JID, error) {
+	if req.FromMe {
+		return types.EmptyJID, nil
+	}
+	if chat.Server != types.GroupServer {
+		return chat, nil
+	}
+
+	if req.Participant != "" {
+		participant, err := parsePhoneToJID(req.Participant)
+		if err != nil {
+			return types.JID{}, fmt.Errorf("invalid participant: %s", req.Participant)
+		}
+		return participant, nil
+	}
+
+	stored, _, err := h.multiSessionManager.GetMessage(ctx, sessionID, req.MessageID)
+	if err == nil && stored.SenderJID != "" {
+		if sender, err := types.ParseJID(stored.SenderJID); err == nil {
+			return sender, nil
+		}
+	}
+	return types.JID{}, fmt.Errorf("participant is required to react to a group message that is not stored")
+}
+
+// SendChatPresence shows the session typing or recording in a chat
+func (h *MessageHandler) SendChatPresence(w http.ResponseWriter, r *http.Request) {
+	// Reject new sends while maintenance mode is on
+	if !h.checkMaintenance(w) {
+		return
+	}
+
 	sessionIDStr := chi.URLParam(r, "sessionId")
 
 	sessionID, err := domain.ParseSessionID(sessionIDStr)
@@ -665,7 +2410,7 @@ func (h *MessageHandler) SendLocationMessage(w http.ResponseWriter, r *http.Requ
 		return
 	}
 
-	var req SendLocationMessageRequest
+	var req ChatPresenceRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
 		return
@@ -676,85 +2421,64 @@ func (h *MessageHandler) SendLocationMessage(w http.ResponseWriter, r *http.Requ
 		http.Error(w, "Phone number is required", http.StatusBadRequest)
 		return
 	}
-
-	// Validate coordinates
-	if err := h.mediaHelper.IsValidCoordinate(req.Latitude, req.Longitude); err != nil {
-		log.Error().Err(err).Msg("Invalid coordinates")
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if req.State == "" {
+		http.Error(w, "State is required", http.StatusBadRequest)
 		return
 	}
 
 	// Get session client
-	client, err := h.multiSessionManager.GetClient(sessionID)
-	if err != nil {
-		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to get session client")
-		http.Error(w, "Session not found or not connected", http.StatusNotFound)
+	client, ok := h.requireConnectedSession(w, sessionID, false)
+	if !ok {
 		return
 	}
 
 	// Parse recipient JID
-	recipient, err := h.parsePhoneToJID(req.Phone)
+	recipient, err := parsePhoneToJID(req.Phone)
 	if err != nil {
 		log.Error().Err(err).Str("phone", req.Phone).Msg("Failed to parse phone number")
 		http.Error(w, "Invalid phone number format", http.StatusBadRequest)
 		return
 	}
 
-	// Generate message ID if not provided
-	messageID := req.ID
-	if messageID == "" {
-		messageID = client.GenerateMessageID()
-	}
+	// Messages to the session's own number go to its self-chat
+	recipient = selfChatJID(client, recipient)
 
-	// Create location message
-	msg := &waE2E.Message{
-		LocationMessage: &waE2E.LocationMessage{
-			DegreesLatitude:  proto.Float64(req.Latitude),
-			DegreesLongitude: proto.Float64(req.Longitude),
-			Name:             proto.String(req.Name),
-			Address:          proto.String(req.Address),
-		},
+	// Enforce the session's recipient allowlist
+	if !h.checkRecipientAllowed(w, sessionID, recipient) {
+		return
 	}
 
-	// Send message
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	resp, err := client.SendMessage(ctx, recipient, msg, whatsmeow.SendRequestExtra{ID: messageID})
-	if err != nil {
+	if err := h.multiSessionManager.SendChatPresence(sessionID, client, recipient, domain.PresenceType(req.State)); err != nil {
+		if _, ok := err.(*domain.ValidationError); ok {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		log.Error().
 			Err(err).
 			Str("session_id", sessionIDStr).
 			Str("phone", req.Phone).
-			Msg("Failed to send location message")
-		http.Error(w, fmt.Sprintf("Failed to send message: %v", err), http.StatusInternalServerError)
+			Msg("Failed to send chat presence")
+		http.Error(w, fmt.Sprintf("Failed to send chat presence: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	// Create response
-	response := MessageResponse{
-		MessageID: resp.ID,
-		Status:    "sent",
-		Timestamp: resp.Timestamp,
-		Phone:     req.Phone,
-		SessionID: sessionIDStr,
-	}
-
-	log.Info().
-		Str("session_id", sessionIDStr).
-		Str("phone", req.Phone).
-		Str("message_id", resp.ID).
-		Float64("latitude", req.Latitude).
-		Float64("longitude", req.Longitude).
-		Msg("Location message sent successfully")
-
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(map[string]any{
+		"session_id": sessionIDStr,
+		"phone":      req.Phone,
+		"recipient":  recipient.String(),
+		"state":      req.State,
+	})
 }
 
-// SendContactMessage sends a contact message
-func (h *MessageHandler) SendContactMessage(w http.ResponseWriter, r *http.Request) {
+// MarkRead sends read receipts for received messages, showing their sender
+// blue ticks
+func (h *MessageHandler) MarkRead(w http.ResponseWriter, r *http.Request) {
+	// Reject new sends while maintenance mode is on
+	if !h.checkMaintenance(w) {
+		return
+	}
+
 	sessionIDStr := chi.URLParam(r, "sessionId")
 
 	sessionID, err := domain.ParseSessionID(sessionIDStr)
@@ -763,7 +2487,7 @@ func (h *MessageHandler) SendContactMessage(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	var req SendContactMessageRequest
+	var req MarkReadRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
 		http.Error(w, "Invalid JSON payload", http.StatusBadRequest)
 		return
@@ -774,81 +2498,56 @@ func (h *MessageHandler) SendContactMessage(w http.ResponseWriter, r *http.Reque
 		http.Error(w, "Phone number is required", http.StatusBadRequest)
 		return
 	}
-	if req.ContactPhone == "" {
-		http.Error(w, "Contact phone is required", http.StatusBadRequest)
-		return
-	}
-	if req.ContactName == "" {
-		http.Error(w, "Contact name is required", http.StatusBadRequest)
+	if len(req.MessageIDs) == 0 {
+		http.Error(w, "Message IDs are required", http.StatusBadRequest)
 		return
 	}
 
 	// Get session client
-	client, err := h.multiSessionManager.GetClient(sessionID)
-	if err != nil {
-		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to get session client")
-		http.Error(w, "Session not found or not connected", http.StatusNotFound)
+	client, ok := h.requireConnectedSession(w, sessionID, false)
+	if !ok {
 		return
 	}
 
-	// Parse recipient JID
-	recipient, err := h.parsePhoneToJID(req.Phone)
+	chat, err := parsePhoneToJID(req.Phone)
 	if err != nil {
 		log.Error().Err(err).Str("phone", req.Phone).Msg("Failed to parse phone number")
 		http.Error(w, "Invalid phone number format", http.StatusBadRequest)
 		return
 	}
 
-	// Generate message ID if not provided
-	messageID := req.ID
-	if messageID == "" {
-		messageID = client.GenerateMessageID()
+	sender := types.EmptyJID
+	if req.Sender != "" {
+		if sender, err = parsePhoneToJID(req.Sender); err != nil {
+			http.Error(w, "Invalid sender format", http.StatusBadRequest)
+			return
+		}
 	}
 
-	// Create vCard
-	vcard := h.mediaHelper.FormatVCard(req.ContactName, req.ContactPhone)
-
-	// Create contact message
-	msg := &waE2E.Message{
-		ContactMessage: &waE2E.ContactMessage{
-			DisplayName: proto.String(req.ContactName),
-			Vcard:       proto.String(vcard),
-		},
+	messageIDs := make([]types.MessageID, 0, len(req.MessageIDs))
+	for _, id := range req.MessageIDs {
+		if id = strings.TrimSpace(id); id != "" {
+			messageIDs = append(messageIDs, id)
+		}
 	}
 
-	// Send message
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
-	defer cancel()
-
-	resp, err := client.SendMessage(ctx, recipient, msg, whatsmeow.SendRequestExtra{ID: messageID})
-	if err != nil {
+	if err := h.multiSessionManager.MarkMessagesRead(sessionID, client, chat, sender, messageIDs); err != nil {
+		if _, ok := err.(*domain.ValidationError); ok {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		log.Error().
 			Err(err).
 			Str("session_id", sessionIDStr).
 			Str("phone", req.Phone).
-			Msg("Failed to send contact message")
-		http.Error(w, fmt.Sprintf("Failed to send message: %v", err), http.StatusInternalServerError)
+			Msg("Failed to mark messages as read")
+		http.Error(w, fmt.Sprintf("Failed to mark messages as read: %v", err), http.StatusInternalServerError)
 		return
 	}
 
-	// Create response
-	response := MessageResponse{
-		MessageID: resp.ID,
-		Status:    "sent",
-		Timestamp: resp.Timestamp,
-		Phone:     req.Phone,
-		SessionID: sessionIDStr,
-	}
-
-	log.Info().
-		Str("session_id", sessionIDStr).
-		Str("phone", req.Phone).
-		Str("message_id", resp.ID).
-		Str("contact_name", req.ContactName).
-		Str("contact_phone", req.ContactPhone).
-		Msg("Contact message sent successfully")
-
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(map[string]any{
+		"status": "ok",
+		"count":  len(messageIDs),
+	})
 }
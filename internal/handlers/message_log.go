@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"wazmeow/internal/domain"
+	"wazmeow/pkg/logger"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// logOutgoingMessage logs a redacted summary of a message about to be sent.
+// Only sizes and metadata are logged, never the text or media content.
+func logOutgoingMessage(sessionID domain.SessionID, recipient types.JID, messageID string, msg *waE2E.Message) {
+	event := logger.Global().WhatsApp().Debug()
+	if event == nil {
+		return
+	}
+
+	event = event.
+		Str("session_id", sessionID.String()).
+		Str("recipient", recipient.String()).
+		Str("message_id", messageID)
+
+	switch {
+	case msg.GetExtendedTextMessage() != nil:
+		event = event.Str("type", "text").
+			Int("text_length", len(msg.GetExtendedTextMessage().GetText()))
+	case msg.GetConversation() != "":
+		event = event.Str("type", "text").
+			Int("text_length", len(msg.GetConversation()))
+	case msg.GetImageMessage() != nil:
+		m := msg.GetImageMessage()
+		event = event.Str("type", "image").
+			Str("mimetype", m.GetMimetype()).
+			Uint64("media_size", m.GetFileLength()).
+			Int("caption_length", len(m.GetCaption()))
+	case msg.GetAudioMessage() != nil:
+		m := msg.GetAudioMessage()
+		event = event.Str("type", "audio").
+			Str("mimetype", m.GetMimetype()).
+			Uint64("media_size", m.GetFileLength()).
+			Bool("ptt", m.GetPTT())
+	case msg.GetVideoMessage() != nil:
+		m := msg.GetVideoMessage()
+		event = event.Str("type", "video").
+			Str("mimetype", m.GetMimetype()).
+			Uint64("media_size", m.GetFileLength()).
+			Int("caption_length", len(m.GetCaption()))
+	case msg.GetDocumentMessage() != nil:
+		m := msg.GetDocumentMessage()
+		event = event.Str("type", "document").
+			Str("mimetype", m.GetMimetype()).
+			Uint64("media_size", m.GetFileLength()).
+			Int("caption_length", len(m.GetCaption()))
+	case msg.GetLocationMessage() != nil:
+		event = event.Str("type", "location")
+	case msg.GetContactMessage() != nil:
+		event = event.Str("type", "contact")
+	default:
+		event = event.Str("type", "unknown")
+	}
+
+	event.Msg("Sending message")
+}
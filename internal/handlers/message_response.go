@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+
+	"wazmeow/internal/domain"
+	"wazmeow/internal/services"
+
+	"github.com/rs/zerolog/log"
+)
+
+// Send response shapes
+const (
+	sendResponseFull    = "full"
+	sendResponseMinimal = "minimal"
+)
+
+// sendResponseFormat returns the send response shape a request asked for with
+// Accept: application/json; format=minimal|full, or the configured default
+func (h *MessageHandler) sendResponseFormat(r *http.Request) string {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err != nil || (mediaType != "application/json" && mediaType != "*/*") {
+			continue
+		}
+		switch format := strings.ToLower(params["format"]); format {
+		case sendResponseFull, sendResponseMinimal:
+			return format
+		}
+	}
+	return h.sendResponse
+}
+
+// writeSendResponse writes the 200 response of a completed send in the shape
+// the request asked for
+func (h *MessageHandler) writeSendResponse(w http.ResponseWriter, r *http.Request, response MessageResponse) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+
+	if h.sendResponseFormat(r) == sendResponseMinimal {
+		json.NewEncoder(w).Encode(MinimalMessageResponse{
+			ID:            response.MessageID,
+			TrackingToken: response.TrackingToken,
+		})
+		return
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+// DuplicateMessageResponse is the 409 body of a send reusing a message ID
+type DuplicateMessageResponse struct {
+	Error string             `json:"error"`
+	Prior services.PriorSend `json:"prior"`
+}
+
+// checkMessageIDUnused rejects a client-supplied message ID the session
+// already sent with, answering 409 with that earlier send. It returns false
+// when the send must not proceed.
+func (h *MessageHandler) checkMessageIDUnused(w http.ResponseWriter, r *http.Request, sessionID domain.SessionID, messageID string) bool {
+	prior, err := h.multiSessionManager.FindPriorSend(r.Context(), sessionID, messageID)
+	if err != nil {
+		// The store being unavailable must not block sends
+		log.Warn().Err(err).Str("session_id", sessionID.String()).Str("message_id", messageID).Msg("Failed to check message ID reuse")
+		return true
+	}
+	if prior == nil {
+		return true
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusConflict)
+	json.NewEncoder(w).Encode(DuplicateMessageResponse{
+		Error: "message ID already used by an earlier send",
+		Prior: *prior,
+	})
+	return false
+}
@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"testing"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/types"
+)
+
+func TestSelfChatJID(t *testing.T) {
+	ownID := types.NewADJID("5511999990000", 0, 12)
+	ownLID := types.NewJID("123456789012345", types.HiddenUserServer)
+	own := ownID.ToNonAD()
+
+	paired := &whatsmeow.Client{Store: &store.Device{ID: &ownID, LID: ownLID}}
+	unpaired := &whatsmeow.Client{Store: &store.Device{}}
+
+	other := types.NewJID("5511888880000", types.DefaultUserServer)
+	group := types.NewJID("120363000000000000", types.GroupServer)
+
+	tests := []struct {
+		name      string
+		client    *whatsmeow.Client
+		recipient types.JID
+		want      types.JID
+	}{
+		{"own phone number", paired, types.NewJID(ownID.User, types.DefaultUserServer), own},
+		{"own LID", paired, ownLID, own},
+		{"another user", paired, other, other},
+		{"group", paired, group, group},
+		{"no store ID", unpaired, types.NewJID(ownID.User, types.DefaultUserServer), types.NewJID(ownID.User, types.DefaultUserServer)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selfChatJID(tt.client, tt.recipient); got != tt.want {
+				t.Errorf("selfChatJID(%s) = %s, want %s", tt.recipient, got, tt.want)
+			}
+		})
+	}
+}
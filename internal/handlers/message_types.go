@@ -1,69 +1,213 @@
 package handlers
 
-import "time"
+import (
+	"time"
+
+	"wazmeow/internal/domain"
+)
 
 // SendTextMessageRequest represents a text message send request
 type SendTextMessageRequest struct {
-	Phone   string `json:"phone" validate:"required"`
-	Message string `json:"message" validate:"required"`
-	ID      string `json:"id,omitempty"`
+	Phone           string `json:"phone" validate:"required"`
+	Message         string `json:"message" validate:"required"`
+	ID              string `json:"id,omitempty"`
+	QuotedMessageID string `json:"quoted_message_id,omitempty"` // Message this one replies to
+	QuotedPhone     string `json:"quoted_phone,omitempty"`      // Author of the quoted message, looked up when omitted
+	Async           bool   `json:"async,omitempty"`
+	Priority        string `json:"priority,omitempty"`         // Order among queued sends: high, normal (default) or low
+	QueueIfOffline  bool   `json:"queue_if_offline,omitempty"` // Hold the send until a reconnecting session is back online
+	AutoSplit       bool   `json:"auto_split,omitempty"`       // Split bodies over the length limit into several messages, not with async or queue_if_offline
 }
 
 // SendImageMessageRequest represents an image message send request
 type SendImageMessageRequest struct {
-	Phone   string `json:"phone" validate:"required"`
-	Image   string `json:"image" validate:"required"` // Base64 or URL
-	Caption string `json:"caption,omitempty"`
-	ID      string `json:"id,omitempty"`
+	Phone           string `json:"phone" validate:"required"`
+	Image           string `json:"image" validate:"required"` // Base64 or URL
+	Caption         string `json:"caption,omitempty"`
+	ID              string `json:"id,omitempty"`
+	QuotedMessageID string `json:"quoted_message_id,omitempty"` // Message this one replies to
+	QuotedPhone     string `json:"quoted_phone,omitempty"`      // Author of the quoted message, looked up when omitted
+	Async           bool   `json:"async,omitempty"`
+	Priority        string `json:"priority,omitempty"` // Order among queued sends: high, normal (default) or low
+	Optimize        bool   `json:"optimize,omitempty"` // Resize and re-encode as JPEG before upload
+	Quality         int    `json:"quality,omitempty"`  // JPEG quality (1-100) used when optimizing
 }
 
 // SendAudioMessageRequest represents an audio message send request
 type SendAudioMessageRequest struct {
-	Phone string `json:"phone" validate:"required"`
-	Audio string `json:"audio" validate:"required"` // Base64 or URL
-	ID    string `json:"id,omitempty"`
+	Phone           string `json:"phone" validate:"required"`
+	Audio           string `json:"audio" validate:"required"` // Base64 or URL
+	ID              string `json:"id,omitempty"`
+	QuotedMessageID string `json:"quoted_message_id,omitempty"` // Message this one replies to
+	QuotedPhone     string `json:"quoted_phone,omitempty"`      // Author of the quoted message, looked up when omitted
+	Async           bool   `json:"async,omitempty"`
+	Priority        string `json:"priority,omitempty"` // Order among queued sends: high, normal (default) or low
 }
 
 // SendVideoMessageRequest represents a video message send request
 type SendVideoMessageRequest struct {
-	Phone   string `json:"phone" validate:"required"`
-	Video   string `json:"video" validate:"required"` // Base64 or URL
-	Caption string `json:"caption,omitempty"`
-	ID      string `json:"id,omitempty"`
+	Phone           string `json:"phone" validate:"required"`
+	Video           string `json:"video" validate:"required"` // Base64 or URL
+	Caption         string `json:"caption,omitempty"`
+	ID              string `json:"id,omitempty"`
+	QuotedMessageID string `json:"quoted_message_id,omitempty"` // Message this one replies to
+	QuotedPhone     string `json:"quoted_phone,omitempty"`      // Author of the quoted message, looked up when omitted
+	Async           bool   `json:"async,omitempty"`
+	Priority        string `json:"priority,omitempty"` // Order among queued sends: high, normal (default) or low
 }
 
 // SendDocumentMessageRequest represents a document message send request
 type SendDocumentMessageRequest struct {
-	Phone    string `json:"phone" validate:"required"`
-	Document string `json:"document" validate:"required"` // Base64 or URL
-	Filename string `json:"filename,omitempty"`
-	Mimetype string `json:"mimetype,omitempty"`
-	ID       string `json:"id,omitempty"`
+	Phone           string `json:"phone" validate:"required"`
+	Document        string `json:"document" validate:"required"` // Base64 or URL
+	Filename        string `json:"filename,omitempty"`
+	Mimetype        string `json:"mimetype,omitempty"`
+	ID              string `json:"id,omitempty"`
+	QuotedMessageID string `json:"quoted_message_id,omitempty"` // Message this one replies to
+	QuotedPhone     string `json:"quoted_phone,omitempty"`      // Author of the quoted message, looked up when omitted
+	Async           bool   `json:"async,omitempty"`
+	Priority        string `json:"priority,omitempty"` // Order among queued sends: high, normal (default) or low
+}
+
+// SendMediaMessageRequest represents a media send request whose message type
+// is picked from the media's MIME type
+type SendMediaMessageRequest struct {
+	Phone           string `json:"phone" validate:"required"`
+	Media           string `json:"media" validate:"required"` // Base64 data URL or public http(s) URL, audio other than OGG is sent as a document
+	Caption         string `json:"caption,omitempty"`         // Ignored for audio
+	Filename        string `json:"filename,omitempty"`        // Used when sent as a document
+	ID              string `json:"id,omitempty"`
+	QuotedMessageID string `json:"quoted_message_id,omitempty"` // Message this one replies to
+	QuotedPhone     string `json:"quoted_phone,omitempty"`      // Author of the quoted message, looked up when omitted
+	Async           bool   `json:"async,omitempty"`
+	Priority        string `json:"priority,omitempty"` // Order among queued sends: high, normal (default) or low
 }
 
 // SendLocationMessageRequest represents a location message send request
 type SendLocationMessageRequest struct {
-	Phone     string  `json:"phone" validate:"required"`
-	Latitude  float64 `json:"latitude" validate:"required"`
-	Longitude float64 `json:"longitude" validate:"required"`
-	Name      string  `json:"name,omitempty"`
-	Address   string  `json:"address,omitempty"`
-	ID        string  `json:"id,omitempty"`
+	Phone           string  `json:"phone" validate:"required"`
+	Latitude        float64 `json:"latitude" validate:"required"`
+	Longitude       float64 `json:"longitude" validate:"required"`
+	Name            string  `json:"name,omitempty"`
+	Address         string  `json:"address,omitempty"`
+	ID              string  `json:"id,omitempty"`
+	QuotedMessageID string  `json:"quoted_message_id,omitempty"` // Message this one replies to
+	QuotedPhone     string  `json:"quoted_phone,omitempty"`      // Author of the quoted message, looked up when omitted
+	Async           bool    `json:"async,omitempty"`
+	Priority        string  `json:"priority,omitempty"` // Order among queued sends: high, normal (default) or low
+	QueueIfOffline  bool    `json:"queue_if_offline,omitempty"`
+}
+
+// SendProductMessageRequest represents a catalog product message send request
+type SendProductMessageRequest struct {
+	Phone           string  `json:"phone" validate:"required"`
+	ProductID       string  `json:"product_id" validate:"required"`
+	BusinessJID     string  `json:"business_jid,omitempty"` // catalog owner, defaults to the session's own account
+	Title           string  `json:"title,omitempty"`
+	Description     string  `json:"description,omitempty"`
+	CurrencyCode    string  `json:"currency_code,omitempty"` // ISO 4217, required with price
+	Price           float64 `json:"price,omitempty"`
+	RetailerID      string  `json:"retailer_id,omitempty"`
+	URL             string  `json:"url,omitempty"`
+	Image           string  `json:"image,omitempty"` // base64 data URL of the product image
+	Body            string  `json:"body,omitempty"`
+	Footer          string  `json:"footer,omitempty"`
+	ID              string  `json:"id,omitempty"`
+	QuotedMessageID string  `json:"quoted_message_id,omitempty"` // Message this one replies to
+	QuotedPhone     string  `json:"quoted_phone,omitempty"`      // Author of the quoted message, looked up when omitted
+	Async           bool    `json:"async,omitempty"`
+	Priority        string  `json:"priority,omitempty"` // Order among queued sends: high, normal (default) or low
 }
 
 // SendContactMessageRequest represents a contact message send request
 type SendContactMessageRequest struct {
-	Phone        string `json:"phone" validate:"required"`
-	ContactPhone string `json:"contact_phone" validate:"required"`
-	ContactName  string `json:"contact_name" validate:"required"`
-	ID           string `json:"id,omitempty"`
+	Phone           string `json:"phone" validate:"required"`
+	ContactPhone    string `json:"contact_phone" validate:"required"`
+	ContactName     string `json:"contact_name" validate:"required"`
+	ID              string `json:"id,omitempty"`
+	QuotedMessageID string `json:"quoted_message_id,omitempty"` // Message this one replies to
+	QuotedPhone     string `json:"quoted_phone,omitempty"`      // Author of the quoted message, looked up when omitted
+	Async           bool   `json:"async,omitempty"`
+	Priority        string `json:"priority,omitempty"` // Order among queued sends: high, normal (default) or low
+	QueueIfOffline  bool   `json:"queue_if_offline,omitempty"`
+}
+
+// SendReactionMessageRequest represents a reaction send request
+type SendReactionMessageRequest struct {
+	Phone       string `json:"phone" validate:"required"`
+	MessageID   string `json:"message_id" validate:"required"` // Message reacted to
+	Reaction    string `json:"reaction"`                       // Emoji, empty to remove a previous reaction
+	FromMe      bool   `json:"from_me,omitempty"`              // The message reacted to was sent by this session
+	Participant string `json:"participant,omitempty"`          // Sender of a received group message, looked up when omitted
+	Async       bool   `json:"async,omitempty"`
+	Priority    string `json:"priority,omitempty"` // Order among queued sends: high, normal (default) or low
+}
+
+// EditMessageRequest represents a request to edit a sent text message
+type EditMessageRequest struct {
+	Phone     string `json:"phone" validate:"required"`
+	MessageID string `json:"message_id" validate:"required"` // Message being edited
+	NewText   string `json:"new_text" validate:"required"`
+}
+
+// RevokeMessageRequest represents a request to delete a sent message for everyone
+type RevokeMessageRequest struct {
+	Phone     string `json:"phone" validate:"required"`
+	MessageID string `json:"message_id" validate:"required"` // Message being deleted
+}
+
+// ChatPresenceRequest represents a request to show a typing indicator in a chat
+type ChatPresenceRequest struct {
+	Phone string `json:"phone" validate:"required"`
+	State string `json:"state" validate:"required"` // composing, recording or paused
+}
+
+// MarkReadRequest represents a request to send read receipts for received messages
+type MarkReadRequest struct {
+	Phone      string   `json:"phone" validate:"required"`
+	MessageIDs []string `json:"message_ids" validate:"required,min=1"`
+	Sender     string   `json:"sender,omitempty"` // Member who sent the messages, required in group chats
 }
 
 // MessageResponse represents the response after sending a message
 type MessageResponse struct {
-	MessageID string    `json:"message_id"`
-	Status    string    `json:"status"`
-	Timestamp time.Time `json:"timestamp"`
-	Phone     string    `json:"phone"`
-	SessionID string    `json:"session_id"`
+	MessageID     string    `json:"message_id"`
+	Status        string    `json:"status"`
+	Timestamp     time.Time `json:"timestamp"`
+	Phone         string    `json:"phone"`
+	Recipient     string    `json:"recipient"` // JID the message was sent to
+	SessionID     string    `json:"session_id"`
+	TrackingToken string    `json:"tracking_token,omitempty"` // Set when sent with track=true
+}
+
+// MinimalMessageResponse is the send response for clients that only need the message ID
+type MinimalMessageResponse struct {
+	ID            string `json:"id"`
+	TrackingToken string `json:"tracking_token,omitempty"` // kept so tracked sends stay readable
+}
+
+// SplitMessageResponse represents the response after sending a text split into several messages
+type SplitMessageResponse struct {
+	MessageIDs []string  `json:"message_ids"`
+	Status     string    `json:"status"`
+	Timestamp  time.Time `json:"timestamp"`
+	Phone      string    `json:"phone"`
+	SessionID  string    `json:"session_id"`
+}
+
+// AsyncMessageResponse represents the response after queueing an asynchronous send
+type AsyncMessageResponse struct {
+	JobID         string `json:"job_id"`
+	MessageID     string `json:"message_id"`
+	Status        string `json:"status"`
+	Phone         string `json:"phone"`
+	SessionID     string `json:"session_id"`
+	TrackingToken string `json:"tracking_token,omitempty"`
+}
+
+// StoredMessageResponse represents a message retrieved from the message store
+type StoredMessageResponse struct {
+	*domain.Message
+	HasMedia bool                   `json:"has_media"`
+	Quoted   *StoredMessageResponse `json:"quoted,omitempty"`
 }
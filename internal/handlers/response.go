@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// ErrorResponse is the JSON body of an error response
+type ErrorResponse struct {
+	Error  string `json:"error"`
+	Status string `json:"status,omitempty"` // Session status, set when the session is not connected
+	Code   string `json:"code,omitempty"`   // Stable error code, set for failed media uploads
+}
+
+// writeJSONError writes an error as a JSON body with the given status
+func writeJSONError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: message})
+}
+
+// NotFound answers requests that match no route
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	writeJSONError(w, http.StatusNotFound, "route not found")
+}
+
+// MethodNotAllowed answers requests whose route does not support the method
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	writeJSONError(w, http.StatusMethodNotAllowed, "method not allowed")
+}
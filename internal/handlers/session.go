@@ -3,7 +3,11 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
@@ -12,25 +16,33 @@ import (
 
 	"github.com/go-chi/chi/v5"
 	"github.com/rs/zerolog/log"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
 )
 
 // SessionHandler handles HTTP requests for session operations
 type SessionHandler struct {
-	createSessionUC     *services.CreateSessionUseCase
-	multiSessionManager *services.MultiSessionManager
-	sessionRepo         domain.Repository
+	createSessionUC      *services.CreateSessionUseCase
+	updateSessionUC      *services.UpdateSessionUseCase
+	sessionCredentialsUC *services.SessionCredentialsUseCase
+	multiSessionManager  *services.MultiSessionManager
+	sessionRepo          domain.Repository
 }
 
 // NewSessionHandler creates a new session handler
 func NewSessionHandler(
 	createSessionUC *services.CreateSessionUseCase,
+	updateSessionUC *services.UpdateSessionUseCase,
+	sessionCredentialsUC *services.SessionCredentialsUseCase,
 	multiSessionManager *services.MultiSessionManager,
 	sessionRepo domain.Repository,
 ) *SessionHandler {
 	return &SessionHandler{
-		createSessionUC:     createSessionUC,
-		multiSessionManager: multiSessionManager,
-		sessionRepo:         sessionRepo,
+		createSessionUC:      createSessionUC,
+		updateSessionUC:      updateSessionUC,
+		sessionCredentialsUC: sessionCredentialsUC,
+		multiSessionManager:  multiSessionManager,
+		sessionRepo:          sessionRepo,
 	}
 }
 
@@ -80,8 +92,9 @@ func (h *SessionHandler) ListSessions(w http.ResponseWriter, r *http.Request) {
 			"id":         session.ID.String(),
 			"name":       session.Name,
 			"status":     string(session.Status),
-			"created_at": session.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-			"updated_at": session.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			"timezone":   session.Timezone,
+			"created_at": formatTimestamp(r, session.CreatedAt, session.Location()),
+			"updated_at": formatTimestamp(r, session.UpdatedAt, session.Location()),
 		})
 	}
 
@@ -119,14 +132,248 @@ func (h *SessionHandler) GetSessionInfo(w http.ResponseWriter, r *http.Request)
 		"id":         session.ID.String(),
 		"name":       session.Name,
 		"status":     string(session.Status),
-		"created_at": session.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
-		"updated_at": session.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		"timezone":   session.Timezone,
+		"created_at": formatTimestamp(r, session.CreatedAt, session.Location()),
+		"updated_at": formatTimestamp(r, session.UpdatedAt, session.Location()),
+		// Live client state: not_initialized means connect has not been called yet
+		"connection_status": string(h.multiSessionManager.GetSessionStatus(sessionID)),
+		"initialized":       h.multiSessionManager.IsSessionInitialized(sessionID),
+	}
+
+	// Paired but disconnected needs a reconnect, unpaired needs a QR code
+	authenticated, connected := h.multiSessionManager.GetAuthState(sessionID)
+	response["authenticated"] = authenticated
+	response["connected"] = connected
+
+	if lastMessageAt := h.multiSessionManager.GetLastMessageAt(sessionID); !lastMessageAt.IsZero() {
+		response["last_message_at"] = formatTimestamp(r, lastMessageAt, session.Location())
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
+// maxStatusQueryIDs bounds the sessions of a single bulk status query
+const maxStatusQueryIDs = 500
+
+// GetSessionStatuses handles GET /sessions/status?ids=a,b,c
+func (h *SessionHandler) GetSessionStatuses(w http.ResponseWriter, r *http.Request) {
+	var ids []domain.SessionID
+	if value := r.URL.Query().Get("ids"); value != "" {
+		for _, part := range strings.Split(value, ",") {
+			id := domain.SessionID(strings.TrimSpace(part))
+			if id == "" {
+				continue
+			}
+			if !id.IsValid() {
+				http.Error(w, "Invalid session ID: "+id.String(), http.StatusBadRequest)
+				return
+			}
+			ids = append(ids, id)
+		}
+
+		if len(ids) > maxStatusQueryIDs {
+			http.Error(w, fmt.Sprintf("Too many session IDs, at most %d per query", maxStatusQueryIDs), http.StatusBadRequest)
+			return
+		}
+	}
+
+	statuses := h.multiSessionManager.GetSessionStatuses(ids)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"sessions": statuses,
+		"count":    len(statuses),
+	})
+}
+
+// GetMe handles GET /sessions/{sessionID}/me
+func (h *SessionHandler) GetMe(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionID")
+
+	sessionID := domain.SessionID(sessionIDStr)
+	if !sessionID.IsValid() {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	if _, err := h.sessionRepo.GetByID(r.Context(), sessionID); err != nil {
+		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to get session")
+
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, "Session not found", http.StatusNotFound)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	identity, err := h.multiSessionManager.GetIdentity(sessionID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(identity)
+}
+
+// CancelPairing handles POST /sessions/{sessionID}/pair/cancel
+func (h *SessionHandler) CancelPairing(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionID")
+
+	sessionID := domain.SessionID(sessionIDStr)
+	if !sessionID.IsValid() {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.multiSessionManager.CancelPairing(r.Context(), sessionID); err != nil {
+		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to cancel pairing")
+
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, "Session not found", http.StatusNotFound)
+		case *domain.BusinessError:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"session_id": sessionIDStr,
+		"status":     domain.StatusDisconnected,
+		"message":    "Pairing cancelled",
+	})
+}
+
+// GetPairStatus handles GET /sessions/{sessionID}/pair/status
+func (h *SessionHandler) GetPairStatus(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionID")
+
+	sessionID := domain.SessionID(sessionIDStr)
+	if !sessionID.IsValid() {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	status, err := h.multiSessionManager.GetPairStatus(r.Context(), sessionID)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to get pairing status")
+
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, "Session not found", http.StatusNotFound)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
+
+// defaultMetricsWindow is the window covered when no from is given
+const defaultMetricsWindow = 24 * time.Hour
+
+// GetMetrics handles GET /sessions/{sessionID}/metrics?from=&to=
+func (h *SessionHandler) GetMetrics(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionID")
+
+	sessionID := domain.SessionID(sessionIDStr)
+	if !sessionID.IsValid() {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	to := time.Now()
+	if v := r.URL.Query().Get("to"); v != "" {
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			http.Error(w, "Invalid to, expected an RFC 3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		to = parsed
+	}
+
+	from := to.Add(-defaultMetricsWindow)
+	if v := r.URL.Query().Get("from"); v != "" {
+		parsed, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			http.Error(w, "Invalid from, expected an RFC 3339 timestamp", http.StatusBadRequest)
+			return
+		}
+		from = parsed
+	}
+
+	if !from.Before(to) {
+		http.Error(w, "from must be before to", http.StatusBadRequest)
+		return
+	}
+
+	metrics, err := h.multiSessionManager.GetDeliveryMetrics(r.Context(), sessionID, from, to)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to get delivery metrics")
+
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, "Session not found", http.StatusNotFound)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(metrics)
+}
+
+// UpdateSession handles PUT /sessions/{sessionID}
+func (h *SessionHandler) UpdateSession(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionID")
+
+	sessionID := domain.SessionID(sessionIDStr)
+	if !sessionID.IsValid() {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	var req services.UpdateSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Error().Err(err).Msg("Failed to decode update session request")
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	session, err := h.updateSessionUC.Execute(r.Context(), sessionID, req)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to update session")
+
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, "Session not found", http.StatusNotFound)
+		case *domain.ValidationError:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case *domain.AlreadyExistsError:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	// A running session applies the new settings to its next send
+	h.multiSessionManager.RefreshSessionSettings(session)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session.ToMap())
+}
+
 // DeleteSession handles DELETE /sessions/{sessionID}
 func (h *SessionHandler) DeleteSession(w http.ResponseWriter, r *http.Request) {
 	sessionIDStr := chi.URLParam(r, "sessionID")
@@ -137,6 +384,13 @@ func (h *SessionHandler) DeleteSession(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Tear down any live client first so a row deleted out-of-band does not leave it behind
+	if err := h.multiSessionManager.RemoveSession(sessionID); err != nil {
+		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to tear down session")
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
 	err := h.sessionRepo.Delete(r.Context(), sessionID)
 	if err != nil {
 		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to delete session")
@@ -189,6 +443,12 @@ func (h *SessionHandler) ConnectSession(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	// Block until connected when asked to, instead of leaving clients to poll
+	if r.URL.Query().Get("wait") == "true" {
+		h.waitForConnection(w, r, sessionID)
+		return
+	}
+
 	// Get session status
 	status := h.multiSessionManager.GetSessionStatus(sessionID)
 
@@ -207,6 +467,56 @@ func (h *SessionHandler) ConnectSession(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(response)
 }
 
+// waitForConnection waits for a started session to connect and writes the final status,
+// including the current QR code when the session is still waiting to be paired
+func (h *SessionHandler) waitForConnection(w http.ResponseWriter, r *http.Request, sessionID domain.SessionID) {
+	timeout := 30 * time.Second
+	if value := r.URL.Query().Get("timeout"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 || parsed > 2*time.Minute {
+			http.Error(w, "Invalid timeout, expected a duration up to 2m", http.StatusBadRequest)
+			return
+		}
+		timeout = parsed
+	}
+
+	// Let the wait outlive the server write timeout (best effort)
+	_ = http.NewResponseController(w).SetWriteDeadline(time.Now().Add(timeout + 10*time.Second))
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+
+	status, err := h.multiSessionManager.WaitForStatus(ctx, sessionID, services.StatusConnected, services.StatusError)
+	timedOut := err != nil && ctx.Err() != nil
+	if err != nil && !timedOut {
+		log.Error().Err(err).Str("session_id", sessionID.String()).Msg("Failed to wait for session connection")
+		http.Error(w, "Failed to wait for session connection", http.StatusInternalServerError)
+		return
+	}
+
+	response := map[string]any{
+		"session_id": sessionID.String(),
+		"status":     string(status),
+		"timed_out":  timedOut,
+	}
+
+	if status != services.StatusConnected {
+		if qr, err := h.multiSessionManager.PeekQRCode(r.Context(), sessionID); err == nil && qr != nil {
+			response["qr_code"] = qr.Code
+			response["expires_at"] = qr.ExpiresAt.Format(time.RFC3339)
+		}
+	}
+
+	log.Info().
+		Str("session_id", sessionID.String()).
+		Str("status", string(status)).
+		Bool("timed_out", timedOut).
+		Msg("Session connection wait finished")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
 // LogoutSession handles POST /sessions/{sessionID}/logout
 func (h *SessionHandler) LogoutSession(w http.ResponseWriter, r *http.Request) {
 	sessionIDStr := chi.URLParam(r, "sessionID")
@@ -278,6 +588,12 @@ func (h *SessionHandler) GetQRCode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Only return the stored QR code when peeking, never start a connection
+	if r.URL.Query().Get("peek") == "true" {
+		h.peekQRCode(w, r, sessionID)
+		return
+	}
+
 	// Log QR code request
 	log.Info().
 		Str("session_id", sessionIDStr).
@@ -289,7 +605,7 @@ func (h *SessionHandler) GetQRCode(w http.ResponseWriter, r *http.Request) {
 	defer cancel()
 
 	// Generate QR code using MultiSessionManager
-	qrCode, err := h.multiSessionManager.GenerateQRCode(ctx, sessionID)
+	qr, err := h.multiSessionManager.GenerateQRCode(ctx, sessionID)
 	if err != nil {
 		log.Error().
 			Err(err).
@@ -310,24 +626,56 @@ func (h *SessionHandler) GetQRCode(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Calculate expiration time (QR codes typically expire in 20 seconds)
-	expiresAt := time.Now().Add(20 * time.Second)
-
 	response := map[string]any{
-		"session_id": sessionIDStr,
-		"qr_code":    qrCode,
-		"expires_at": expiresAt.Format(time.RFC3339),
+		"session_id":   sessionIDStr,
+		"qr_code":      qr.Code,
+		"generated_at": qr.GeneratedAt.Format(time.RFC3339),
+		"expires_at":   qr.ExpiresAt.Format(time.RFC3339),
 	}
 
 	log.Info().
 		Str("session_id", sessionIDStr).
-		Time("expires_at", expiresAt).
+		Time("expires_at", qr.ExpiresAt).
 		Msg("QR code generated successfully")
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
 }
 
+// peekQRCode writes the currently stored QR code, 204 when there is none or
+// 410 when it expired and was cleared
+func (h *SessionHandler) peekQRCode(w http.ResponseWriter, r *http.Request, sessionID domain.SessionID) {
+	qr, err := h.multiSessionManager.PeekQRCode(r.Context(), sessionID)
+	if errors.Is(err, services.ErrQRCodeExpired) {
+		http.Error(w, "QR code expired, request a new one", http.StatusGone)
+		return
+	}
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionID.String()).Msg("Failed to peek QR code")
+
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, "Session not found", http.StatusNotFound)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if qr == nil {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"session_id":   sessionID.String(),
+		"qr_code":      qr.Code,
+		"generated_at": qr.GeneratedAt.Format(time.RFC3339),
+		"expires_at":   qr.ExpiresAt.Format(time.RFC3339),
+	})
+}
+
 // PairPhone handles POST /sessions/{sessionID}/pairphone
 func (h *SessionHandler) PairPhone(w http.ResponseWriter, r *http.Request) {
 	sessionIDStr := chi.URLParam(r, "sessionID")
@@ -433,15 +781,956 @@ func (h *SessionHandler) SetProxy(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Proxy configuration is not yet implemented
-	// This endpoint is reserved for future proxy functionality
-	response := map[string]any{
-		"session_id": sessionIDStr,
-		"proxy_url":  req.ProxyURL,
-		"status":     "proxy_not_implemented",
-		"message":    "Proxy configuration is not yet implemented",
+	result, err := h.multiSessionManager.SetSessionProxy(r.Context(), sessionID, req.ProxyURL)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to set session proxy")
+
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, "Session not found", http.StatusNotFound)
+		case *domain.ValidationError:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to set session proxy", http.StatusInternalServerError)
+		}
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	json.NewEncoder(w).Encode(result)
+}
+
+// DownloadMediaReference handles POST /sessions/{sessionID}/download. The body
+// is the media reference of a message event, the decrypted bytes are returned.
+func (h *SessionHandler) DownloadMediaReference(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionID")
+
+	sessionID := domain.SessionID(sessionIDStr)
+	if !sessionID.IsValid() {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	var ref domain.MediaReference
+	if err := json.NewDecoder(r.Body).Decode(&ref); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	data, err := h.multiSessionManager.DownloadMediaReference(ctx, sessionID, ref)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to download media")
+
+		switch err.(type) {
+		case *domain.ValidationError:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case *domain.BusinessError:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to download media", http.StatusBadGateway)
+		}
+		return
+	}
+
+	mimeType := ref.MimeType
+	if mimeType == "" {
+		mimeType = "application/octet-stream"
+	}
+
+	w.Header().Set("Content-Type", mimeType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// TestProxy handles POST /sessions/{sessionID}/proxy/test
+func (h *SessionHandler) TestProxy(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionID")
+
+	sessionID := domain.SessionID(sessionIDStr)
+	if !sessionID.IsValid() {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.multiSessionManager.TestProxy(r.Context(), sessionID)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to test proxy")
+
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, "Session not found", http.StatusNotFound)
+		case *domain.ValidationError:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case *domain.BusinessError:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to test proxy", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetAllowlist handles GET /sessions/{sessionID}/allowlist
+func (h *SessionHandler) GetAllowlist(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionID")
+
+	sessionID := domain.SessionID(sessionIDStr)
+	if !sessionID.IsValid() {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	session, err := h.sessionRepo.GetByID(r.Context(), sessionID)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to get session")
+
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, "Session not found", http.StatusNotFound)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	recipients := session.AllowedRecipients()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"session_id": sessionIDStr,
+		"recipients": recipients,
+		"enabled":    len(recipients) > 0,
+	})
+}
+
+// SetAllowlist handles PUT /sessions/{sessionID}/allowlist
+func (h *SessionHandler) SetAllowlist(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionID")
+
+	sessionID := domain.SessionID(sessionIDStr)
+	if !sessionID.IsValid() {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Recipients []string `json:"recipients"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	// Normalize phone numbers and JIDs to the form used by the send path
+	recipients := make([]string, 0, len(req.Recipients))
+	for _, recipient := range req.Recipients {
+		jid, err := parsePhoneToJID(recipient)
+		if err != nil {
+			http.Error(w, "Invalid recipient: "+recipient, http.StatusBadRequest)
+			return
+		}
+		recipients = append(recipients, jid.ToNonAD().String())
+	}
+
+	h.updateAllowlist(w, r, sessionID, recipients)
+}
+
+// ClearAllowlist handles DELETE /sessions/{sessionID}/allowlist
+func (h *SessionHandler) ClearAllowlist(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionID")
+
+	sessionID := domain.SessionID(sessionIDStr)
+	if !sessionID.IsValid() {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	h.updateAllowlist(w, r, sessionID, nil)
+}
+
+// updateAllowlist persists a new recipient allowlist and writes the resulting list
+func (h *SessionHandler) updateAllowlist(w http.ResponseWriter, r *http.Request, sessionID domain.SessionID, recipients []string) {
+	session, err := h.sessionRepo.GetByID(r.Context(), sessionID)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionID.String()).Msg("Failed to get session")
+
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, "Session not found", http.StatusNotFound)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	session.SetAllowedRecipients(recipients)
+	if err := h.sessionRepo.SetAllowlist(r.Context(), sessionID, session.AllowedRecipients()); err != nil {
+		log.Error().Err(err).Str("session_id", sessionID.String()).Msg("Failed to update recipient allowlist")
+		http.Error(w, "Failed to update recipient allowlist", http.StatusInternalServerError)
+		return
+	}
+
+	log.Info().
+		Str("session_id", sessionID.String()).
+		Int("recipients", len(session.AllowedRecipients())).
+		Msg("Recipient allowlist updated")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"session_id": sessionID.String(),
+		"recipients": session.AllowedRecipients(),
+		"enabled":    len(session.AllowedRecipients()) > 0,
+	})
+}
+
+// SetTimezone handles PUT /sessions/{sessionID}/timezone
+func (h *SessionHandler) SetTimezone(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionID")
+
+	sessionID := domain.SessionID(sessionIDStr)
+	if !sessionID.IsValid() {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Timezone string `json:"timezone"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	session, err := h.sessionRepo.GetByID(r.Context(), sessionID)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to get session")
+
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, "Session not found", http.StatusNotFound)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if err := session.SetTimezone(req.Timezone); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := h.sessionRepo.SetTimezone(r.Context(), sessionID, session.Timezone); err != nil {
+		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to update session timezone")
+		http.Error(w, "Failed to update session timezone", http.StatusInternalServerError)
+		return
+	}
+
+	log.Info().
+		Str("session_id", sessionIDStr).
+		Str("timezone", session.Timezone).
+		Msg("Session timezone updated")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"session_id": sessionIDStr,
+		"timezone":   session.Timezone,
+	})
+}
+
+// ExportCredentials handles GET /sessions/{sessionID}/export-credentials
+func (h *SessionHandler) ExportCredentials(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionID")
+
+	sessionID := domain.SessionID(sessionIDStr)
+	if !sessionID.IsValid() {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	blob, err := h.sessionCredentialsUC.Export(r.Context(), sessionID)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to export session credentials")
+
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, "Session not found", http.StatusNotFound)
+		case *domain.BusinessError:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to export session credentials", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"session_id":  sessionIDStr,
+		"credentials": blob,
+	})
+}
+
+// ImportCredentials handles POST /sessions/{sessionID}/import-credentials
+func (h *SessionHandler) ImportCredentials(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionID")
+
+	sessionID := domain.SessionID(sessionIDStr)
+	if !sessionID.IsValid() {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Credentials string `json:"credentials"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Credentials == "" {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	jid, err := h.sessionCredentialsUC.Import(r.Context(), sessionID, req.Credentials)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to import session credentials")
+
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, "Session not found", http.StatusNotFound)
+		case *domain.ValidationError:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case *domain.BusinessError:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to import session credentials", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"session_id": sessionIDStr,
+		"wa_jid":     jid,
+		"message":    "Credentials imported, connect the session to resume it",
+	})
+}
+
+// SetEvents handles PUT /sessions/{sessionID}/events
+func (h *SessionHandler) SetEvents(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionID")
+
+	sessionID := domain.SessionID(sessionIDStr)
+	if !sessionID.IsValid() {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Events []string `json:"events"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	// An empty list subscribes to every event, a missing one is a mistake
+	if req.Events == nil {
+		http.Error(w, "events is required", http.StatusBadRequest)
+		return
+	}
+
+	events, err := h.multiSessionManager.SetSubscribedEvents(r.Context(), sessionID, req.Events)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to update subscribed events")
+
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, "Session not found", http.StatusNotFound)
+		case *domain.ValidationError:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to update subscribed events", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	log.Info().
+		Str("session_id", sessionIDStr).
+		Strs("events", events).
+		Msg("Subscribed events updated")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"session_id": sessionIDStr,
+		"events":     events,
+	})
+}
+
+// SetWebhook handles POST /sessions/{sessionID}/webhook
+func (h *SessionHandler) SetWebhook(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionID")
+
+	sessionID := domain.SessionID(sessionIDStr)
+	if !sessionID.IsValid() {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	// An empty URL falls back to the global webhook, empty events deliver
+	// every event type and an omitted secret keeps the current one
+	var req struct {
+		URL    string   `json:"url"`
+		Events []string `json:"events"`
+		Secret *string  `json:"secret"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.multiSessionManager.SetSessionWebhook(r.Context(), sessionID, req.URL, req.Events, req.Secret)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to update session webhook")
+
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, "Session not found", http.StatusNotFound)
+		case *domain.ValidationError:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to update session webhook", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetConnectionHistory handles GET /sessions/{sessionID}/events/history
+func (h *SessionHandler) GetConnectionHistory(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionID")
+
+	sessionID := domain.SessionID(sessionIDStr)
+	if !sessionID.IsValid() {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	history, err := h.multiSessionManager.GetConnectionHistory(r.Context(), sessionID)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to get connection history")
+
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, "Session not found", http.StatusNotFound)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"session_id": sessionIDStr,
+		"events":     history,
+	})
+}
+
+// GetS3Config handles GET /sessions/{sessionID}/s3
+func (h *SessionHandler) GetS3Config(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionID")
+
+	sessionID := domain.SessionID(sessionIDStr)
+	if !sessionID.IsValid() {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	session, err := h.sessionRepo.GetByID(r.Context(), sessionID)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to get session")
+
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, "Session not found", http.StatusNotFound)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(services.NewS3ConfigView(session))
+}
+
+// SetS3Config handles POST /sessions/{sessionID}/s3/set
+func (h *SessionHandler) SetS3Config(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionID")
+
+	sessionID := domain.SessionID(sessionIDStr)
+	if !sessionID.IsValid() {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	// Omitted keys keep the stored ones, an omitted media_delivery keeps the
+	// current mode
+	var req struct {
+		services.S3Settings
+		MediaDelivery string `json:"media_delivery"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	result, err := h.multiSessionManager.SetSessionS3(r.Context(), sessionID, req.S3Settings, req.MediaDelivery)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to update session S3 configuration")
+
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, "Session not found", http.StatusNotFound)
+		case *domain.ValidationError:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			http.Error(w, "Failed to update session S3 configuration", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// ListIgnoredChats handles GET /sessions/{sessionID}/chats/ignored
+func (h *SessionHandler) ListIgnoredChats(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionID")
+
+	sessionID := domain.SessionID(sessionIDStr)
+	if !sessionID.IsValid() {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	session, err := h.sessionRepo.GetByID(r.Context(), sessionID)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to get session")
+
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, "Session not found", http.StatusNotFound)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"session_id": sessionIDStr,
+		"chats":      session.IgnoredChatList(),
+	})
+}
+
+// IgnoreChat handles POST /sessions/{sessionID}/chats/{jid}/ignore
+func (h *SessionHandler) IgnoreChat(w http.ResponseWriter, r *http.Request) {
+	h.setChatIgnored(w, r, true)
+}
+
+// UnignoreChat handles DELETE /sessions/{sessionID}/chats/{jid}/ignore
+func (h *SessionHandler) UnignoreChat(w http.ResponseWriter, r *http.Request) {
+	h.setChatIgnored(w, r, false)
+}
+
+// setChatIgnored adds or removes the chat in the URL from the session's ignore list
+func (h *SessionHandler) setChatIgnored(w http.ResponseWriter, r *http.Request, ignored bool) {
+	sessionIDStr := chi.URLParam(r, "sessionID")
+
+	sessionID := domain.SessionID(sessionIDStr)
+	if !sessionID.IsValid() {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	chat, err := parsePhoneToJID(chi.URLParam(r, "jid"))
+	if err != nil {
+		http.Error(w, "Invalid chat JID", http.StatusBadRequest)
+		return
+	}
+
+	chats, err := h.multiSessionManager.SetChatIgnored(r.Context(), sessionID, chat.ToNonAD().String(), ignored)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to update ignored chats")
+
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, "Session not found", http.StatusNotFound)
+		default:
+			http.Error(w, "Failed to update ignored chats", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	log.Info().
+		Str("session_id", sessionIDStr).
+		Str("chat", chat.String()).
+		Bool("ignored", ignored).
+		Msg("Chat ignore setting updated")
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"session_id": sessionIDStr,
+		"chat":       chat.ToNonAD().String(),
+		"ignored":    ignored,
+		"chats":      chats,
+	})
+}
+
+// SendPresence handles POST /sessions/{sessionID}/presence
+func (h *SessionHandler) SendPresence(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionID")
+
+	sessionID := domain.SessionID(sessionIDStr)
+	if !sessionID.IsValid() {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		State string `json:"state"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.State == "" {
+		http.Error(w, "state is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.multiSessionManager.SendPresence(r.Context(), sessionID, domain.PresenceType(req.State)); err != nil {
+		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to send presence")
+
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, "Session not found", http.StatusNotFound)
+		case *domain.ValidationError:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case *domain.BusinessError:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to send presence", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"session_id": sessionIDStr,
+		"state":      req.State,
+	})
+}
+
+// RefreshPresence handles POST /sessions/{sessionID}/presence/refresh
+func (h *SessionHandler) RefreshPresence(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionID")
+
+	sessionID := domain.SessionID(sessionIDStr)
+	if !sessionID.IsValid() {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.multiSessionManager.RefreshPresence(r.Context(), sessionID); err != nil {
+		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to refresh presence")
+
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, "Session not found", http.StatusNotFound)
+		case *domain.BusinessError:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to refresh presence", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"session_id": sessionIDStr,
+		"state":      domain.PresenceTypeAvailable,
+	})
+}
+
+// MarkChatRead handles POST /sessions/{sessionID}/chats/{jid}/read
+func (h *SessionHandler) MarkChatRead(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionID")
+
+	sessionID := domain.SessionID(sessionIDStr)
+	if !sessionID.IsValid() {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	chat, err := parsePhoneToJID(chi.URLParam(r, "jid"))
+	if err != nil {
+		http.Error(w, "Invalid chat JID", http.StatusBadRequest)
+		return
+	}
+
+	var req struct {
+		Read *bool `json:"read"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+	if req.Read == nil {
+		http.Error(w, "read is required", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.multiSessionManager.MarkChatRead(r.Context(), sessionID, chat.ToNonAD(), *req.Read); err != nil {
+		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to mark chat read state")
+
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, "Session not found", http.StatusNotFound)
+		case *domain.BusinessError:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to mark chat read state", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"session_id": sessionIDStr,
+		"chat":       chat.ToNonAD().String(),
+		"read":       *req.Read,
+	})
+}
+
+// maxGroupParticipants bounds one membership change, the size of the largest groups
+const maxGroupParticipants = 1024
+
+// UpdateGroupParticipantsRequest represents a group membership change
+type UpdateGroupParticipantsRequest struct {
+	Action       string   `json:"action"`       // add, remove, promote or demote
+	Participants []string `json:"participants"` // phone numbers or JIDs
+}
+
+// UpdateGroupParticipants handles POST /sessions/{sessionID}/groups/{jid}/participants
+func (h *SessionHandler) UpdateGroupParticipants(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionID")
+
+	sessionID := domain.SessionID(sessionIDStr)
+	if !sessionID.IsValid() {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	updateGroupParticipants(w, r, h.multiSessionManager, sessionID, chi.URLParam(r, "jid"))
+}
+
+// updateGroupParticipants applies a membership change to the group named by
+// groupParam, for the session and group routes alike
+func updateGroupParticipants(w http.ResponseWriter, r *http.Request, msm *services.MultiSessionManager, sessionID domain.SessionID, groupParam string) {
+	group, err := types.ParseJID(groupParam)
+	if err != nil || group.Server != types.GroupServer {
+		http.Error(w, "Invalid group JID", http.StatusBadRequest)
+		return
+	}
+
+	var req UpdateGroupParticipantsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	action := whatsmeow.ParticipantChange(req.Action)
+	switch action {
+	case whatsmeow.ParticipantChangeAdd, whatsmeow.ParticipantChangeRemove,
+		whatsmeow.ParticipantChangePromote, whatsmeow.ParticipantChangeDemote:
+	default:
+		http.Error(w, "action must be one of add, remove, promote, demote", http.StatusBadRequest)
+		return
+	}
+
+	if len(req.Participants) == 0 {
+		http.Error(w, "participants is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.Participants) > maxGroupParticipants {
+		http.Error(w, fmt.Sprintf("At most %d participants per request", maxGroupParticipants), http.StatusBadRequest)
+		return
+	}
+
+	participants, ok := parseGroupParticipants(w, req.Participants)
+	if !ok {
+		return
+	}
+
+	result, err := msm.UpdateGroupParticipants(r.Context(), sessionID, group, participants, action)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionID.String()).Msg("Failed to update group participants")
+
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, "Session not found", http.StatusNotFound)
+		case *domain.BusinessError:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to update group participants", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// GetGroupSummary handles GET /sessions/{sessionID}/groups/{jid}/summary
+func (h *SessionHandler) GetGroupSummary(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionID")
+
+	sessionID := domain.SessionID(sessionIDStr)
+	if !sessionID.IsValid() {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	group, err := types.ParseJID(chi.URLParam(r, "jid"))
+	if err != nil || group.Server != types.GroupServer {
+		http.Error(w, "Invalid group JID", http.StatusBadRequest)
+		return
+	}
+
+	summary, err := h.multiSessionManager.GetGroupSummary(r.Context(), sessionID, group)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to get group summary")
+
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, err.Error(), http.StatusNotFound)
+		case *domain.BusinessError:
+			http.Error(w, err.Error(), http.StatusConflict)
+		default:
+			http.Error(w, "Failed to get group summary", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+}
+
+// ResyncRequest represents an on-demand resync request
+type ResyncRequest struct {
+	FullSync bool `json:"full_sync"`
+}
+
+// Resync handles POST /sessions/{sessionID}/resync
+func (h *SessionHandler) Resync(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionID")
+
+	sessionID := domain.SessionID(sessionIDStr)
+	if !sessionID.IsValid() {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	// The body is optional, an empty one means an incremental sync
+	var req ResyncRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 60*time.Second)
+	defer cancel()
+
+	result, err := h.multiSessionManager.Resync(ctx, sessionID, req.FullSync)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionIDStr).Msg("Failed to resync session")
+
+		switch {
+		case ctx.Err() == context.DeadlineExceeded:
+			http.Error(w, "Resync timeout", http.StatusGatewayTimeout)
+		default:
+			switch err.(type) {
+			case *domain.NotFoundError:
+				http.Error(w, "Session not found", http.StatusNotFound)
+			case *domain.BusinessError:
+				http.Error(w, err.Error(), http.StatusConflict)
+			default:
+				http.Error(w, "Failed to resync session", http.StatusBadGateway)
+			}
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"session_id": sessionIDStr,
+		"full_sync":  req.FullSync,
+		"patches":    result.Patches,
+		"groups":     result.Groups,
+	})
+}
+
+// ListJobs handles GET /sessions/{sessionID}/jobs
+func (h *SessionHandler) ListJobs(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionID")
+
+	sessionID := domain.SessionID(sessionIDStr)
+	if !sessionID.IsValid() {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	jobs := h.multiSessionManager.ListJobs(sessionID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"session_id": sessionIDStr,
+		"jobs":       jobs,
+		"count":      len(jobs),
+	})
+}
+
+// CancelJob handles DELETE /sessions/{sessionID}/jobs/{jobID}
+func (h *SessionHandler) CancelJob(w http.ResponseWriter, r *http.Request) {
+	sessionIDStr := chi.URLParam(r, "sessionID")
+	jobID := chi.URLParam(r, "jobID")
+
+	sessionID := domain.SessionID(sessionIDStr)
+	if !sessionID.IsValid() {
+		http.Error(w, "Invalid session ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.multiSessionManager.CancelJob(sessionID, jobID); err != nil {
+		switch err.(type) {
+		case *domain.NotFoundError:
+			http.Error(w, "Job not found", http.StatusNotFound)
+		default:
+			log.Error().Err(err).Str("session_id", sessionIDStr).Str("job_id", jobID).Msg("Failed to cancel job")
+			http.Error(w, "Failed to cancel job", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]any{
+		"session_id": sessionIDStr,
+		"job_id":     jobID,
+		"status":     "cancelled",
+	})
 }
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// splitBoundaries are tried in order when looking for a place to split a long text
+var splitBoundaries = []string{"\n\n", "\n", ". ", " "}
+
+// splitText splits text into chunks of at most limit characters, preferring
+// paragraph, line, sentence and word boundaries before cutting mid-word
+func splitText(text string, limit int) []string {
+	var chunks []string
+
+	for utf8.RuneCountInString(text) > limit {
+		// Byte offset of the first rune past the limit
+		cut := len(text)
+		count := 0
+		for i := range text {
+			if count == limit {
+				cut = i
+				break
+			}
+			count++
+		}
+
+		window := text[:cut]
+		split := cut
+		for _, boundary := range splitBoundaries {
+			if idx := strings.LastIndex(window, boundary); idx > 0 {
+				split = idx + len(boundary)
+				break
+			}
+		}
+
+		if chunk := strings.TrimSpace(text[:split]); chunk != "" {
+			chunks = append(chunks, chunk)
+		}
+		text = text[split:]
+	}
+
+	if chunk := strings.TrimSpace(text); chunk != "" {
+		chunks = append(chunks, chunk)
+	}
+
+	return chunks
+}
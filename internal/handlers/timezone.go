@@ -0,0 +1,29 @@
+package handlers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// TimezoneHeader lets clients request session-local timestamps without a query parameter
+const TimezoneHeader = "X-Timezone"
+
+// timestampLayout is the layout used for timestamps in responses
+const timestampLayout = "2006-01-02T15:04:05Z07:00"
+
+// wantsSessionTimezone reports whether the request asked for timestamps in the
+// session timezone, via ?tz=session or the X-Timezone: session header
+func wantsSessionTimezone(r *http.Request) bool {
+	return strings.EqualFold(r.URL.Query().Get("tz"), "session") ||
+		strings.EqualFold(r.Header.Get(TimezoneHeader), "session")
+}
+
+// formatTimestamp formats t for a response, converting it to loc when the
+// request asked for session-local timestamps
+func formatTimestamp(r *http.Request, t time.Time, loc *time.Location) string {
+	if wantsSessionTimezone(r) && loc != nil {
+		t = t.In(loc)
+	}
+	return t.Format(timestampLayout)
+}
@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"wazmeow/internal/domain"
+
+	"go.mau.fi/whatsmeow"
+)
+
+// Stable codes of failed media uploads. Client errors (4xx) mean the media
+// must change before a retry, server errors (5xx) that a later retry may work.
+const (
+	uploadErrTooLarge    = "media_too_large"
+	uploadErrRejected    = "media_rejected"
+	uploadErrAuth        = "media_auth_failed"
+	uploadErrServer      = "media_server_error"
+	uploadErrUnavailable = "media_unavailable"
+	uploadErrFailed      = "media_upload_failed"
+)
+
+// uploadStatusPattern extracts the media server's HTTP status, which
+// whatsmeow only reports inside the error text
+var uploadStatusPattern = regexp.MustCompile(`upload failed with status code (\d{3})`)
+
+// classifyUploadError maps a client.Upload error to the HTTP status and code reported to the caller
+func classifyUploadError(err error) (int, string) {
+	if match := uploadStatusPattern.FindStringSubmatch(err.Error()); match != nil {
+		status, _ := strconv.Atoi(match[1])
+		switch {
+		case status == http.StatusRequestEntityTooLarge:
+			return http.StatusRequestEntityTooLarge, uploadErrTooLarge
+		case status == http.StatusUnauthorized || status == http.StatusForbidden:
+			return http.StatusBadGateway, uploadErrAuth
+		case status >= 400 && status < 500:
+			return http.StatusBadRequest, uploadErrRejected
+		default:
+			return http.StatusBadGateway, uploadErrServer
+		}
+	}
+
+	switch {
+	case errors.Is(err, context.DeadlineExceeded),
+		errors.Is(err, whatsmeow.ErrNotConnected),
+		errors.Is(err, whatsmeow.ErrNotLoggedIn),
+		strings.Contains(err.Error(), "failed to refresh media connections"),
+		strings.Contains(err.Error(), "failed to execute request"):
+		return http.StatusServiceUnavailable, uploadErrUnavailable
+	default:
+		return http.StatusInternalServerError, uploadErrFailed
+	}
+}
+
+// writeUploadError reports a failed media upload with a status and code that
+// tell the caller whether to change the media or retry later
+func (h *MessageHandler) writeUploadError(w http.ResponseWriter, sessionID domain.SessionID, err error, media string) {
+	if h.writeRateLimited(w, sessionID, err) {
+		return
+	}
+
+	status, code := classifyUploadError(err)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{
+		Error: fmt.Sprintf("Failed to upload %s: %v", media, err),
+		Code:  code,
+	})
+}
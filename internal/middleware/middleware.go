@@ -1,8 +1,10 @@
 package middleware
 
 import (
+	"crypto/subtle"
 	"net/http"
 	"runtime/debug"
+	"strings"
 	"time"
 
 	"github.com/rs/zerolog/log"
@@ -78,3 +80,38 @@ func (rw *responseWriter) WriteHeader(code int) {
 	rw.statusCode = code
 	rw.ResponseWriter.WriteHeader(code)
 }
+
+// Unwrap exposes the underlying writer to http.ResponseController
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// APIKeyMiddleware restricts access to requests carrying the configured API key,
+// either as "Authorization: Bearer <key>" or in the X-API-Key header.
+// When no key is configured every request is rejected.
+func APIKeyMiddleware(apiKey string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if apiKey == "" {
+				http.Error(w, "Admin API key is not configured", http.StatusForbidden)
+				return
+			}
+
+			provided := r.Header.Get("X-API-Key")
+			if provided == "" {
+				provided = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+			}
+
+			if subtle.ConstantTimeCompare([]byte(provided), []byte(apiKey)) != 1 {
+				log.Warn().
+					Str("path", r.URL.Path).
+					Str("remote_addr", r.RemoteAddr).
+					Msg("Rejected request with invalid API key")
+				http.Error(w, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -15,13 +15,31 @@ import (
 type Router struct {
 	sessionHandler *handlers.SessionHandler
 	messageHandler *handlers.MessageHandler
+	contactHandler *handlers.ContactHandler
+	groupHandler   *handlers.GroupHandler
+	adminHandler   *handlers.AdminHandler
+	mediaFiles     *handlers.MediaFileHandler // nil when inbound media is not saved
+	adminAPIKey    string
 }
 
 // NewRouter creates a new router instance
-func NewRouter(sessionHandler *handlers.SessionHandler, messageHandler *handlers.MessageHandler) *Router {
+func NewRouter(
+	sessionHandler *handlers.SessionHandler,
+	messageHandler *handlers.MessageHandler,
+	contactHandler *handlers.ContactHandler,
+	groupHandler *handlers.GroupHandler,
+	adminHandler *handlers.AdminHandler,
+	mediaFiles *handlers.MediaFileHandler,
+	adminAPIKey string,
+) *Router {
 	return &Router{
 		sessionHandler: sessionHandler,
 		messageHandler: messageHandler,
+		contactHandler: contactHandler,
+		groupHandler:   groupHandler,
+		adminHandler:   adminHandler,
+		mediaFiles:     mediaFiles,
+		adminAPIKey:    adminAPIKey,
 	}
 }
 
@@ -42,12 +60,16 @@ func (rt *Router) SetupRoutes() http.Handler {
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   []string{"*"}, // Configure this properly for production
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token", "X-API-Key", handlers.TimezoneHeader},
 		ExposedHeaders:   []string{"Link"},
 		AllowCredentials: false,
 		MaxAge:           300,
 	}))
 
+	// JSON errors for unmatched routes and methods
+	r.NotFound(handlers.NotFound)
+	r.MethodNotAllowed(handlers.MethodNotAllowed)
+
 	// Health check
 	r.Get("/health", rt.healthCheck)
 
@@ -55,6 +77,13 @@ func (rt *Router) SetupRoutes() http.Handler {
 	r.Route("/api/v1", func(r chi.Router) {
 		rt.setupSessionRoutes(r)
 		rt.setupMessageRoutes(r)
+		rt.setupGroupRoutes(r)
+		rt.setupAdminRoutes(r)
+
+		// Saved inbound media
+		if rt.mediaFiles != nil {
+			r.Handle("/media/files/*", http.StripPrefix("/api/v1/media/files", rt.mediaFiles))
+		}
 	})
 
 	return r
@@ -66,10 +95,14 @@ func (rt *Router) setupSessionRoutes(r chi.Router) {
 		// Session management
 		r.Post("/add", rt.sessionHandler.CreateSession)
 		r.Get("/list", rt.sessionHandler.ListSessions)
+		r.Get("/status", rt.sessionHandler.GetSessionStatuses)
 
 		// Session-specific routes
 		r.Route("/{sessionID}", func(r chi.Router) {
 			r.Get("/info", rt.sessionHandler.GetSessionInfo)
+			r.Get("/me", rt.sessionHandler.GetMe)
+			r.Get("/metrics", rt.sessionHandler.GetMetrics)
+			r.Put("/", rt.sessionHandler.UpdateSession)
 			r.Delete("/", rt.sessionHandler.DeleteSession)
 
 			// Session operations
@@ -77,11 +110,83 @@ func (rt *Router) setupSessionRoutes(r chi.Router) {
 			r.Post("/logout", rt.sessionHandler.LogoutSession)
 			r.Get("/qr", rt.sessionHandler.GetQRCode)
 			r.Post("/pairphone", rt.sessionHandler.PairPhone)
+			r.Post("/pair/cancel", rt.sessionHandler.CancelPairing)
+			r.Get("/pair/status", rt.sessionHandler.GetPairStatus)
 			r.Post("/proxy/set", rt.sessionHandler.SetProxy)
+			r.Post("/proxy/test", rt.sessionHandler.TestProxy)
+			r.Post("/resync", rt.sessionHandler.Resync)
+			r.Post("/presence", rt.sessionHandler.SendPresence)
+			r.Post("/presence/refresh", rt.sessionHandler.RefreshPresence)
+
+			// Recipient allowlist
+			r.Get("/allowlist", rt.sessionHandler.GetAllowlist)
+			r.Put("/allowlist", rt.sessionHandler.SetAllowlist)
+			r.Delete("/allowlist", rt.sessionHandler.ClearAllowlist)
+
+			// Pending async jobs
+			r.Get("/jobs", rt.sessionHandler.ListJobs)
+			r.Delete("/jobs/{jobID}", rt.sessionHandler.CancelJob)
+
+			// On-demand media download from a message event's reference
+			r.Post("/download", rt.sessionHandler.DownloadMediaReference)
+
+			// Contacts
+			r.Get("/contacts/{jid}/vcard", rt.contactHandler.GetContactVCard)
+
+			// Webhook and the event types delivered
+			r.Post("/webhook", rt.sessionHandler.SetWebhook)
+			r.Put("/events", rt.sessionHandler.SetEvents)
+			r.Get("/events/history", rt.sessionHandler.GetConnectionHistory)
+
+			// Bucket inbound media is uploaded to
+			r.Get("/s3", rt.sessionHandler.GetS3Config)
+			r.Post("/s3/set", rt.sessionHandler.SetS3Config)
+
+			// Chats whose events are not delivered
+			r.Get("/chats/ignored", rt.sessionHandler.ListIgnoredChats)
+			r.Post("/chats/{jid}/ignore", rt.sessionHandler.IgnoreChat)
+			r.Delete("/chats/{jid}/ignore", rt.sessionHandler.UnignoreChat)
+
+			// Chat read state
+			r.Post("/chats/{jid}/read", rt.sessionHandler.MarkChatRead)
+
+			// Group membership
+			r.Post("/groups/{jid}/participants", rt.sessionHandler.UpdateGroupParticipants)
+			r.Get("/groups/{jid}/summary", rt.sessionHandler.GetGroupSummary)
+
+			// Response timezone
+			r.Put("/timezone", rt.sessionHandler.SetTimezone)
+
+			// Admin-only credential backup and restore
+			r.Group(func(r chi.Router) {
+				r.Use(middleware.APIKeyMiddleware(rt.adminAPIKey))
+				r.Get("/export-credentials", rt.sessionHandler.ExportCredentials)
+				r.Post("/import-credentials", rt.sessionHandler.ImportCredentials)
+			})
 		})
 	})
 }
 
+// setupAdminRoutes configures instance-wide admin routes
+func (rt *Router) setupAdminRoutes(r chi.Router) {
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(middleware.APIKeyMiddleware(rt.adminAPIKey))
+
+		// Effective configuration, secrets redacted
+		r.Get("/config", rt.adminHandler.GetConfig)
+
+		// Maintenance mode
+		r.Get("/maintenance", rt.adminHandler.GetMaintenance)
+		r.Put("/maintenance", rt.adminHandler.SetMaintenance)
+
+		// Emergency shutdown of every live session, pairing is kept
+		r.Post("/disconnect-all", rt.adminHandler.DisconnectAll)
+
+		// Failed webhook deliveries
+		r.Get("/webhooks/dead-letters", rt.adminHandler.ListDeadLetters)
+	})
+}
+
 // setupMessageRoutes configures message-related routes
 func (rt *Router) setupMessageRoutes(r chi.Router) {
 	r.Route("/message/{sessionId}", func(r chi.Router) {
@@ -93,10 +198,41 @@ func (rt *Router) setupMessageRoutes(r chi.Router) {
 		r.Post("/send/audio", rt.messageHandler.SendAudioMessage)
 		r.Post("/send/video", rt.messageHandler.SendVideoMessage)
 		r.Post("/send/document", rt.messageHandler.SendDocumentMessage)
+		r.Post("/send/media", rt.messageHandler.SendMediaMessage)
 
 		// Special messages (not implemented yet)
 		r.Post("/send/location", rt.messageHandler.SendLocationMessage)
 		r.Post("/send/contact", rt.messageHandler.SendContactMessage)
+		r.Post("/send/product", rt.messageHandler.SendProductMessage)
+		r.Post("/send/reaction", rt.messageHandler.SendReactionMessage)
+
+		// Changes to sent messages
+		r.Post("/edit", rt.messageHandler.EditMessage)
+		r.Post("/delete", rt.messageHandler.RevokeMessage)
+
+		// Typing indicators
+		r.Post("/presence", rt.messageHandler.SendChatPresence)
+
+		// Read receipts
+		r.Post("/markread", rt.messageHandler.MarkRead)
+
+		// Inbound media
+		r.Get("/media/{messageId}", rt.messageHandler.DownloadMedia)
+		r.Get("/download/{messageId}", rt.messageHandler.DownloadMedia)
+
+		// Stored message lookup
+		r.Get("/history", rt.messageHandler.GetChatHistory)
+		r.Get("/{messageId}", rt.messageHandler.GetMessage)
+		r.Get("/{messageId}/status", rt.messageHandler.GetMessageStatus)
+	})
+}
+
+// setupGroupRoutes configures group-related routes
+func (rt *Router) setupGroupRoutes(r chi.Router) {
+	r.Route("/group/{sessionId}", func(r chi.Router) {
+		r.Post("/create", rt.groupHandler.CreateGroup)
+		r.Get("/list", rt.groupHandler.ListGroups)
+		r.Post("/{groupJid}/participants", rt.groupHandler.UpdateParticipants)
 	})
 }
 
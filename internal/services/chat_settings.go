@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"wazmeow/internal/domain"
+
+	"github.com/rs/zerolog/log"
+	"go.mau.fi/whatsmeow/appstate"
+	"go.mau.fi/whatsmeow/proto/waSyncAction"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// MarkChatRead marks a whole chat as read or unread through an app-state
+// patch, so the change syncs to the user's other devices
+func (msm *MultiSessionManager) MarkChatRead(ctx context.Context, sessionID domain.SessionID, chat types.JID, read bool) error {
+	if _, err := msm.sessionRepo.GetByID(ctx, sessionID); err != nil {
+		return err
+	}
+
+	client, err := msm.GetClient(sessionID)
+	if err != nil || !client.IsConnected() || !client.IsLoggedIn() {
+		return domain.NewBusinessError("session is not connected")
+	}
+
+	patch := appstate.PatchInfo{
+		Type: appstate.WAPatchRegularLow,
+		Mutations: []appstate.MutationInfo{{
+			Index:   []string{appstate.IndexMarkChatAsRead, chat.String()},
+			Version: 3,
+			Value: &waSyncAction.SyncActionValue{
+				MarkChatAsReadAction: &waSyncAction.MarkChatAsReadAction{
+					Read: proto.Bool(read),
+					MessageRange: &waSyncAction.SyncActionMessageRange{
+						LastMessageTimestamp: proto.Int64(time.Now().Unix()),
+					},
+				},
+			},
+		}},
+	}
+
+	if err := client.SendAppState(ctx, patch); err != nil {
+		return fmt.Errorf("failed to send app state patch: %w", err)
+	}
+
+	log.Info().
+		Str("session_id", sessionID.String()).
+		Str("chat", chat.String()).
+		Bool("read", read).
+		Msg("Chat read state updated")
+
+	return nil
+}
+
+// ResyncResult summarizes an on-demand app-state and group resync
+type ResyncResult struct {
+	Patches []string `json:"patches"`
+	Groups  int      `json:"groups"`
+}
+
+// Resync fetches every app-state patch (contacts, chat settings, push name)
+// and the joined groups again, so later contact and group queries are fresh.
+// A full sync discards the local app-state versions and downloads everything.
+func (msm *MultiSessionManager) Resync(ctx context.Context, sessionID domain.SessionID, fullSync bool) (*ResyncResult, error) {
+	if _, err := msm.sessionRepo.GetByID(ctx, sessionID); err != nil {
+		return nil, err
+	}
+
+	client, err := msm.GetClient(sessionID)
+	if err != nil || !client.IsConnected() || !client.IsLoggedIn() {
+		return nil, domain.NewBusinessError("session is not connected")
+	}
+
+	result := &ResyncResult{Patches: make([]string, 0, len(appstate.AllPatchNames))}
+	for _, name := range appstate.AllPatchNames {
+		if err := client.FetchAppState(ctx, name, fullSync, false); err != nil {
+			return nil, fmt.Errorf("failed to fetch app state %s: %w", name, err)
+		}
+		result.Patches = append(result.Patches, string(name))
+	}
+
+	groups, err := client.GetJoinedGroups()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch joined groups: %w", err)
+	}
+	result.Groups = len(groups)
+
+	log.Info().
+		Str("session_id", sessionID.String()).
+		Bool("full_sync", fullSync).
+		Int("groups", result.Groups).
+		Msg("Session resynced")
+
+	return result, nil
+}
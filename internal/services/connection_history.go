@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"wazmeow/internal/domain"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// connectionHistorySize bounds the connection events kept per session
+const connectionHistorySize = 50
+
+// ConnectionEvent is a change of a session's connection, as kept in its history
+type ConnectionEvent struct {
+	Status    string    `json:"status"`
+	Reason    string    `json:"reason,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// connectionHistory keeps the recent connection events of every session in
+// a ring buffer per session. It outlives the session's client, so the
+// history spans reconnects.
+type connectionHistory struct {
+	rings map[domain.SessionID]*connectionRing
+	mutex sync.Mutex
+}
+
+// connectionRing holds the last connectionHistorySize events of a session
+type connectionRing struct {
+	events []ConnectionEvent
+	next   int // slot the next event is written to once the ring is full
+}
+
+func newConnectionHistory() *connectionHistory {
+	return &connectionHistory{
+		rings: make(map[domain.SessionID]*connectionRing),
+	}
+}
+
+func (h *connectionHistory) record(sessionID domain.SessionID, status, reason string) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	ring, exists := h.rings[sessionID]
+	if !exists {
+		ring = &connectionRing{events: make([]ConnectionEvent, 0, connectionHistorySize)}
+		h.rings[sessionID] = ring
+	}
+
+	event := ConnectionEvent{Status: status, Reason: reason, Timestamp: time.Now()}
+	if len(ring.events) < connectionHistorySize {
+		ring.events = append(ring.events, event)
+		return
+	}
+	ring.events[ring.next] = event
+	ring.next = (ring.next + 1) % connectionHistorySize
+}
+
+// list returns the events of a session, oldest first
+func (h *connectionHistory) list(sessionID domain.SessionID) []ConnectionEvent {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	ring, exists := h.rings[sessionID]
+	if !exists {
+		return []ConnectionEvent{}
+	}
+
+	events := make([]ConnectionEvent, 0, len(ring.events))
+	events = append(events, ring.events[ring.next:]...)
+	return append(events, ring.events[:ring.next]...)
+}
+
+func (h *connectionHistory) forget(sessionID domain.SessionID) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	delete(h.rings, sessionID)
+}
+
+// recordConnectionEvent adds a whatsmeow connection event to the session's
+// history, ignoring events that are not about the connection
+func (msm *MultiSessionManager) recordConnectionEvent(sessionID domain.SessionID, evt any) {
+	var status, reason string
+	switch v := evt.(type) {
+	case *events.Connected:
+		status = "connected"
+	case *events.Disconnected:
+		status, reason = "disconnected", "websocket closed"
+	case *events.StreamReplaced:
+		status, reason = "disconnected", "replaced by another connection of the same device"
+	case *events.LoggedOut:
+		status = "logged_out"
+		if v.OnConnect {
+			reason = v.Reason.String()
+		}
+	case *events.ConnectFailure:
+		status, reason = "connect_failure", v.Reason.String()
+		if v.Message != "" {
+			reason += ": " + v.Message
+		}
+	case *events.ClientOutdated:
+		status, reason = "connect_failure", "client outdated"
+	case *events.TemporaryBan:
+		status, reason = "temporary_ban", v.String()
+	case *events.StreamError:
+		status, reason = "stream_error", v.Code
+	case *events.KeepAliveTimeout:
+		status, reason = "keepalive_timeout", fmt.Sprintf("%d keepalive pings failed", v.ErrorCount)
+	case *events.KeepAliveRestored:
+		status = "keepalive_restored"
+	default:
+		return
+	}
+
+	msm.connHistory.record(sessionID, status, reason)
+}
+
+// GetConnectionHistory returns the recent connection events of a session,
+// oldest first
+func (msm *MultiSessionManager) GetConnectionHistory(ctx context.Context, sessionID domain.SessionID) ([]ConnectionEvent, error) {
+	if _, err := msm.sessionRepo.GetByID(ctx, sessionID); err != nil {
+		return nil, err
+	}
+
+	return msm.connHistory.list(sessionID), nil
+}
@@ -0,0 +1,153 @@
+package services
+
+import (
+	"sync"
+
+	"wazmeow/internal/domain"
+
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+// chatTimerStore remembers the disappearing timers chats announced in inbound
+// messages, so a session default never overrides a chat's own setting. The
+// timers of a session are kept while it runs.
+type chatTimerStore struct {
+	timers map[domain.SessionID]map[types.JID]uint32
+	mutex  sync.RWMutex
+}
+
+func newChatTimerStore() *chatTimerStore {
+	return &chatTimerStore{
+		timers: make(map[domain.SessionID]map[types.JID]uint32),
+	}
+}
+
+// get returns the timer a chat announced and whether it announced one. A
+// known timer of 0 means disappearing messages were turned off in the chat.
+func (s *chatTimerStore) get(sessionID domain.SessionID, chat types.JID) (uint32, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	timer, known := s.timers[sessionID][chat.ToNonAD()]
+	return timer, known
+}
+
+func (s *chatTimerStore) set(sessionID domain.SessionID, chat types.JID, timer uint32) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	chats, exists := s.timers[sessionID]
+	if !exists {
+		chats = make(map[types.JID]uint32)
+		s.timers[sessionID] = chats
+	}
+	chats[chat.ToNonAD()] = timer
+}
+
+// forget drops the timers of a session that stopped
+func (s *chatTimerStore) forget(sessionID domain.SessionID) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	delete(s.timers, sessionID)
+}
+
+// recordChatTimer learns a chat's disappearing timer from an inbound message
+func (msm *MultiSessionManager) recordChatTimer(sessionID domain.SessionID, evt *events.Message) {
+	if protocol := evt.Message.GetProtocolMessage(); protocol.GetType() == waE2E.ProtocolMessage_EPHEMERAL_SETTING {
+		msm.chatTimers.set(sessionID, evt.Info.Chat, protocol.GetEphemeralExpiration())
+		return
+	}
+
+	if ctxInfo := extractContextInfo(evt.Message); ctxInfo != nil && ctxInfo.Expiration != nil {
+		msm.chatTimers.set(sessionID, evt.Info.Chat, ctxInfo.GetExpiration())
+	}
+}
+
+// applyDefaultEphemeral gives an outbound message the session's default
+// disappearing timer, unless the message already carries one or the chat has
+// its own setting
+func (msm *MultiSessionManager) applyDefaultEphemeral(sessionID domain.SessionID, recipient types.JID, msg *waE2E.Message) {
+	if recipient.Server == types.NewsletterServer || recipient.Server == types.BroadcastServer {
+		return
+	}
+	if _, known := msm.chatTimers.get(sessionID, recipient); known {
+		return
+	}
+
+	msm.mutex.RLock()
+	var seconds int
+	if sessionClient, running := msm.sessions[sessionID]; running {
+		seconds = sessionClient.defaultEphemeralSeconds
+	}
+	msm.mutex.RUnlock()
+	if seconds == 0 {
+		return
+	}
+
+	ctxInfo := outboundContextInfo(msg)
+	if ctxInfo == nil || ctxInfo.Expiration != nil {
+		return
+	}
+	ctxInfo.Expiration = proto.Uint32(uint32(seconds))
+}
+
+// outboundContextInfo returns the context info of an outbound message,
+// attaching an empty one when the message has none yet
+func outboundContextInfo(msg *waE2E.Message) *waE2E.ContextInfo {
+	switch {
+	case msg.GetExtendedTextMessage() != nil:
+		m := msg.GetExtendedTextMessage()
+		if m.ContextInfo == nil {
+			m.ContextInfo = &waE2E.ContextInfo{}
+		}
+		return m.ContextInfo
+	case msg.GetImageMessage() != nil:
+		m := msg.GetImageMessage()
+		if m.ContextInfo == nil {
+			m.ContextInfo = &waE2E.ContextInfo{}
+		}
+		return m.ContextInfo
+	case msg.GetVideoMessage() != nil:
+		m := msg.GetVideoMessage()
+		if m.ContextInfo == nil {
+			m.ContextInfo = &waE2E.ContextInfo{}
+		}
+		return m.ContextInfo
+	case msg.GetAudioMessage() != nil:
+		m := msg.GetAudioMessage()
+		if m.ContextInfo == nil {
+			m.ContextInfo = &waE2E.ContextInfo{}
+		}
+		return m.ContextInfo
+	case msg.GetDocumentMessage() != nil:
+		m := msg.GetDocumentMessage()
+		if m.ContextInfo == nil {
+			m.ContextInfo = &waE2E.ContextInfo{}
+		}
+		return m.ContextInfo
+	case msg.GetLocationMessage() != nil:
+		m := msg.GetLocationMessage()
+		if m.ContextInfo == nil {
+			m.ContextInfo = &waE2E.ContextInfo{}
+		}
+		return m.ContextInfo
+	case msg.GetContactMessage() != nil:
+		m := msg.GetContactMessage()
+		if m.ContextInfo == nil {
+			m.ContextInfo = &waE2E.ContextInfo{}
+		}
+		return m.ContextInfo
+	case msg.GetProductMessage() != nil:
+		m := msg.GetProductMessage()
+		if m.ContextInfo == nil {
+			m.ContextInfo = &waE2E.ContextInfo{}
+		}
+		return m.ContextInfo
+	default:
+		return nil
+	}
+}
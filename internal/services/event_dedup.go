@@ -0,0 +1,88 @@
+package services
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"wazmeow/internal/domain"
+)
+
+const (
+	// defaultEventDedupTTL is how long a delivered message ID suppresses repeats
+	defaultEventDedupTTL = 10 * time.Minute
+	// defaultEventDedupSize bounds the message IDs remembered across all sessions
+	defaultEventDedupSize = 10000
+)
+
+type dedupEntry struct {
+	key    string
+	seenAt time.Time
+}
+
+// eventDeduper remembers recently delivered inbound message IDs, so a message
+// WhatsApp redelivers on reconnect is not emitted twice. Entries expire after
+// the TTL and the least recently seen one is evicted once the cache is full.
+type eventDeduper struct {
+	ttl     time.Duration
+	size    int
+	order   *list.List // front is the most recently seen
+	entries map[string]*list.Element
+	mutex   sync.Mutex
+}
+
+func newEventDeduper(ttl time.Duration, size int) *eventDeduper {
+	return &eventDeduper{
+		ttl:     ttl,
+		size:    size,
+		order:   list.New(),
+		entries: make(map[string]*list.Element),
+	}
+}
+
+// seen records a message and reports whether it was already recorded within the TTL
+func (d *eventDeduper) seen(sessionID domain.SessionID, messageID string) bool {
+	if d.ttl <= 0 || messageID == "" {
+		return false
+	}
+
+	key := sessionID.String() + "/" + messageID
+	now := time.Now()
+
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	if elem, exists := d.entries[key]; exists {
+		entry := elem.Value.(*dedupEntry)
+		if now.Sub(entry.seenAt) < d.ttl {
+			d.order.MoveToFront(elem)
+			return true
+		}
+		// Expired, treat as a new delivery
+		entry.seenAt = now
+		d.order.MoveToFront(elem)
+		return false
+	}
+
+	d.entries[key] = d.order.PushFront(&dedupEntry{key: key, seenAt: now})
+	for d.order.Len() > d.size {
+		oldest := d.order.Back()
+		d.order.Remove(oldest)
+		delete(d.entries, oldest.Value.(*dedupEntry).key)
+	}
+
+	return false
+}
+
+// SetEventDedup sets how long a delivered inbound message ID suppresses
+// redeliveries of it and how many IDs are remembered. A zero TTL disables
+// deduplication.
+func (msm *MultiSessionManager) SetEventDedup(ttl time.Duration, size int) {
+	if ttl < 0 {
+		return
+	}
+	if size <= 0 {
+		size = defaultEventDedupSize
+	}
+	msm.dedup = newEventDeduper(ttl, size)
+}
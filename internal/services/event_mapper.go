@@ -0,0 +1,353 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+
+	"wazmeow/internal/domain"
+
+	"github.com/rs/zerolog/log"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// mapMessageEvent converts an inbound whatsmeow message into a domain event.
+// Protocol edits and revokes are reported as their own event types so they
+// are not mistaken for new messages.
+func mapMessageEvent(sessionID domain.SessionID, evt *events.Message) domain.Event {
+	if protocolMsg := evt.Message.GetProtocolMessage(); protocolMsg != nil {
+		switch protocolMsg.GetType() {
+		case waE2E.ProtocolMessage_MESSAGE_EDIT:
+			edited := protocolMsg.GetEditedMessage()
+			return domain.MessageEditEvent{
+				SessionID:       sessionID,
+				EventType:       domain.EventTypeMessageEdit,
+				MessageID:       evt.Info.ID,
+				TargetMessageID: protocolMsg.GetKey().GetID(),
+				From:            evt.Info.Sender.String(),
+				To:              evt.Info.Chat.String(),
+				Timestamp:       evt.Info.Timestamp,
+				Body:            extractMessageBody(edited),
+				IsGroup:         evt.Info.IsGroup,
+				IsFromMe:        evt.Info.IsFromMe,
+				MessageType:     detectMessageType(edited),
+			}
+
+		case waE2E.ProtocolMessage_REVOKE:
+			return domain.MessageRevokeEvent{
+				SessionID:       sessionID,
+				EventType:       domain.EventTypeMessageRevoke,
+				MessageID:       evt.Info.ID,
+				TargetMessageID: protocolMsg.GetKey().GetID(),
+				From:            evt.Info.Sender.String(),
+				To:              evt.Info.Chat.String(),
+				Timestamp:       evt.Info.Timestamp,
+				IsGroup:         evt.Info.IsGroup,
+				IsFromMe:        evt.Info.IsFromMe,
+			}
+		}
+	}
+
+	return newMessageEvent(sessionID, evt)
+}
+
+// newMessageEvent builds a domain.MessageEvent from a regular inbound message
+func newMessageEvent(sessionID domain.SessionID, evt *events.Message) domain.MessageEvent {
+	msg := evt.Message
+
+	event := domain.MessageEvent{
+		SessionID:   sessionID,
+		EventType:   domain.EventTypeMessage,
+		MessageID:   evt.Info.ID,
+		MessageType: detectMessageType(msg),
+		From:        evt.Info.Sender.String(),
+		To:          evt.Info.Chat.String(),
+		Timestamp:   evt.Info.Timestamp,
+		Body:        extractMessageBody(msg),
+		MimeType:    extractMimeType(msg),
+		Caption:     extractCaption(msg),
+		IsGroup:     evt.Info.IsGroup,
+		IsFromMe:    evt.Info.IsFromMe,
+	}
+
+	if evt.Info.IsGroup {
+		event.GroupID = evt.Info.Chat.String()
+		event.Participant = evt.Info.Sender.String()
+	}
+
+	if media := extractDownloadable(msg); media != nil {
+		event.Media = &domain.MediaReference{
+			DirectPath:    media.GetDirectPath(),
+			MediaKey:      media.GetMediaKey(),
+			FileSHA256:    media.GetFileSHA256(),
+			FileEncSHA256: media.GetFileEncSHA256(),
+			FileLength:    mediaFileLength(msg),
+			MediaType:     event.MessageType,
+			MimeType:      event.MimeType,
+		}
+	}
+
+	if ctxInfo := extractContextInfo(msg); ctxInfo != nil {
+		event.Mentions = ctxInfo.GetMentionedJID()
+		if ctxInfo.GetStanzaID() != "" {
+			event.Quoted = &domain.QuotedMessage{
+				MessageID: ctxInfo.GetStanzaID(),
+				From:      ctxInfo.GetParticipant(),
+				Body:      extractMessageBody(ctxInfo.GetQuotedMessage()),
+				Type:      detectMessageType(ctxInfo.GetQuotedMessage()),
+			}
+		}
+	}
+
+	return event
+}
+
+// newStoredMessage builds the persisted form of a message
+func newStoredMessage(sessionID domain.SessionID, evt *events.Message) *domain.Message {
+	event := newMessageEvent(sessionID, evt)
+
+	stored := &domain.Message{
+		ID:        evt.Info.ID,
+		SessionID: sessionID,
+		ChatJID:   evt.Info.Chat.String(),
+		SenderJID: evt.Info.Sender.String(),
+		FromMe:    evt.Info.IsFromMe,
+		Type:      event.MessageType,
+		Body:      event.Body,
+		Caption:   event.Caption,
+		MimeType:  event.MimeType,
+		Timestamp: evt.Info.Timestamp,
+	}
+	if event.Quoted != nil {
+		stored.QuotedMessageID = event.Quoted.MessageID
+	}
+	if media := extractDownloadable(evt.Message); media != nil {
+		stored.DirectPath = media.GetDirectPath()
+		stored.MediaKey = media.GetMediaKey()
+		stored.FileSHA256 = media.GetFileSHA256()
+		stored.FileEncSHA256 = media.GetFileEncSHA256()
+		stored.FileLength = int64(mediaFileLength(evt.Message))
+	}
+
+	return stored
+}
+
+// detectMessageType returns the domain message type of a WhatsApp message
+func detectMessageType(msg *waE2E.Message) domain.MessageType {
+	switch {
+	case msg == nil:
+		return domain.MessageTypeUnknown
+	case msg.GetConversation() != "" || msg.GetExtendedTextMessage() != nil:
+		return domain.MessageTypeText
+	case msg.GetImageMessage() != nil:
+		return domain.MessageTypeImage
+	case msg.GetVideoMessage() != nil:
+		return domain.MessageTypeVideo
+	case msg.GetAudioMessage() != nil:
+		return domain.MessageTypeAudio
+	case msg.GetDocumentMessage() != nil:
+		return domain.MessageTypeDocument
+	case msg.GetStickerMessage() != nil:
+		return domain.MessageTypeSticker
+	case msg.GetLocationMessage() != nil, msg.GetLiveLocationMessage() != nil:
+		return domain.MessageTypeLocation
+	case msg.GetContactMessage() != nil, msg.GetContactsArrayMessage() != nil:
+		return domain.MessageTypeContact
+	default:
+		return domain.MessageTypeUnknown
+	}
+}
+
+// extractMessageBody returns the text content of a message, if any
+func extractMessageBody(msg *waE2E.Message) string {
+	if msg == nil {
+		return ""
+	}
+	if text := msg.GetConversation(); text != "" {
+		return text
+	}
+	if text := msg.GetExtendedTextMessage().GetText(); text != "" {
+		return text
+	}
+	return extractCaption(msg)
+}
+
+// extractCaption returns the caption of a media message, if any
+func extractCaption(msg *waE2E.Message) string {
+	switch {
+	case msg.GetImageMessage() != nil:
+		return msg.GetImageMessage().GetCaption()
+	case msg.GetVideoMessage() != nil:
+		return msg.GetVideoMessage().GetCaption()
+	case msg.GetDocumentMessage() != nil:
+		return msg.GetDocumentMessage().GetCaption()
+	default:
+		return ""
+	}
+}
+
+// extractMimeType returns the MIME type of a media message, if any
+func extractMimeType(msg *waE2E.Message) string {
+	switch {
+	case msg.GetImageMessage() != nil:
+		return msg.GetImageMessage().GetMimetype()
+	case msg.GetVideoMessage() != nil:
+		return msg.GetVideoMessage().GetMimetype()
+	case msg.GetAudioMessage() != nil:
+		return msg.GetAudioMessage().GetMimetype()
+	case msg.GetDocumentMessage() != nil:
+		return msg.GetDocumentMessage().GetMimetype()
+	case msg.GetStickerMessage() != nil:
+		return msg.GetStickerMessage().GetMimetype()
+	default:
+		return ""
+	}
+}
+
+// extractDownloadable returns the downloadable media part of a message, if any
+func extractDownloadable(msg *waE2E.Message) whatsmeow.DownloadableMessage {
+	switch {
+	case msg.GetImageMessage() != nil:
+		return msg.GetImageMessage()
+	case msg.GetVideoMessage() != nil:
+		return msg.GetVideoMessage()
+	case msg.GetAudioMessage() != nil:
+		return msg.GetAudioMessage()
+	case msg.GetDocumentMessage() != nil:
+		return msg.GetDocumentMessage()
+	case msg.GetStickerMessage() != nil:
+		return msg.GetStickerMessage()
+	default:
+		return nil
+	}
+}
+
+// mediaFileLength returns the declared size of a media message, if any
+func mediaFileLength(msg *waE2E.Message) uint64 {
+	switch {
+	case msg.GetImageMessage() != nil:
+		return msg.GetImageMessage().GetFileLength()
+	case msg.GetVideoMessage() != nil:
+		return msg.GetVideoMessage().GetFileLength()
+	case msg.GetAudioMessage() != nil:
+		return msg.GetAudioMessage().GetFileLength()
+	case msg.GetDocumentMessage() != nil:
+		return msg.GetDocumentMessage().GetFileLength()
+	case msg.GetStickerMessage() != nil:
+		return msg.GetStickerMessage().GetFileLength()
+	default:
+		return 0
+	}
+}
+
+// mediaTypeFor maps a domain message type to the whatsmeow media type used for downloads
+func mediaTypeFor(messageType domain.MessageType) whatsmeow.MediaType {
+	switch messageType {
+	case domain.MessageTypeVideo:
+		return whatsmeow.MediaVideo
+	case domain.MessageTypeAudio:
+		return whatsmeow.MediaAudio
+	case domain.MessageTypeDocument:
+		return whatsmeow.MediaDocument
+	default:
+		return whatsmeow.MediaImage
+	}
+}
+
+// extractContextInfo returns the context info attached to a message, if any
+func extractContextInfo(msg *waE2E.Message) *waE2E.ContextInfo {
+	switch {
+	case msg.GetExtendedTextMessage() != nil:
+		return msg.GetExtendedTextMessage().GetContextInfo()
+	case msg.GetImageMessage() != nil:
+		return msg.GetImageMessage().GetContextInfo()
+	case msg.GetVideoMessage() != nil:
+		return msg.GetVideoMessage().GetContextInfo()
+	case msg.GetAudioMessage() != nil:
+		return msg.GetAudioMessage().GetContextInfo()
+	case msg.GetDocumentMessage() != nil:
+		return msg.GetDocumentMessage().GetContextInfo()
+	case msg.GetStickerMessage() != nil:
+		return msg.GetStickerMessage().GetContextInfo()
+	default:
+		return nil
+	}
+}
+
+// mapChatStateEvent converts an app-state chat change into a domain event.
+// It returns false for events that are not chat state changes.
+func mapChatStateEvent(sessionID domain.SessionID, evt any) (domain.ChatStateEvent, bool) {
+	event := domain.ChatStateEvent{
+		SessionID: sessionID,
+		EventType: domain.EventTypeChatState,
+	}
+
+	switch v := evt.(type) {
+	case *events.Archive:
+		event.Chat = v.JID.String()
+		event.Action = chatStateAction(v.Action.GetArchived(), "archived", "unarchived")
+		event.FromFullSync = v.FromFullSync
+		event.Timestamp = v.Timestamp
+
+	case *events.Mute:
+		event.Chat = v.JID.String()
+		event.Action = chatStateAction(v.Action.GetMuted(), "muted", "unmuted")
+		if end := v.Action.GetMuteEndTimestamp(); v.Action.GetMuted() && end > 0 {
+			mutedUntil := time.UnixMilli(end)
+			event.MutedUntil = &mutedUntil
+		}
+		event.FromFullSync = v.FromFullSync
+		event.Timestamp = v.Timestamp
+
+	case *events.Pin:
+		event.Chat = v.JID.String()
+		event.Action = chatStateAction(v.Action.GetPinned(), "pinned", "unpinned")
+		event.FromFullSync = v.FromFullSync
+		event.Timestamp = v.Timestamp
+
+	case *events.MarkChatAsRead:
+		event.Chat = v.JID.String()
+		event.Action = chatStateAction(v.Action.GetRead(), "read", "unread")
+		event.FromFullSync = v.FromFullSync
+		event.Timestamp = v.Timestamp
+
+	default:
+		return event, false
+	}
+
+	return event, true
+}
+
+func chatStateAction(state bool, on, off string) string {
+	if state {
+		return on
+	}
+	return off
+}
+
+// mapUnknownEvent wraps a whatsmeow event without a dedicated mapping into a
+// notification carrying its Go type name and its JSON encoding. It returns
+// false for events that cannot be encoded.
+func mapUnknownEvent(sessionID domain.SessionID, evt any) (domain.NotificationEvent, bool) {
+	content, err := json.Marshal(evt)
+	if err != nil {
+		log.Debug().Err(err).Str("session_id", sessionID.String()).Str("type", fmt.Sprintf("%T", evt)).Msg("Skipping unencodable unknown event")
+		return domain.NotificationEvent{}, false
+	}
+
+	eventType := reflect.TypeOf(evt)
+	for eventType.Kind() == reflect.Pointer {
+		eventType = eventType.Elem()
+	}
+
+	return domain.NotificationEvent{
+		SessionID: sessionID,
+		EventType: domain.EventTypeNotification,
+		Type:      eventType.Name(),
+		Timestamp: time.Now(),
+		Content:   json.RawMessage(content),
+	}, true
+}
@@ -0,0 +1,161 @@
+package services
+
+import (
+	"context"
+	"hash/fnv"
+	"sync"
+
+	"wazmeow/internal/domain"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// defaultEventWorkers is how many chats of one session are processed at once
+	defaultEventWorkers = 4
+	// defaultEventBufferSize bounds the events of one session waiting for a worker
+	defaultEventBufferSize = 1000
+)
+
+// sessionEventQueue processes the events of one session on a small worker
+// pool, so slow webhook deliveries never block whatsmeow's read loop and one
+// busy session cannot starve the others. Events are sharded by chat onto one
+// queue per worker, so the events of a chat run one at a time and in order.
+// When a shard's buffer is full its oldest waiting event is dropped.
+type sessionEventQueue struct {
+	sessionID domain.SessionID
+	shards    []*eventShard
+	workers   sync.WaitGroup
+}
+
+// eventShard is the queue of one worker
+type eventShard struct {
+	tasks  []func()
+	size   int
+	closed bool
+	mutex  sync.Mutex
+	ready  *sync.Cond
+}
+
+func newSessionEventQueue(sessionID domain.SessionID, workers, size int) *sessionEventQueue {
+	q := &sessionEventQueue{
+		sessionID: sessionID,
+		shards:    make([]*eventShard, workers),
+	}
+
+	// The buffer is split evenly between the shards
+	shardSize := (size + workers - 1) / workers
+	for i := 0; i < workers; i++ {
+		shard := &eventShard{size: shardSize}
+		shard.ready = sync.NewCond(&shard.mutex)
+		q.shards[i] = shard
+		q.workers.Add(1)
+		go q.work(shard)
+	}
+
+	return q
+}
+
+// submit queues an event handler behind the earlier events of the same chat,
+// dropping the oldest waiting event of its shard if the buffer is full
+func (q *sessionEventQueue) submit(chat string, task func()) {
+	h := fnv.New32a()
+	h.Write([]byte(chat))
+	shard := q.shards[h.Sum32()%uint32(len(q.shards))]
+
+	shard.mutex.Lock()
+	if shard.closed {
+		shard.mutex.Unlock()
+		return
+	}
+
+	dropped := false
+	if len(shard.tasks) >= shard.size {
+		shard.tasks[0] = nil
+		shard.tasks = shard.tasks[1:]
+		dropped = true
+	}
+	shard.tasks = append(shard.tasks, task)
+	shard.mutex.Unlock()
+	shard.ready.Signal()
+
+	if dropped {
+		log.Warn().
+			Str("session_id", q.sessionID.String()).
+			Int("buffer_size", shard.size).
+			Msg("Event buffer full, dropped oldest event")
+	}
+}
+
+// close makes the workers exit once they processed the events already
+// queued. Use wait to block until they did.
+func (q *sessionEventQueue) close() {
+	for _, shard := range q.shards {
+		shard.mutex.Lock()
+		shard.closed = true
+		shard.mutex.Unlock()
+		shard.ready.Broadcast()
+	}
+}
+
+// wait blocks until the workers of a closed queue exited or ctx is done
+func (q *sessionEventQueue) wait(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		q.workers.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (q *sessionEventQueue) work(shard *eventShard) {
+	defer q.workers.Done()
+
+	for {
+		shard.mutex.Lock()
+		for len(shard.tasks) == 0 && !shard.closed {
+			shard.ready.Wait()
+		}
+		if len(shard.tasks) == 0 {
+			shard.mutex.Unlock()
+			return
+		}
+		task := shard.tasks[0]
+		shard.tasks[0] = nil
+		shard.tasks = shard.tasks[1:]
+		shard.mutex.Unlock()
+
+		q.run(task)
+	}
+}
+
+// run executes one event handler, keeping the worker alive if it panics
+func (q *sessionEventQueue) run(task func()) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Error().
+				Str("session_id", q.sessionID.String()).
+				Interface("panic", r).
+				Msg("Panic while processing session event")
+		}
+	}()
+
+	task()
+}
+
+// SetEventConcurrency sets the worker count and buffer size of the event and
+// webhook delivery queues of sessions started from now on. Non-positive values keep the default.
+func (msm *MultiSessionManager) SetEventConcurrency(workers, bufferSize int) {
+	if workers > 0 {
+		msm.eventWorkers = workers
+	}
+	if bufferSize > 0 {
+		msm.eventBufferSize = bufferSize
+	}
+}
@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"wazmeow/internal/domain"
+
+	"github.com/rs/zerolog/log"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+const (
+	// defaultGroupBatchSize is how many participants one update request carries
+	defaultGroupBatchSize = 20
+	// defaultGroupBatchDelay is the pause between two update requests
+	defaultGroupBatchDelay = time.Second
+)
+
+// GroupParticipantResult is the outcome of a membership change for one participant
+type GroupParticipantResult struct {
+	JID     string `json:"jid"`
+	Success bool   `json:"success"`
+	Code    int    `json:"code,omitempty"`  // WhatsApp error code, e.g. 403 when privacy settings block an add
+	Error   string `json:"error,omitempty"` // set when the whole batch failed
+}
+
+// GroupParticipantsResult aggregates a membership change sent in batches
+type GroupParticipantsResult struct {
+	Group        string                   `json:"group"`
+	Action       string                   `json:"action"`
+	Batches      int                      `json:"batches"`
+	Succeeded    int                      `json:"succeeded"`
+	Failed       int                      `json:"failed"`
+	Participants []GroupParticipantResult `json:"participants"`
+}
+
+// SetGroupBatching sets how many participants each update request carries and
+// the pause between requests
+func (msm *MultiSessionManager) SetGroupBatching(size int, delay time.Duration) {
+	if size > 0 {
+		msm.groupBatchSize = size
+	}
+	if delay >= 0 {
+		msm.groupBatchDelay = delay
+	}
+}
+
+// UpdateGroupParticipants adds, removes, promotes or demotes group members.
+// Large lists are split into batches sent one after another, so a bulk change
+// stays within WhatsApp's per-request limits; a failed batch does not stop the
+// ones after it.
+func (msm *MultiSessionManager) UpdateGroupParticipants(ctx context.Context, sessionID domain.SessionID, group types.JID, participants []types.JID, action whatsmeow.ParticipantChange) (*GroupParticipantsResult, error) {
+	if _, err := msm.sessionRepo.GetByID(ctx, sessionID); err != nil {
+		return nil, err
+	}
+
+	client, err := msm.GetClient(sessionID)
+	if err != nil || !client.IsConnected() || !client.IsLoggedIn() {
+		return nil, domain.NewBusinessError("session is not connected")
+	}
+
+	result := &GroupParticipantsResult{
+		Group:        group.String(),
+		Action:       string(action),
+		Participants: make([]GroupParticipantResult, 0, len(participants)),
+	}
+
+	for start := 0; start < len(participants); start += msm.groupBatchSize {
+		end := min(start+msm.groupBatchSize, len(participants))
+		batch := participants[start:end]
+
+		if start > 0 && msm.groupBatchDelay > 0 {
+			select {
+			case <-time.After(msm.groupBatchDelay):
+			case <-ctx.Done():
+			}
+		}
+
+		var changed []types.GroupParticipant
+		err := ctx.Err()
+		if err == nil {
+			changed, err = client.UpdateGroupParticipants(group, batch, action)
+		}
+		result.Batches++
+
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str("session_id", sessionID.String()).
+				Str("group", group.String()).
+				Int("batch_size", len(batch)).
+				Msg("Failed to update group participants batch")
+
+			for _, jid := range batch {
+				result.Participants = append(result.Participants, GroupParticipantResult{
+					JID:   jid.String(),
+					Error: err.Error(),
+				})
+			}
+			result.Failed += len(batch)
+			continue
+		}
+
+		for _, participant := range changed {
+			entry := GroupParticipantResult{
+				JID:     participant.JID.String(),
+				Success: participant.Error == 0,
+				Code:    participant.Error,
+			}
+			if entry.Success {
+				result.Succeeded++
+			} else {
+				result.Failed++
+			}
+			result.Participants = append(result.Participants, entry)
+		}
+	}
+
+	log.Info().
+		Str("session_id", sessionID.String()).
+		Str("group", group.String()).
+		Str("action", string(action)).
+		Int("batches", result.Batches).
+		Int("succeeded", result.Succeeded).
+		Int("failed", result.Failed).
+		Msg("Group participants updated")
+
+	return result, nil
+}
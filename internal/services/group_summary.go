@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	"wazmeow/internal/domain"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// GroupSummary is the small subset of group info needed for routing decisions
+type GroupSummary struct {
+	Group        string `json:"group"`
+	MemberCount  int    `json:"member_count"`
+	IsAdmin      bool   `json:"is_admin"`       // true for superadmins as well
+	IsSuperAdmin bool   `json:"is_super_admin"` // the group creator
+	AdminsOnly   bool   `json:"admins_only"`    // only admins can send messages
+}
+
+// GetGroupSummary returns the member count of a group and the session account's
+// standing in it
+func (msm *MultiSessionManager) GetGroupSummary(ctx context.Context, sessionID domain.SessionID, group types.JID) (*GroupSummary, error) {
+	if _, err := msm.sessionRepo.GetByID(ctx, sessionID); err != nil {
+		return nil, err
+	}
+
+	client, err := msm.GetClient(sessionID)
+	if err != nil || !client.IsConnected() || !client.IsLoggedIn() {
+		return nil, domain.NewBusinessError("session is not connected")
+	}
+
+	info, err := client.GetGroupInfo(group)
+	if err != nil {
+		switch {
+		case errors.Is(err, whatsmeow.ErrGroupNotFound):
+			return nil, domain.NewNotFoundError("Group", group.String())
+		case errors.Is(err, whatsmeow.ErrNotInGroup):
+			return nil, domain.NewBusinessError("session is not a participant of this group")
+		}
+		return nil, err
+	}
+
+	summary := &GroupSummary{
+		Group:       info.JID.String(),
+		MemberCount: len(info.Participants),
+		AdminsOnly:  info.IsAnnounce,
+	}
+
+	// Participants are listed by phone number or LID depending on the group's addressing mode
+	var ownPN types.JID
+	if client.Store.ID != nil {
+		ownPN = client.Store.ID.ToNonAD()
+	}
+	ownLID := client.Store.LID.ToNonAD()
+
+	for _, p := range info.Participants {
+		if !isOwnParticipant(p, ownPN, ownLID) {
+			continue
+		}
+		summary.IsAdmin = p.IsAdmin || p.IsSuperAdmin
+		summary.IsSuperAdmin = p.IsSuperAdmin
+		break
+	}
+
+	return summary, nil
+}
+
+// isOwnParticipant reports whether a group participant is the session account
+func isOwnParticipant(p types.GroupParticipant, ownPN, ownLID types.JID) bool {
+	for _, jid := range []types.JID{p.JID, p.PhoneNumber, p.LID} {
+		if jid.IsEmpty() {
+			continue
+		}
+		jid = jid.ToNonAD()
+		if (!ownPN.IsEmpty() && jid == ownPN) || (!ownLID.IsEmpty() && jid == ownLID) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"time"
+	"unicode/utf8"
+
+	"wazmeow/internal/domain"
+
+	"github.com/rs/zerolog/log"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// maxGroupSubjectLength is the longest group name WhatsApp accepts
+const maxGroupSubjectLength = 100
+
+// GroupMember is a participant of a group as returned by the API
+type GroupMember struct {
+	JID          string `json:"jid"`
+	PhoneNumber  string `json:"phone_number,omitempty"`
+	LID          string `json:"lid,omitempty"`
+	IsAdmin      bool   `json:"is_admin"`        // true for superadmins as well
+	IsSuperAdmin bool   `json:"is_super_admin"`  // the group creator
+	Error        int    `json:"error,omitempty"` // WhatsApp error code when adding the participant failed
+}
+
+// GroupInfo is a group as returned by the API
+type GroupInfo struct {
+	JID          string        `json:"jid"`
+	Subject      string        `json:"subject"`
+	Topic        string        `json:"topic,omitempty"`
+	Owner        string        `json:"owner,omitempty"`
+	CreatedAt    time.Time     `json:"created_at"`
+	AdminsOnly   bool          `json:"admins_only"` // only admins can send messages
+	Locked       bool          `json:"locked"`      // only admins can edit the group info
+	Participants []GroupMember `json:"participants"`
+}
+
+// newGroupInfo builds the API view of a whatsmeow group
+func newGroupInfo(info *types.GroupInfo) GroupInfo {
+	group := GroupInfo{
+		JID:          info.JID.String(),
+		Subject:      info.Name,
+		Topic:        info.Topic,
+		CreatedAt:    info.GroupCreated,
+		AdminsOnly:   info.IsAnnounce,
+		Locked:       info.IsLocked,
+		Participants: make([]GroupMember, 0, len(info.Participants)),
+	}
+	if !info.OwnerJID.IsEmpty() {
+		group.Owner = info.OwnerJID.String()
+	}
+
+	for _, p := range info.Participants {
+		member := GroupMember{
+			JID:          p.JID.String(),
+			IsAdmin:      p.IsAdmin || p.IsSuperAdmin,
+			IsSuperAdmin: p.IsSuperAdmin,
+			Error:        p.Error,
+		}
+		if !p.PhoneNumber.IsEmpty() {
+			member.PhoneNumber = p.PhoneNumber.String()
+		}
+		if !p.LID.IsEmpty() {
+			member.LID = p.LID.String()
+		}
+		group.Participants = append(group.Participants, member)
+	}
+
+	return group
+}
+
+// CreateGroup creates a group with the session account as its creator. The
+// account does not need to be among the participants. Participants WhatsApp
+// refused to add carry an error code.
+func (msm *MultiSessionManager) CreateGroup(ctx context.Context, sessionID domain.SessionID, subject string, participants []types.JID) (*GroupInfo, error) {
+	if subject == "" {
+		return nil, domain.NewValidationError("subject is required")
+	}
+	if utf8.RuneCountInString(subject) > maxGroupSubjectLength {
+		return nil, domain.NewValidationError(fmt.Sprintf("subject is longer than %d characters", maxGroupSubjectLength))
+	}
+
+	if _, err := msm.sessionRepo.GetByID(ctx, sessionID); err != nil {
+		return nil, err
+	}
+
+	client, err := msm.GetClient(sessionID)
+	if err != nil || !client.IsConnected() || !client.IsLoggedIn() {
+		return nil, domain.NewBusinessError("session is not connected")
+	}
+
+	info, err := client.CreateGroup(whatsmeow.ReqCreateGroup{
+		Name:         subject,
+		Participants: participants,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create group: %w", err)
+	}
+
+	log.Info().
+		Str("session_id", sessionID.String()).
+		Str("group", info.JID.String()).
+		Int("participants", len(participants)).
+		Msg("Group created")
+
+	group := newGroupInfo(info)
+	return &group, nil
+}
+
+// ListJoinedGroups returns the groups the session account is a participant of
+func (msm *MultiSessionManager) ListJoinedGroups(ctx context.Context, sessionID domain.SessionID) ([]GroupInfo, error) {
+	if _, err := msm.sessionRepo.GetByID(ctx, sessionID); err != nil {
+		return nil, err
+	}
+
+	client, err := msm.GetClient(sessionID)
+	if err != nil || !client.IsConnected() || !client.IsLoggedIn() {
+		return nil, domain.NewBusinessError("session is not connected")
+	}
+
+	joined, err := client.GetJoinedGroups()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get joined groups: %w", err)
+	}
+
+	groups := make([]GroupInfo, 0, len(joined))
+	for _, info := range joined {
+		groups = append(groups, newGroupInfo(info))
+	}
+	return groups, nil
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"wazmeow/internal/domain"
+
+	"github.com/rs/zerolog/log"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// jobPersistTimeout bounds a single pending job write
+const jobPersistTimeout = 5 * time.Second
+
+// persist stores a pending job so it survives a restart. A failed write is
+// logged and the job still runs from memory.
+func (js *jobStore) persist(job *SendJob) {
+	if js.repo == nil {
+		return
+	}
+
+	payload, err := proto.Marshal(job.Message)
+	if err != nil {
+		log.Error().Err(err).Str("job_id", job.ID).Msg("Failed to encode pending job")
+		return
+	}
+
+	pending := &domain.PendingJob{
+		ID:         job.ID,
+		SessionID:  job.SessionID,
+		Type:       string(job.Type),
+		Recipient:  job.Recipient.String(),
+		Phone:      job.Phone,
+		MessageID:  job.MessageID,
+		Payload:    payload,
+		Priority:   string(job.Priority),
+		Attempts:   job.Attempts,
+		EnqueuedAt: job.EnqueuedAt,
+	}
+	if !job.ExpiresAt.IsZero() {
+		expiresAt := job.ExpiresAt
+		pending.ExpiresAt = &expiresAt
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), jobPersistTimeout)
+	defer cancel()
+	_ = js.repo.Save(ctx, pending) // the repository logs failures
+}
+
+// unpersist removes a job that ran or was cancelled from storage
+func (js *jobStore) unpersist(jobID string) {
+	if js.repo == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), jobPersistTimeout)
+	defer cancel()
+	_ = js.repo.Delete(ctx, jobID) // the repository logs failures
+}
+
+// restorePendingJobs reloads the jobs left pending by the previous run. They
+// are held like offline sends, so they go out once their session reconnects
+// or are reported as expired if it does not come back in time.
+func (msm *MultiSessionManager) restorePendingJobs() {
+	js := msm.jobs
+	if js.repo == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	pending, err := js.repo.List(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to restore pending jobs")
+		return
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	now := time.Now()
+	restored, expired := 0, 0
+	for _, p := range pending {
+		job, err := sendJobFromPending(p)
+		if err != nil {
+			log.Error().
+				Err(err).
+				Str("session_id", p.SessionID.String()).
+				Str("job_id", p.ID).
+				Msg("Dropping pending job that could not be decoded")
+			js.unpersist(p.ID)
+			continue
+		}
+
+		// Async sends had no deadline, give them the same grace as held sends
+		if job.ExpiresAt.IsZero() {
+			job.ExpiresAt = now.Add(offlineSendTTL)
+		}
+
+		js.mutex.Lock()
+		js.jobs[job.ID] = job
+		js.mutex.Unlock()
+
+		if now.After(job.ExpiresAt) {
+			go msm.expireOfflineSend(job)
+			expired++
+			continue
+		}
+
+		ob := msm.offlineSends
+		ob.mutex.Lock()
+		ob.jobs[job.SessionID] = append(ob.jobs[job.SessionID], job)
+		ob.mutex.Unlock()
+		restored++
+	}
+
+	log.Info().
+		Int("restored_jobs", restored).
+		Int("expired_jobs", expired).
+		Msg("Pending jobs restored")
+}
+
+// sendJobFromPending decodes a stored pending job
+func sendJobFromPending(p *domain.PendingJob) (*SendJob, error) {
+	recipient, err := types.ParseJID(p.Recipient)
+	if err != nil {
+		return nil, err
+	}
+
+	message := &waE2E.Message{}
+	if err := proto.Unmarshal(p.Payload, message); err != nil {
+		return nil, err
+	}
+
+	job := &SendJob{
+		ID:         p.ID,
+		Type:       JobType(p.Type),
+		SessionID:  p.SessionID,
+		Recipient:  recipient,
+		Phone:      p.Phone,
+		MessageID:  p.MessageID,
+		Message:    message,
+		Priority:   SendPriority(p.Priority),
+		EnqueuedAt: p.EnqueuedAt,
+		Attempts:   p.Attempts,
+	}
+	if p.ExpiresAt != nil {
+		job.ExpiresAt = *p.ExpiresAt
+	}
+
+	return job, nil
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"wazmeow/internal/domain"
+
+	"github.com/rs/zerolog/log"
+)
+
+// JobType identifies the feature that created a job
+type JobType string
+
+const (
+	JobTypeAsyncSend   JobType = "async_send"   // queued with async: true
+	JobTypeOfflineSend JobType = "offline_send" // held with queue_if_offline: true
+)
+
+// JobInfo is a snapshot of a pending job
+type JobInfo struct {
+	ID         string           `json:"id"`
+	SessionID  domain.SessionID `json:"session_id"`
+	Type       JobType          `json:"type"`
+	Target     string           `json:"target"`
+	MessageID  string           `json:"message_id"`
+	Priority   SendPriority     `json:"priority"`
+	EnqueuedAt time.Time        `json:"enqueued_at"`
+	ExpiresAt  *time.Time       `json:"expires_at,omitempty"`
+}
+
+// jobStore tracks every pending job of the async features, so jobs can be
+// listed and cancelled wherever they are waiting
+type jobStore struct {
+	jobs  map[string]*SendJob
+	repo  domain.JobRepository // nil when jobs are kept in memory only
+	mutex sync.Mutex
+}
+
+func newJobStore(repo domain.JobRepository) *jobStore {
+	return &jobStore{
+		jobs: make(map[string]*SendJob),
+		repo: repo,
+	}
+}
+
+func (js *jobStore) add(job *SendJob) {
+	js.mutex.Lock()
+	js.jobs[job.ID] = job
+	js.mutex.Unlock()
+
+	js.persist(job)
+}
+
+// take removes a job that is about to run and reports whether it was still
+// pending. A job that was cancelled in the meantime must not run.
+func (js *jobStore) take(jobID string) bool {
+	js.mutex.Lock()
+
+	if _, exists := js.jobs[jobID]; !exists {
+		js.mutex.Unlock()
+		return false
+	}
+	delete(js.jobs, jobID)
+	js.mutex.Unlock()
+
+	js.unpersist(jobID)
+	return true
+}
+
+// ListJobs returns the pending jobs of a session, oldest first
+func (msm *MultiSessionManager) ListJobs(sessionID domain.SessionID) []JobInfo {
+	js := msm.jobs
+	js.mutex.Lock()
+	defer js.mutex.Unlock()
+
+	infos := make([]JobInfo, 0)
+	for _, job := range js.jobs {
+		if job.SessionID != sessionID {
+			continue
+		}
+
+		info := JobInfo{
+			ID:         job.ID,
+			SessionID:  job.SessionID,
+			Type:       job.Type,
+			Target:     job.Recipient.String(),
+			MessageID:  job.MessageID,
+			Priority:   job.Priority,
+			EnqueuedAt: job.EnqueuedAt,
+		}
+		if !job.ExpiresAt.IsZero() {
+			expiresAt := job.ExpiresAt
+			info.ExpiresAt = &expiresAt
+		}
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].EnqueuedAt.Before(infos[j].EnqueuedAt)
+	})
+
+	return infos
+}
+
+// CancelJob cancels a pending job of a session
+func (msm *MultiSessionManager) CancelJob(sessionID domain.SessionID, jobID string) error {
+	js := msm.jobs
+	js.mutex.Lock()
+	job, exists := js.jobs[jobID]
+	if !exists || job.SessionID != sessionID {
+		js.mutex.Unlock()
+		return domain.NewNotFoundError("job", jobID)
+	}
+	delete(js.jobs, jobID)
+	js.mutex.Unlock()
+
+	js.unpersist(jobID)
+
+	// Jobs already handed to the send queue are skipped by the workers
+	if job.Type == JobTypeOfflineSend {
+		msm.offlineSends.remove(sessionID, jobID)
+	}
+
+	log.Info().
+		Str("session_id", sessionID.String()).
+		Str("job_id", jobID).
+		Str("type", string(job.Type)).
+		Msg("Job cancelled")
+
+	return nil
+}
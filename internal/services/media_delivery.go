@@ -0,0 +1,262 @@
+package services
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"wazmeow/internal/domain"
+
+	"github.com/rs/zerolog/log"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// deliverMediaMessage attaches inbound media to a message event according to
+// the session's media delivery mode, then emits the event
+func (msm *MultiSessionManager) deliverMediaMessage(sessionID domain.SessionID, client *whatsmeow.Client, evt *events.Message, event domain.MessageEvent) {
+	// Emit even when media could not be attached so the message itself is not lost
+	defer func() { msm.emitEvent(event) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	session, err := msm.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionID.String()).Msg("Failed to get session for media delivery")
+		return
+	}
+
+	// Saved media is downloaded once and reused for inline delivery
+	var data []byte
+	if msm.mediaSaveDir != "" {
+		if data, err = client.Download(ctx, extractDownloadable(evt.Message)); err != nil {
+			log.Error().Err(err).Str("session_id", sessionID.String()).Str("message_id", evt.Info.ID).Msg("Failed to download inbound media")
+		} else if savedPath, err := msm.saveMedia(sessionID, evt, data); err != nil {
+			log.Error().Err(err).Str("session_id", sessionID.String()).Str("message_id", evt.Info.ID).Msg("Failed to save inbound media")
+		} else {
+			event.MediaPath = savedPath
+			event.MediaFile = msm.mediaFileURL(savedPath)
+		}
+	}
+
+	switch session.MediaDelivery {
+	case domain.MediaDeliveryS3:
+		mediaURL, err := msm.uploadMediaToS3(ctx, session, client, evt, data)
+		if err == nil {
+			event.MediaURL = mediaURL
+			return
+		}
+		// Fall back to a download link so the media stays reachable
+		log.Warn().Err(err).Str("session_id", sessionID.String()).Str("message_id", evt.Info.ID).Msg("S3 media upload failed, using download URL")
+		fallthrough
+
+	case domain.MediaDeliveryURL:
+		if err := msm.messageRepo.Save(ctx, newStoredMessage(sessionID, evt)); err != nil {
+			log.Error().Err(err).Str("session_id", sessionID.String()).Str("message_id", evt.Info.ID).Msg("Failed to store media message")
+			return
+		}
+		event.MediaURL = msm.mediaURL(sessionID, evt.Info.ID)
+
+	default:
+		if data == nil {
+			if data, err = client.Download(ctx, extractDownloadable(evt.Message)); err != nil {
+				log.Error().Err(err).Str("session_id", sessionID.String()).Str("message_id", evt.Info.ID).Msg("Failed to download inbound media")
+				return
+			}
+		}
+		event.MediaData = base64.StdEncoding.EncodeToString(data)
+	}
+}
+
+// SetMediaSaveDir enables saving inbound media under dir
+func (msm *MultiSessionManager) SetMediaSaveDir(dir string) {
+	msm.mediaSaveDir = dir
+}
+
+// uploadMediaToS3 uploads inbound media to the session's bucket and returns
+// the URL it is served from. data is downloaded when not given.
+func (msm *MultiSessionManager) uploadMediaToS3(ctx context.Context, session *domain.Session, client *whatsmeow.Client, evt *events.Message, data []byte) (string, error) {
+	uploader, err := NewS3Uploader(session.S3Config())
+	if err != nil {
+		return "", err
+	}
+
+	if data == nil {
+		if data, err = client.Download(ctx, extractDownloadable(evt.Message)); err != nil {
+			return "", fmt.Errorf("failed to download inbound media: %w", err)
+		}
+	}
+
+	return uploader.Upload(ctx, mediaObjectPath(session.ID, evt), data, extractMimeType(evt.Message))
+}
+
+// mediaObjectPath names inbound media session/chat/messageID.ext. The path
+// only depends on the message, so a redelivered message overwrites its
+// earlier copy.
+func mediaObjectPath(sessionID domain.SessionID, evt *events.Message) string {
+	return path.Join(
+		safePathComponent(sessionID.String()),
+		safePathComponent(evt.Info.Chat.ToNonAD().String()),
+		safePathComponent(evt.Info.ID)+mediaExtension(extractMimeType(evt.Message)),
+	)
+}
+
+// saveMedia writes inbound media to its object path under the media save
+// directory and returns that relative path
+func (msm *MultiSessionManager) saveMedia(sessionID domain.SessionID, evt *events.Message, data []byte) (string, error) {
+	relPath := mediaObjectPath(sessionID, evt)
+
+	fullPath := filepath.Join(msm.mediaSaveDir, filepath.FromSlash(relPath))
+	dir := filepath.Dir(fullPath)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create media directory: %w", err)
+	}
+
+	// Write to a temporary file first so readers never see a partial file
+	tmpFile, err := os.CreateTemp(dir, ".media-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create media file: %w", err)
+	}
+
+	if _, err := tmpFile.Write(data); err != nil {
+		tmpFile.Close()
+		os.Remove(tmpFile.Name())
+		return "", fmt.Errorf("failed to write media file: %w", err)
+	}
+
+	if err := tmpFile.Close(); err != nil {
+		os.Remove(tmpFile.Name())
+		return "", fmt.Errorf("failed to write media file: %w", err)
+	}
+
+	if err := os.Rename(tmpFile.Name(), fullPath); err != nil {
+		os.Remove(tmpFile.Name())
+		return "", fmt.Errorf("failed to move media file into place: %w", err)
+	}
+
+	return relPath, nil
+}
+
+// mediaFileURL builds the static link of a saved media file
+func (msm *MultiSessionManager) mediaFileURL(relPath string) string {
+	return fmt.Sprintf("%s/api/v1/media/files/%s", msm.publicURL, relPath)
+}
+
+// safePathComponent makes a sender-controlled value usable as a single path element
+func safePathComponent(value string) string {
+	value = strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r < 0x20 {
+			return '_'
+		}
+		return r
+	}, value)
+
+	if value == "" || value == "." || value == ".." {
+		return "_"
+	}
+	return value
+}
+
+// commonMediaExtensions pins the extension of the MIME types WhatsApp uses,
+// where the system MIME table would pick an unusual one
+var commonMediaExtensions = map[string]string{
+	"image/jpeg":      ".jpg",
+	"image/png":       ".png",
+	"image/webp":      ".webp",
+	"video/mp4":       ".mp4",
+	"audio/ogg":       ".ogg",
+	"audio/mpeg":      ".mp3",
+	"audio/mp4":       ".m4a",
+	"application/pdf": ".pdf",
+}
+
+// mediaExtension returns the file extension for a MIME type, ".bin" if unknown
+func mediaExtension(mimeType string) string {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return ".bin"
+	}
+
+	if ext, ok := commonMediaExtensions[mediaType]; ok {
+		return ext
+	}
+	if exts, err := mime.ExtensionsByType(mediaType); err == nil && len(exts) > 0 {
+		return exts[0]
+	}
+	return ".bin"
+}
+
+// mediaURL builds the download link for a stored media message
+func (msm *MultiSessionManager) mediaURL(sessionID domain.SessionID, messageID string) string {
+	return fmt.Sprintf("%s/api/v1/message/%s/media/%s", msm.publicURL, sessionID, messageID)
+}
+
+// DownloadMediaReference downloads and decrypts media from the reference
+// delivered in a message event, without the message being stored
+func (msm *MultiSessionManager) DownloadMediaReference(ctx context.Context, sessionID domain.SessionID, ref domain.MediaReference) ([]byte, error) {
+	if ref.DirectPath == "" || len(ref.MediaKey) == 0 || len(ref.FileEncSHA256) == 0 || len(ref.FileSHA256) == 0 {
+		return nil, domain.NewValidationError("direct_path, media_key, file_sha256 and file_enc_sha256 are required")
+	}
+
+	client, err := msm.GetClient(sessionID)
+	if err != nil || !client.IsConnected() {
+		return nil, domain.NewBusinessError("session is not connected")
+	}
+
+	data, err := client.DownloadMediaWithPath(
+		ctx,
+		ref.DirectPath,
+		ref.FileEncSHA256,
+		ref.FileSHA256,
+		ref.MediaKey,
+		int(ref.FileLength),
+		mediaTypeFor(ref.MediaType),
+		"",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download media: %w", err)
+	}
+
+	return data, nil
+}
+
+// DownloadMedia downloads and decrypts the media of a stored message,
+// returning its bytes and MIME type
+func (msm *MultiSessionManager) DownloadMedia(ctx context.Context, sessionID domain.SessionID, messageID string) ([]byte, string, error) {
+	client, err := msm.GetClient(sessionID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	message, err := msm.messageRepo.GetByID(ctx, sessionID, messageID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if !message.HasMedia() {
+		return nil, "", domain.NewBusinessError("message has no media")
+	}
+
+	data, err := client.DownloadMediaWithPath(
+		ctx,
+		message.DirectPath,
+		message.FileEncSHA256,
+		message.FileSHA256,
+		message.MediaKey,
+		int(message.FileLength),
+		mediaTypeFor(message.Type),
+		"",
+	)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to download media: %w", err)
+	}
+
+	return data, message.MimeType, nil
+}
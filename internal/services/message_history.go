@@ -0,0 +1,83 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"wazmeow/internal/domain"
+
+	"github.com/rs/zerolog/log"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+const (
+	// storeMessageTimeout bounds persisting a single received message
+	storeMessageTimeout = 10 * time.Second
+
+	// DefaultHistoryLimit is the page size of chat history when none is given
+	DefaultHistoryLimit = 50
+	// MaxHistoryLimit caps the page size of chat history
+	MaxHistoryLimit = 200
+)
+
+// storeMessage persists a received message so it can be looked up, quoted
+// and have its media downloaded later. Protocol messages (edits, revokes,
+// key shares) are not conversation content and are skipped.
+func (msm *MultiSessionManager) storeMessage(sessionID domain.SessionID, evt *events.Message) {
+	if evt.Message == nil || evt.Message.GetProtocolMessage() != nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), storeMessageTimeout)
+	defer cancel()
+
+	if err := msm.messageRepo.Save(ctx, newStoredMessage(sessionID, evt)); err != nil {
+		log.Error().Err(err).Str("session_id", sessionID.String()).Str("message_id", evt.Info.ID).Msg("Failed to store message")
+	}
+}
+
+// GetMessage returns a stored message of a session together with the
+// message it quotes, when that one is stored too
+func (msm *MultiSessionManager) GetMessage(ctx context.Context, sessionID domain.SessionID, messageID string) (*domain.Message, *domain.Message, error) {
+	message, err := msm.messageRepo.GetByID(ctx, sessionID, messageID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if message.QuotedMessageID == "" {
+		return message, nil, nil
+	}
+
+	quoted, err := msm.messageRepo.GetByID(ctx, sessionID, message.QuotedMessageID)
+	if err != nil {
+		if _, ok := err.(*domain.NotFoundError); !ok {
+			log.Warn().
+				Err(err).
+				Str("session_id", sessionID.String()).
+				Str("message_id", message.QuotedMessageID).
+				Msg("Failed to load quoted message")
+		}
+		return message, nil, nil
+	}
+
+	return message, quoted, nil
+}
+
+// ListChatMessages returns a page of the stored messages of a chat, newest
+// first, starting after the (before, beforeID) cursor of the previous page
+// (from the newest when before is zero). The limit is capped at
+// MaxHistoryLimit.
+func (msm *MultiSessionManager) ListChatMessages(ctx context.Context, sessionID domain.SessionID, chat string, limit int, before time.Time, beforeID string) ([]*domain.Message, error) {
+	if _, err := msm.sessionRepo.GetByID(ctx, sessionID); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = DefaultHistoryLimit
+	}
+	if limit > MaxHistoryLimit {
+		limit = MaxHistoryLimit
+	}
+
+	return msm.messageRepo.ListByChat(ctx, sessionID, chat, limit, before, beforeID)
+}
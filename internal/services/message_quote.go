@@ -0,0 +1,65 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"wazmeow/internal/domain"
+
+	"github.com/rs/zerolog/log"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"google.golang.org/protobuf/proto"
+)
+
+// QuoteMessage makes an outbound message a reply to the message quotedID of
+// chat. The quoted author is quotedSender when given, else the sender of the
+// stored message, else the chat itself in a direct chat. The quoted body is
+// the stored text or caption, or empty when the message is not stored.
+func (msm *MultiSessionManager) QuoteMessage(ctx context.Context, sessionID domain.SessionID, client *whatsmeow.Client, chat types.JID, msg *waE2E.Message, quotedID string, quotedSender types.JID) error {
+	ctxInfo := outboundContextInfo(msg)
+	if ctxInfo == nil {
+		return fmt.Errorf("this message type cannot quote another message")
+	}
+
+	stored, err := msm.messageRepo.GetByID(ctx, sessionID, quotedID)
+	if err != nil {
+		if _, ok := err.(*domain.NotFoundError); !ok {
+			log.Warn().
+				Err(err).
+				Str("session_id", sessionID.String()).
+				Str("message_id", quotedID).
+				Msg("Failed to load quoted message")
+		}
+		stored = nil
+	}
+
+	if quotedSender.IsEmpty() {
+		switch {
+		case stored != nil && stored.FromMe && client.Store.ID != nil:
+			quotedSender = client.Store.ID.ToNonAD()
+		case stored != nil && stored.SenderJID != "":
+			if quotedSender, err = types.ParseJID(stored.SenderJID); err != nil {
+				return fmt.Errorf("stored sender of quoted message is invalid: %w", err)
+			}
+		case chat.Server != types.GroupServer:
+			quotedSender = chat
+		default:
+			return fmt.Errorf("quoted_phone is required to quote a group message that is not stored")
+		}
+	}
+
+	var body string
+	if stored != nil {
+		body = stored.Body
+		if body == "" {
+			body = stored.Caption
+		}
+	}
+
+	ctxInfo.StanzaID = proto.String(quotedID)
+	ctxInfo.Participant = proto.String(quotedSender.ToNonAD().String())
+	ctxInfo.QuotedMessage = &waE2E.Message{Conversation: proto.String(body)}
+	return nil
+}
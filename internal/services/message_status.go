@@ -0,0 +1,234 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"wazmeow/internal/domain"
+
+	"github.com/rs/zerolog/log"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// DeliveryMetrics are the counts of messages a session sent in a time window.
+// Each message is counted once, under the furthest status it reached.
+type DeliveryMetrics struct {
+	SessionID domain.SessionID                                    `json:"session_id"`
+	From      time.Time                                           `json:"from"`
+	To        time.Time                                           `json:"to"`
+	Total     int                                                 `json:"total"`
+	ByStatus  map[domain.MessageStatus]int                        `json:"by_status"`
+	ByType    map[domain.MessageType]map[domain.MessageStatus]int `json:"by_type"`
+	SendQuota *SendQuotaUsage                                     `json:"send_quota,omitempty"`
+}
+
+// SendMessage sends a message with the session's default disappearing timer
+// applied and records its delivery status for metrics. Every send counts
+// against the session's daily send quota, failing with a *SendQuotaError
+// once it is used up; sends that never reach WhatsApp are given back.
+func (msm *MultiSessionManager) SendMessage(ctx context.Context, sessionID domain.SessionID, client *whatsmeow.Client, recipient types.JID, msg *waE2E.Message, messageID string) (whatsmeow.SendResponse, error) {
+	usage, err := msm.reserveSendOrFailOpen(ctx, sessionID)
+	if err != nil {
+		return whatsmeow.SendResponse{}, err
+	}
+
+	resp, err := msm.sendReserved(ctx, sessionID, client, recipient, msg, messageID)
+	if err != nil && usage != nil && !sendReachedWhatsApp(err) {
+		msm.releaseSend(sessionID, usage)
+	}
+	return resp, err
+}
+
+// reserveSendOrFailOpen counts a send against the daily send quota. Only a
+// *SendQuotaError is returned, sends go through when the quota cannot be
+// checked, with a nil usage.
+func (msm *MultiSessionManager) reserveSendOrFailOpen(ctx context.Context, sessionID domain.SessionID) (*SendQuotaUsage, error) {
+	usage, err := msm.reserveSend(ctx, sessionID)
+	var quotaErr *SendQuotaError
+	if errors.As(err, &quotaErr) {
+		return nil, err
+	} else if err != nil {
+		log.Warn().Err(err).Str("session_id", sessionID.String()).Msg("Failed to count send against daily quota")
+		return nil, nil
+	}
+	return usage, nil
+}
+
+// sendReserved sends a message already counted against the daily send quota
+func (msm *MultiSessionManager) sendReserved(ctx context.Context, sessionID domain.SessionID, client *whatsmeow.Client, recipient types.JID, msg *waE2E.Message, messageID string) (whatsmeow.SendResponse, error) {
+	msm.applyDefaultEphemeral(sessionID, recipient, msg)
+	resp, err := client.SendMessage(ctx, recipient, msg, whatsmeow.SendRequestExtra{ID: messageID})
+	if err != nil && recipient.Server == types.GroupServer && isGroupNotSyncedError(err) {
+		resp, err = msm.retryGroupSend(ctx, sessionID, client, recipient, msg, messageID, err)
+	}
+	msm.recordSentMessage(sessionID, recipient, msg, messageID, resp, err)
+	return resp, err
+}
+
+// isGroupNotSyncedError reports whether a group send failed because the group's
+// metadata or participant sessions were not synced yet, as happens right after connect
+func isGroupNotSyncedError(err error) bool {
+	return errors.Is(err, whatsmeow.ErrNoSession) || errors.Is(err, whatsmeow.ErrGroupNotFound)
+}
+
+// retryGroupSend fetches the group info once to refresh whatsmeow's group cache
+// and sends again with the same message ID
+func (msm *MultiSessionManager) retryGroupSend(ctx context.Context, sessionID domain.SessionID, client *whatsmeow.Client, group types.JID, msg *waE2E.Message, messageID string, sendErr error) (whatsmeow.SendResponse, error) {
+	log.Warn().
+		Err(sendErr).
+		Str("session_id", sessionID.String()).
+		Str("group", group.String()).
+		Str("message_id", messageID).
+		Msg("Group send failed before group metadata synced, refreshing group info and retrying")
+
+	if _, err := client.GetGroupInfo(group); err != nil {
+		log.Error().
+			Err(err).
+			Str("session_id", sessionID.String()).
+			Str("group", group.String()).
+			Msg("Failed to refresh group info for send retry")
+		return whatsmeow.SendResponse{}, sendErr
+	}
+
+	return client.SendMessage(ctx, group, msg, whatsmeow.SendRequestExtra{ID: messageID})
+}
+
+// recordSentMessage stores the outcome of a send. Only metadata is kept, the
+// content of outgoing messages is never persisted.
+func (msm *MultiSessionManager) recordSentMessage(sessionID domain.SessionID, recipient types.JID, msg *waE2E.Message, messageID string, resp whatsmeow.SendResponse, sendErr error) {
+	stored := &domain.Message{
+		ID:        messageID,
+		SessionID: sessionID,
+		ChatJID:   recipient.String(),
+		FromMe:    true,
+		Type:      detectMessageType(msg),
+		Status:    domain.MessageStatusSent,
+		Timestamp: resp.Timestamp,
+	}
+	if sendErr != nil {
+		stored.Status = domain.MessageStatusFailed
+		stored.Timestamp = time.Now()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := msm.messageRepo.Save(ctx, stored); err != nil {
+		return
+	}
+	// A retried send with the same ID is already stored as failed
+	if stored.Status == domain.MessageStatusSent {
+		_ = msm.messageRepo.AdvanceStatus(ctx, sessionID, []string{messageID}, domain.MessageStatusSent)
+	}
+}
+
+// recordReceiptStatus advances the stored status of the messages a receipt covers
+func (msm *MultiSessionManager) recordReceiptStatus(sessionID domain.SessionID, evt *events.Receipt) {
+	var status domain.MessageStatus
+	switch evt.Type {
+	case types.ReceiptTypeDelivered:
+		status = domain.MessageStatusDelivered
+	case types.ReceiptTypeRead:
+		status = domain.MessageStatusRead
+	case types.ReceiptTypePlayed:
+		status = domain.MessageStatusPlayed
+	default:
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := msm.messageRepo.AdvanceStatus(ctx, sessionID, evt.MessageIDs, status); err != nil {
+		log.Debug().Err(err).Str("session_id", sessionID.String()).Msg("Receipt status not recorded")
+	}
+}
+
+// GetDeliveryMetrics counts the messages a session sent in [from, to)
+func (msm *MultiSessionManager) GetDeliveryMetrics(ctx context.Context, sessionID domain.SessionID, from, to time.Time) (*DeliveryMetrics, error) {
+	if _, err := msm.sessionRepo.GetByID(ctx, sessionID); err != nil {
+		return nil, err
+	}
+
+	counts, err := msm.messageRepo.CountByStatus(ctx, sessionID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	metrics := &DeliveryMetrics{
+		SessionID: sessionID,
+		From:      from,
+		To:        to,
+		ByStatus:  make(map[domain.MessageStatus]int),
+		ByType:    make(map[domain.MessageType]map[domain.MessageStatus]int),
+	}
+	for _, c := range counts {
+		metrics.Total += c.Count
+		metrics.ByStatus[c.Status] += c.Count
+		if metrics.ByType[c.Type] == nil {
+			metrics.ByType[c.Type] = make(map[domain.MessageStatus]int)
+		}
+		metrics.ByType[c.Type][c.Status] += c.Count
+	}
+
+	if usage, err := msm.GetSendQuotaUsage(ctx, sessionID); err != nil {
+		log.Warn().Err(err).Str("session_id", sessionID.String()).Msg("Failed to get daily send quota usage")
+	} else {
+		metrics.SendQuota = usage
+	}
+
+	return metrics, nil
+}
+
+// PriorSend is an earlier send of the session that used a message ID
+type PriorSend struct {
+	MessageID string    `json:"message_id"`
+	Status    string    `json:"status"` // stored delivery status, or "queued" while its job is pending
+	Recipient string    `json:"recipient"`
+	Timestamp time.Time `json:"timestamp"`
+	JobID     string    `json:"job_id,omitempty"`
+}
+
+// FindPriorSend returns the earlier send of the session that used messageID,
+// or nil when the ID is free. A send that failed does not hold on to its ID,
+// so it can be retried with the same one.
+func (msm *MultiSessionManager) FindPriorSend(ctx context.Context, sessionID domain.SessionID, messageID string) (*PriorSend, error) {
+	js := msm.jobs
+	js.mutex.Lock()
+	for _, job := range js.jobs {
+		if job.SessionID == sessionID && job.MessageID == messageID {
+			prior := &PriorSend{
+				MessageID: messageID,
+				Status:    "queued",
+				Recipient: job.Recipient.String(),
+				Timestamp: job.EnqueuedAt,
+				JobID:     job.ID,
+			}
+			js.mutex.Unlock()
+			return prior, nil
+		}
+	}
+	js.mutex.Unlock()
+
+	stored, err := msm.messageRepo.GetByID(ctx, sessionID, messageID)
+	if err != nil {
+		if _, ok := err.(*domain.NotFoundError); ok {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !stored.FromMe || stored.Status == "" || stored.Status == domain.MessageStatusFailed {
+		return nil, nil
+	}
+
+	return &PriorSend{
+		MessageID: messageID,
+		Status:    string(stored.Status),
+		Recipient: stored.ChatJID,
+		Timestamp: stored.Timestamp,
+	}, nil
+}
@@ -0,0 +1,183 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	"wazmeow/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// offlineSendTTL is how long a send held for an offline session waits for it to reconnect
+	offlineSendTTL = 5 * time.Minute
+	// maxOfflineSendsPerSession bounds the sends held for a single offline session
+	maxOfflineSendsPerSession = 100
+	// offlineSweepInterval is how often expired held sends are reported
+	offlineSweepInterval = 30 * time.Second
+)
+
+// offlineSendBuffer holds sends for sessions that are reconnecting until
+// they come back online or the sends expire
+type offlineSendBuffer struct {
+	jobs  map[domain.SessionID][]*SendJob
+	mutex sync.Mutex
+}
+
+func newOfflineSendBuffer() *offlineSendBuffer {
+	return &offlineSendBuffer{
+		jobs: make(map[domain.SessionID][]*SendJob),
+	}
+}
+
+// EnqueueOfflineSend holds a message until its session reconnects and returns its job ID.
+// The job is handed to the send queue on reconnect, or reported as expired after offlineSendTTL.
+func (msm *MultiSessionManager) EnqueueOfflineSend(job *SendJob) (string, error) {
+	job.ID = uuid.New().String()
+	job.Type = JobTypeOfflineSend
+	job.EnqueuedAt = time.Now()
+	job.ExpiresAt = job.EnqueuedAt.Add(offlineSendTTL)
+	if job.Priority == "" {
+		job.Priority = SendPriorityNormal
+	}
+
+	msm.jobs.add(job)
+
+	ob := msm.offlineSends
+	ob.mutex.Lock()
+	if len(ob.jobs[job.SessionID]) >= maxOfflineSendsPerSession {
+		ob.mutex.Unlock()
+		msm.jobs.take(job.ID)
+		return "", domain.NewBusinessError("offline send queue is full")
+	}
+	ob.jobs[job.SessionID] = append(ob.jobs[job.SessionID], job)
+	ob.mutex.Unlock()
+
+	log.Debug().
+		Str("session_id", job.SessionID.String()).
+		Str("job_id", job.ID).
+		Str("message_id", job.MessageID).
+		Time("expires_at", job.ExpiresAt).
+		Msg("Send job held until session reconnects")
+
+	return job.ID, nil
+}
+
+// remove drops a held send, reporting whether it was found
+func (ob *offlineSendBuffer) remove(sessionID domain.SessionID, jobID string) bool {
+	ob.mutex.Lock()
+	defer ob.mutex.Unlock()
+
+	jobs := ob.jobs[sessionID]
+	for i, job := range jobs {
+		if job.ID == jobID {
+			ob.jobs[sessionID] = append(jobs[:i], jobs[i+1:]...)
+			if len(ob.jobs[sessionID]) == 0 {
+				delete(ob.jobs, sessionID)
+			}
+			return true
+		}
+	}
+	return false
+}
+
+// releaseOfflineSends moves the sends held for a session to the send queue once it reconnects
+func (msm *MultiSessionManager) releaseOfflineSends(sessionID domain.SessionID) {
+	ob := msm.offlineSends
+	ob.mutex.Lock()
+	jobs := ob.jobs[sessionID]
+	delete(ob.jobs, sessionID)
+	ob.mutex.Unlock()
+
+	if len(jobs) == 0 {
+		return
+	}
+
+	log.Info().
+		Str("session_id", sessionID.String()).
+		Int("count", len(jobs)).
+		Msg("Releasing sends held while session was offline")
+
+	now := time.Now()
+	for _, job := range jobs {
+		if now.After(job.ExpiresAt) {
+			go msm.expireOfflineSend(job)
+			continue
+		}
+
+		if !msm.queueSendJob(job) {
+			go msm.failSendJob(job, domain.NewBusinessError("send queue is full"))
+		}
+	}
+}
+
+// startOfflineSweeper periodically reports held sends whose session did not reconnect in time
+func (msm *MultiSessionManager) startOfflineSweeper() {
+	go func() {
+		ticker := time.NewTicker(offlineSweepInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				for _, job := range msm.takeExpiredOfflineSends(time.Now()) {
+					msm.expireOfflineSend(job)
+				}
+			case <-msm.shutdown:
+				return
+			}
+		}
+	}()
+}
+
+// takeExpiredOfflineSends removes and returns the held sends that expired before now
+func (msm *MultiSessionManager) takeExpiredOfflineSends(now time.Time) []*SendJob {
+	ob := msm.offlineSends
+	ob.mutex.Lock()
+	defer ob.mutex.Unlock()
+
+	var expired []*SendJob
+	for sessionID, jobs := range ob.jobs {
+		kept := jobs[:0]
+		for _, job := range jobs {
+			if now.After(job.ExpiresAt) {
+				expired = append(expired, job)
+			} else {
+				kept = append(kept, job)
+			}
+		}
+
+		if len(kept) == 0 {
+			delete(ob.jobs, sessionID)
+		} else {
+			ob.jobs[sessionID] = kept
+		}
+	}
+
+	return expired
+}
+
+// expireOfflineSend reports a held send whose session did not reconnect in time
+func (msm *MultiSessionManager) expireOfflineSend(job *SendJob) {
+	if !msm.jobs.take(job.ID) {
+		return
+	}
+
+	log.Warn().
+		Str("session_id", job.SessionID.String()).
+		Str("job_id", job.ID).
+		Msg("Held send expired before session reconnected")
+
+	msm.deliverSendResult(domain.SendResultEvent{
+		SessionID: job.SessionID,
+		EventType: domain.EventTypeSendResult,
+		JobID:     job.ID,
+		MessageID: job.MessageID,
+		Phone:     job.Phone,
+		Status:    "expired",
+		Error:     "session did not reconnect before the send expired",
+		Timestamp: time.Now(),
+	})
+}
@@ -0,0 +1,48 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"go.mau.fi/whatsmeow"
+)
+
+const (
+	defaultPairClient  = whatsmeow.PairClientChrome
+	defaultPairDisplay = "Chrome (Linux)"
+)
+
+// pairClients maps the configurable client names to whatsmeow's client types
+var pairClients = map[string]whatsmeow.PairClientType{
+	"chrome":   whatsmeow.PairClientChrome,
+	"edge":     whatsmeow.PairClientEdge,
+	"firefox":  whatsmeow.PairClientFirefox,
+	"ie":       whatsmeow.PairClientIE,
+	"opera":    whatsmeow.PairClientOpera,
+	"safari":   whatsmeow.PairClientSafari,
+	"electron": whatsmeow.PairClientElectron,
+	"uwp":      whatsmeow.PairClientUWP,
+	"other":    whatsmeow.PairClientOtherWebClient,
+}
+
+// pairDisplayPattern is the `Browser (OS)` form WhatsApp requires for the display name
+var pairDisplayPattern = regexp.MustCompile(`^[^()]+ \([^()]+\)$`)
+
+// SetPairClient sets the client type and display name phone pairing reports
+// to WhatsApp, e.g. "edge" and "Edge (Windows)"
+func (msm *MultiSessionManager) SetPairClient(client, display string) error {
+	clientType, ok := pairClients[strings.ToLower(client)]
+	if !ok {
+		return fmt.Errorf("invalid pair client: %s", client)
+	}
+	if !pairDisplayPattern.MatchString(display) {
+		return fmt.Errorf("invalid pair display %q, expected the form \"Browser (OS)\"", display)
+	}
+
+	msm.mutex.Lock()
+	defer msm.mutex.Unlock()
+	msm.pairClient = clientType
+	msm.pairDisplay = display
+	return nil
+}
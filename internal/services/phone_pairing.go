@@ -0,0 +1,155 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"wazmeow/internal/domain"
+
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+// phonePairTimeout is how long a phone linking code can be entered on the
+// phone before the pairing is reported as timed out
+const phonePairTimeout = 3 * time.Minute
+
+// PairState is the progress of a session's pairing
+type PairState string
+
+const (
+	PairStateNone      PairState = "none"      // no phone pairing was started
+	PairStatePending   PairState = "pending"   // waiting for the code to be entered on the phone
+	PairStateCompleted PairState = "completed" // the phone accepted the code
+	PairStateFailed    PairState = "failed"    // the phone accepted the code but pairing could not be finished
+	PairStateTimedOut  PairState = "timed_out" // the code was not entered in time
+)
+
+// phonePairing tracks a linking code handed out by PairPhone
+type phonePairing struct {
+	phone       string
+	startedAt   time.Time
+	state       PairState
+	completedAt time.Time
+	jid         string
+	err         string
+}
+
+// PairStatus reports whether a session's phone pairing completed
+type PairStatus struct {
+	SessionID   domain.SessionID `json:"session_id"`
+	State       PairState        `json:"state"`
+	Phone       string           `json:"phone,omitempty"`
+	StartedAt   *time.Time       `json:"started_at,omitempty"`
+	ExpiresAt   *time.Time       `json:"expires_at,omitempty"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty"`
+	JID         string           `json:"jid,omitempty"`
+	Error       string           `json:"error,omitempty"`
+}
+
+// GetPairStatus reports the progress of the last phone pairing of a session.
+// A session paired by any other means reports completed, one that never
+// started a phone pairing reports none.
+func (msm *MultiSessionManager) GetPairStatus(ctx context.Context, sessionID domain.SessionID) (*PairStatus, error) {
+	session, err := msm.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &PairStatus{SessionID: sessionID, State: PairStateNone}
+
+	msm.mutex.RLock()
+	var pairing *phonePairing
+	if sessionClient, exists := msm.sessions[sessionID]; exists && sessionClient.phonePair != nil {
+		copied := *sessionClient.phonePair
+		pairing = &copied
+	}
+	msm.mutex.RUnlock()
+
+	if pairing == nil {
+		if session.WAJID != "" {
+			status.State = PairStateCompleted
+			status.JID = session.WAJID
+		}
+		return status, nil
+	}
+
+	expiresAt := pairing.startedAt.Add(phonePairTimeout)
+	status.State = pairing.state
+	status.Phone = pairing.phone
+	status.StartedAt = &pairing.startedAt
+	status.JID = pairing.jid
+	status.Error = pairing.err
+
+	switch pairing.state {
+	case PairStatePending:
+		if time.Now().After(expiresAt) {
+			status.State = PairStateTimedOut
+		} else {
+			status.ExpiresAt = &expiresAt
+		}
+	case PairStateCompleted, PairStateFailed:
+		status.CompletedAt = &pairing.completedAt
+	}
+
+	return status, nil
+}
+
+// startPhonePairing records a linking code handed out for a session,
+// replacing any earlier attempt
+func (msm *MultiSessionManager) startPhonePairing(sessionID domain.SessionID, phone string) {
+	msm.mutex.Lock()
+	defer msm.mutex.Unlock()
+
+	if sessionClient, exists := msm.sessions[sessionID]; exists {
+		sessionClient.phonePair = &phonePairing{
+			phone:     phone,
+			startedAt: time.Now(),
+			state:     PairStatePending,
+		}
+	}
+}
+
+// finishPairing records the outcome of a pairing on a pending phone pairing
+// and emits it as a pair_success or pair_error notification, for QR code and
+// phone code pairing alike
+func (msm *MultiSessionManager) finishPairing(sessionID domain.SessionID, sessionClient *SessionClient, evt any) {
+	event := domain.NotificationEvent{
+		SessionID: sessionID,
+		EventType: domain.EventTypeNotification,
+		Timestamp: time.Now(),
+	}
+	content := map[string]any{}
+
+	state := PairStateCompleted
+	switch v := evt.(type) {
+	case *events.PairSuccess:
+		event.Type = "pair_success"
+		content["jid"] = v.ID.String()
+		content["platform"] = v.Platform
+		content["business_name"] = v.BusinessName
+	case *events.PairError:
+		state = PairStateFailed
+		event.Type = "pair_error"
+		content["jid"] = v.ID.String()
+		if v.Error != nil {
+			content["error"] = v.Error.Error()
+		}
+	default:
+		return
+	}
+
+	msm.mutex.Lock()
+	method := "qr"
+	if pairing := sessionClient.phonePair; pairing != nil && pairing.state == PairStatePending {
+		method = "phone"
+		pairing.state = state
+		pairing.completedAt = event.Timestamp
+		pairing.jid, _ = content["jid"].(string)
+		pairing.err, _ = content["error"].(string)
+	}
+	msm.mutex.Unlock()
+
+	content["method"] = method
+	event.Content = content
+	sessionClient.deliveries.submit("", func() { msm.emitEvent(event) })
+}
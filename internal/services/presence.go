@@ -0,0 +1,93 @@
+package services
+
+import (
+	"context"
+	"fmt"
+
+	"wazmeow/internal/domain"
+
+	"github.com/rs/zerolog/log"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// SendChatPresence shows the session typing (composing), recording a voice
+// message (recording) or neither (paused) in a chat
+func (msm *MultiSessionManager) SendChatPresence(sessionID domain.SessionID, client *whatsmeow.Client, chat types.JID, state domain.PresenceType) error {
+	var (
+		presence types.ChatPresence
+		media    = types.ChatPresenceMediaText
+	)
+	switch state {
+	case domain.PresenceTypeComposing:
+		presence = types.ChatPresenceComposing
+	case domain.PresenceTypeRecording:
+		presence = types.ChatPresenceComposing
+		media = types.ChatPresenceMediaAudio
+	case domain.PresenceTypePaused:
+		presence = types.ChatPresencePaused
+	default:
+		return domain.NewValidationError(fmt.Sprintf("invalid chat presence: %s (use composing, recording or paused)", state))
+	}
+
+	if err := client.SendChatPresence(chat, presence, media); err != nil {
+		return fmt.Errorf("failed to send chat presence: %w", err)
+	}
+
+	log.Debug().
+		Str("session_id", sessionID.String()).
+		Str("chat", chat.String()).
+		Str("state", string(state)).
+		Msg("Chat presence sent")
+
+	return nil
+}
+
+// SendPresence marks the session available or unavailable to its contacts.
+// A session that does not broadcast its presence is marked unavailable again
+// on the next connect.
+func (msm *MultiSessionManager) SendPresence(ctx context.Context, sessionID domain.SessionID, state domain.PresenceType) error {
+	var presence types.Presence
+	switch state {
+	case domain.PresenceTypeAvailable:
+		presence = types.PresenceAvailable
+	case domain.PresenceTypeUnavailable:
+		presence = types.PresenceUnavailable
+	default:
+		return domain.NewValidationError(fmt.Sprintf("invalid presence: %s (use available or unavailable)", state))
+	}
+
+	if _, err := msm.sessionRepo.GetByID(ctx, sessionID); err != nil {
+		return err
+	}
+
+	client, err := msm.GetClient(sessionID)
+	if err != nil || !client.IsConnected() || !client.IsLoggedIn() {
+		return domain.NewBusinessError("session is not connected")
+	}
+
+	if err := client.SendPresence(presence); err != nil {
+		return fmt.Errorf("failed to send presence: %w", err)
+	}
+
+	log.Info().
+		Str("session_id", sessionID.String()).
+		Str("presence", string(presence)).
+		Msg("Presence sent")
+
+	return nil
+}
+
+// RefreshPresence sends an available presence right away for a session that
+// broadcasts its presence, restarting the time it shows as online
+func (msm *MultiSessionManager) RefreshPresence(ctx context.Context, sessionID domain.SessionID) error {
+	session, err := msm.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if !session.BroadcastPresence {
+		return domain.NewBusinessError("session does not broadcast its presence")
+	}
+
+	return msm.SendPresence(ctx, sessionID, domain.PresenceTypeAvailable)
+}
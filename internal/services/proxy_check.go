@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"wazmeow/internal/domain"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// defaultProxyCheckURL is requested through a proxy to test it
+	defaultProxyCheckURL = "https://web.whatsapp.com"
+	// proxyCheckTimeout bounds a proxy test request
+	proxyCheckTimeout = 10 * time.Second
+)
+
+// ProxyCheckResult reports whether a session's stored proxy can reach the check URL
+type ProxyCheckResult struct {
+	SessionID  domain.SessionID `json:"session_id"`
+	ProxyURL   string           `json:"proxy_url"` // credentials are redacted
+	CheckURL   string           `json:"check_url"`
+	Reachable  bool             `json:"reachable"`
+	StatusCode int              `json:"status_code,omitempty"`
+	LatencyMS  int64            `json:"latency_ms"`
+	Error      string           `json:"error,omitempty"`
+}
+
+// SetProxyCheckURL sets the URL requested through a proxy to test it
+func (msm *MultiSessionManager) SetProxyCheckURL(checkURL string) {
+	if checkURL != "" {
+		msm.proxyCheckURL = checkURL
+	}
+}
+
+// TestProxy requests the check URL through the session's stored proxy and
+// reports reachability and latency. The WhatsApp session is not connected.
+func (msm *MultiSessionManager) TestProxy(ctx context.Context, sessionID domain.SessionID) (*ProxyCheckResult, error) {
+	session, err := msm.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if session.ProxyURL == "" {
+		return nil, domain.NewBusinessError("session has no proxy configured")
+	}
+
+	proxyURL, err := url.Parse(session.ProxyURL)
+	if err != nil || proxyURL.Host == "" {
+		return nil, domain.NewValidationError("stored proxy URL is invalid")
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, domain.NewValidationError(fmt.Sprintf("unsupported proxy scheme: %s", proxyURL.Scheme))
+	}
+
+	result := &ProxyCheckResult{
+		SessionID: sessionID,
+		ProxyURL:  proxyURL.Redacted(),
+		CheckURL:  msm.proxyCheckURL,
+	}
+
+	client := &http.Client{
+		Timeout: proxyCheckTimeout,
+		Transport: &http.Transport{
+			Proxy:             http.ProxyURL(proxyURL),
+			DisableKeepAlives: true,
+		},
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, msm.proxyCheckURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build proxy check request: %w", err)
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	result.LatencyMS = time.Since(start).Milliseconds()
+
+	if err != nil {
+		result.Error = err.Error()
+	} else {
+		resp.Body.Close()
+		result.StatusCode = resp.StatusCode
+		// Any HTTP answer means the proxy relayed the request
+		result.Reachable = true
+	}
+
+	log.Info().
+		Str("session_id", sessionID.String()).
+		Str("proxy", result.ProxyURL).
+		Bool("reachable", result.Reachable).
+		Int64("latency_ms", result.LatencyMS).
+		Msg("Proxy tested")
+
+	return result, nil
+}
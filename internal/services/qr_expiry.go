@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"wazmeow/internal/domain"
+
+	"github.com/rs/zerolog/log"
+)
+
+// defaultQRRotation is how long a pairing QR code is considered valid when
+// WhatsApp does not say
+const defaultQRRotation = 20 * time.Second
+
+// ErrQRCodeExpired is returned when the stored QR code is past its expiry;
+// the code is cleared and a new one must be requested
+var ErrQRCodeExpired = errors.New("stored QR code expired")
+
+// QRCodeState is a QR code together with its validity window
+type QRCodeState struct {
+	Code        string
+	GeneratedAt time.Time
+	ExpiresAt   time.Time
+}
+
+// SetQRRotation sets how long a QR code is considered valid after it was
+// generated when WhatsApp does not report its timeout
+func (msm *MultiSessionManager) SetQRRotation(window time.Duration) {
+	if window > 0 {
+		msm.qrRotation = window
+	}
+}
+
+// storedQRCode returns the QR code stored for a session, or nil when none is
+// stored. A code past its expiry is cleared and ErrQRCodeExpired returned,
+// codes stored without an expiry count as expired.
+func (msm *MultiSessionManager) storedQRCode(ctx context.Context, session *domain.Session) (*QRCodeState, error) {
+	if session.QRCode == "" {
+		return nil, nil
+	}
+
+	now := time.Now()
+	if session.QRGeneratedAt != nil && session.QRExpiresAt != nil && now.Before(*session.QRExpiresAt) {
+		return &QRCodeState{
+			Code:        session.QRCode,
+			GeneratedAt: *session.QRGeneratedAt,
+			ExpiresAt:   *session.QRExpiresAt,
+		}, nil
+	}
+
+	// A code generated in the meantime has not expired yet and stays
+	if err := msm.sessionRepo.ClearExpiredQRCode(ctx, session.ID, now); err != nil {
+		return nil, err
+	}
+	log.Info().Str("session_id", session.ID.String()).Msg("Cleared expired QR code")
+
+	return nil, ErrQRCodeExpired
+}
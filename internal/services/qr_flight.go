@@ -0,0 +1,104 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// errQRGenerationEnded is reported to waiters when pairing stopped before any code was issued
+var errQRGenerationEnded = errors.New("QR code generation ended without a code")
+
+// qrFlight is the QR code generation of a session, shared by every caller
+// waiting for a code so the client is only paired once at a time
+type qrFlight struct {
+	cancel context.CancelFunc
+
+	mutex     sync.Mutex
+	code      string
+	issuedAt  time.Time
+	expiresAt time.Time
+	err       error
+	ended     bool
+	waiters   int
+
+	// changed is closed and replaced whenever a code is issued or generation ends
+	changed chan struct{}
+}
+
+func newQRFlight(cancel context.CancelFunc) *qrFlight {
+	return &qrFlight{
+		cancel:  cancel,
+		changed: make(chan struct{}),
+	}
+}
+
+// publish makes code the one handed to current and later waiters until it
+// expires
+func (f *qrFlight) publish(code string, expiresAt time.Time) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	f.code = code
+	f.issuedAt = time.Now()
+	f.expiresAt = expiresAt
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+// finish releases the waiters still waiting for a valid code, with err when
+// no code was ever issued
+func (f *qrFlight) finish(err error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.code == "" {
+		f.err = err
+	}
+	f.ended = true
+	close(f.changed)
+	f.changed = make(chan struct{})
+}
+
+// join registers a caller waiting for a code
+func (f *qrFlight) join() {
+	f.mutex.Lock()
+	f.waiters++
+	f.mutex.Unlock()
+}
+
+// current returns the latest code if it is still valid. Without one it
+// returns why no code can be handed out, or a channel closed on the next
+// change to wait on.
+func (f *qrFlight) current() (*QRCodeState, <-chan struct{}, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	switch {
+	case f.err != nil:
+		return nil, nil, f.err
+	case f.code != "" && time.Now().Before(f.expiresAt):
+		return &QRCodeState{Code: f.code, GeneratedAt: f.issuedAt, ExpiresAt: f.expiresAt}, nil, nil
+	case f.ended:
+		return nil, nil, ErrQRCodeExpired
+	default:
+		return nil, f.changed, nil
+	}
+}
+
+// leave unregisters a caller that got its result
+func (f *qrFlight) leave() {
+	f.mutex.Lock()
+	f.waiters--
+	f.mutex.Unlock()
+}
+
+// abandon unregisters a caller that stopped waiting and reports whether it
+// was the last one before any code was issued
+func (f *qrFlight) abandon() bool {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+	f.waiters--
+	return f.waiters == 0 && f.code == ""
+}
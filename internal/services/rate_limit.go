@@ -0,0 +1,93 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"wazmeow/internal/domain"
+
+	"github.com/rs/zerolog/log"
+	"go.mau.fi/whatsmeow"
+)
+
+const (
+	// baseCooldown is the cooldown applied after a first rate-limit error
+	baseCooldown = 30 * time.Second
+	// maxCooldown caps the cooldown when rate limits keep recurring
+	maxCooldown = 15 * time.Minute
+)
+
+// sessionCooldown tracks the send cooldown of a rate-limited session
+type sessionCooldown struct {
+	until    time.Time
+	duration time.Duration
+}
+
+// cooldownTracker keeps per-session send cooldowns after WhatsApp rate limits
+type cooldownTracker struct {
+	cooldowns map[domain.SessionID]*sessionCooldown
+	mutex     sync.Mutex
+}
+
+func newCooldownTracker() *cooldownTracker {
+	return &cooldownTracker{
+		cooldowns: make(map[domain.SessionID]*sessionCooldown),
+	}
+}
+
+// IsRateLimitError reports whether err is a rate-limit response from WhatsApp
+func IsRateLimitError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, whatsmeow.ErrIQRateOverLimit) {
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "rate-overlimit") || strings.Contains(msg, "status code 429")
+}
+
+// StartCooldown puts a session in cooldown after a rate-limit error and
+// returns its length. Rate limits hit shortly after a previous cooldown
+// double its length, up to maxCooldown.
+func (msm *MultiSessionManager) StartCooldown(sessionID domain.SessionID) time.Duration {
+	ct := msm.cooldowns
+	ct.mutex.Lock()
+	defer ct.mutex.Unlock()
+
+	now := time.Now()
+	duration := baseCooldown
+	if previous, exists := ct.cooldowns[sessionID]; exists && now.Before(previous.until.Add(previous.duration)) {
+		duration = previous.duration * 2
+		if duration > maxCooldown {
+			duration = maxCooldown
+		}
+	}
+
+	ct.cooldowns[sessionID] = &sessionCooldown{
+		until:    now.Add(duration),
+		duration: duration,
+	}
+
+	log.Warn().
+		Str("session_id", sessionID.String()).
+		Dur("cooldown", duration).
+		Msg("Session rate limited by WhatsApp, sends paused")
+
+	return duration
+}
+
+// CooldownRemaining returns how long sends for a session remain paused
+func (msm *MultiSessionManager) CooldownRemaining(sessionID domain.SessionID) time.Duration {
+	ct := msm.cooldowns
+	ct.mutex.Lock()
+	defer ct.mutex.Unlock()
+
+	cooldown, exists := ct.cooldowns[sessionID]
+	if !exists {
+		return 0
+	}
+	return time.Until(cooldown.until).Round(time.Second)
+}
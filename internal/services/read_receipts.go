@@ -0,0 +1,36 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"wazmeow/internal/domain"
+
+	"github.com/rs/zerolog/log"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// MarkMessagesRead sends read receipts for received messages of a chat, so
+// their sender sees them as read. In group chats sender must be the member
+// who sent them; messages of different members need one call each.
+func (msm *MultiSessionManager) MarkMessagesRead(sessionID domain.SessionID, client *whatsmeow.Client, chat, sender types.JID, messageIDs []types.MessageID) error {
+	if len(messageIDs) == 0 {
+		return domain.NewValidationError("at least one message ID is required")
+	}
+	if chat.Server == types.GroupServer && sender.IsEmpty() {
+		return domain.NewValidationError("sender is required for group chats")
+	}
+
+	if err := client.MarkRead(messageIDs, time.Now(), chat, sender); err != nil {
+		return fmt.Errorf("failed to send read receipt: %w", err)
+	}
+
+	log.Debug().
+		Str("session_id", sessionID.String()).
+		Str("chat", chat.String()).
+		Int("count", len(messageIDs)).
+		Msg("Messages marked as read")
+
+	return nil
+}
@@ -0,0 +1,245 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"wazmeow/internal/domain"
+
+	"github.com/rs/zerolog/log"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+)
+
+const (
+	// receiptTrackingTTL is how long receipts of a tracked message are kept
+	receiptTrackingTTL = 24 * time.Hour
+	// maxTrackedMessages bounds the number of messages tracked at once
+	maxTrackedMessages = 10000
+)
+
+// Receipt statuses of a tracked message, in the order they progress
+const (
+	ReceiptStatusSent      = "sent"
+	ReceiptStatusDelivered = "delivered"
+	ReceiptStatusRead      = "read"
+	ReceiptStatusPlayed    = "played"
+)
+
+var receiptStatusRank = map[string]int{
+	ReceiptStatusSent:      0,
+	ReceiptStatusDelivered: 1,
+	ReceiptStatusRead:      2,
+	ReceiptStatusPlayed:    3,
+}
+
+// ReceiptTransition is one receipt received for a tracked message
+type ReceiptTransition struct {
+	Status    string    `json:"status"`
+	From      string    `json:"from"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// TrackedMessage is the receipt state of a tracked message
+type TrackedMessage struct {
+	SessionID domain.SessionID    `json:"session_id"`
+	MessageID string              `json:"message_id"`
+	Status    string              `json:"status"`
+	Receipts  []ReceiptTransition `json:"receipts"`
+	TrackedAt time.Time           `json:"tracked_at"`
+}
+
+type trackedEntry struct {
+	token       string
+	state       TrackedMessage
+	subscribers map[chan ReceiptTransition]struct{}
+	expiresAt   time.Time
+}
+
+// receiptTracker keeps the receipts of messages sent with tracking enabled
+type receiptTracker struct {
+	entries map[string]*trackedEntry
+	mutex   sync.Mutex
+}
+
+func newReceiptTracker() *receiptTracker {
+	return &receiptTracker{
+		entries: make(map[string]*trackedEntry),
+	}
+}
+
+func trackingKey(sessionID domain.SessionID, messageID string) string {
+	return sessionID.String() + "/" + messageID
+}
+
+// TrackMessage starts collecting receipts for a message about to be sent and
+// returns the token needed to read them
+func (msm *MultiSessionManager) TrackMessage(sessionID domain.SessionID, messageID string) (string, error) {
+	tokenBytes := make([]byte, 16)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(tokenBytes)
+
+	rt := msm.receipts
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+
+	if len(rt.entries) >= maxTrackedMessages {
+		rt.evictExpired(time.Now())
+		if len(rt.entries) >= maxTrackedMessages {
+			return "", domain.NewBusinessError("too many tracked messages")
+		}
+	}
+
+	now := time.Now()
+	rt.entries[trackingKey(sessionID, messageID)] = &trackedEntry{
+		token: token,
+		state: TrackedMessage{
+			SessionID: sessionID,
+			MessageID: messageID,
+			Status:    ReceiptStatusSent,
+			Receipts:  []ReceiptTransition{},
+			TrackedAt: now,
+		},
+		subscribers: make(map[chan ReceiptTransition]struct{}),
+		expiresAt:   now.Add(receiptTrackingTTL),
+	}
+
+	return token, nil
+}
+
+// UntrackMessage stops tracking a message, used when its send failed
+func (msm *MultiSessionManager) UntrackMessage(sessionID domain.SessionID, messageID string) {
+	rt := msm.receipts
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+
+	key := trackingKey(sessionID, messageID)
+	if entry, exists := rt.entries[key]; exists {
+		for ch := range entry.subscribers {
+			close(ch)
+		}
+		delete(rt.entries, key)
+	}
+}
+
+// GetTrackedMessage returns the receipt state of a tracked message
+func (msm *MultiSessionManager) GetTrackedMessage(sessionID domain.SessionID, messageID, token string) (TrackedMessage, error) {
+	rt := msm.receipts
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+
+	entry, err := rt.lookup(sessionID, messageID, token)
+	if err != nil {
+		return TrackedMessage{}, err
+	}
+	return entry.snapshot(), nil
+}
+
+// SubscribeReceipts returns the current receipt state of a tracked message and
+// a channel of the receipts that follow. The cancel function must be called
+// once the caller stops reading.
+func (msm *MultiSessionManager) SubscribeReceipts(sessionID domain.SessionID, messageID, token string) (TrackedMessage, <-chan ReceiptTransition, func(), error) {
+	rt := msm.receipts
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+
+	entry, err := rt.lookup(sessionID, messageID, token)
+	if err != nil {
+		return TrackedMessage{}, nil, nil, err
+	}
+
+	ch := make(chan ReceiptTransition, 16)
+	entry.subscribers[ch] = struct{}{}
+
+	cancel := func() {
+		rt.mutex.Lock()
+		defer rt.mutex.Unlock()
+		if _, subscribed := entry.subscribers[ch]; subscribed {
+			delete(entry.subscribers, ch)
+			close(ch)
+		}
+	}
+
+	return entry.snapshot(), ch, cancel, nil
+}
+
+// recordReceipt applies an inbound receipt to the tracked messages it covers
+func (msm *MultiSessionManager) recordReceipt(sessionID domain.SessionID, evt *events.Receipt) {
+	var status string
+	switch evt.Type {
+	case types.ReceiptTypeDelivered:
+		status = ReceiptStatusDelivered
+	case types.ReceiptTypeRead:
+		status = ReceiptStatusRead
+	case types.ReceiptTypePlayed:
+		status = ReceiptStatusPlayed
+	default:
+		return
+	}
+
+	transition := ReceiptTransition{
+		Status:    status,
+		From:      evt.Sender.ToNonAD().String(),
+		Timestamp: evt.Timestamp,
+	}
+
+	rt := msm.receipts
+	rt.mutex.Lock()
+	defer rt.mutex.Unlock()
+
+	for _, messageID := range evt.MessageIDs {
+		entry, exists := rt.entries[trackingKey(sessionID, messageID)]
+		if !exists {
+			continue
+		}
+
+		entry.state.Receipts = append(entry.state.Receipts, transition)
+		if receiptStatusRank[status] > receiptStatusRank[entry.state.Status] {
+			entry.state.Status = status
+		}
+
+		for ch := range entry.subscribers {
+			select {
+			case ch <- transition:
+			default:
+				log.Warn().
+					Str("session_id", sessionID.String()).
+					Str("message_id", messageID).
+					Msg("Receipt subscriber too slow, dropping receipt")
+			}
+		}
+	}
+}
+
+// lookup finds a live tracked message, reporting a wrong token as not found
+func (rt *receiptTracker) lookup(sessionID domain.SessionID, messageID, token string) (*trackedEntry, error) {
+	entry, exists := rt.entries[trackingKey(sessionID, messageID)]
+	if !exists || time.Now().After(entry.expiresAt) ||
+		subtle.ConstantTimeCompare([]byte(entry.token), []byte(token)) != 1 {
+		return nil, domain.NewNotFoundError("tracked message", messageID)
+	}
+	return entry, nil
+}
+
+// evictExpired drops tracked messages past their TTL
+func (rt *receiptTracker) evictExpired(now time.Time) {
+	for key, entry := range rt.entries {
+		if now.After(entry.expiresAt) {
+			for ch := range entry.subscribers {
+				close(ch)
+			}
+			delete(rt.entries, key)
+		}
+	}
+}
+
+func (e *trackedEntry) snapshot() TrackedMessage {
+	state := e.state
+	state.Receipts = append([]ReceiptTransition(nil), e.state.Receipts...)
+	return state
+}
@@ -0,0 +1,117 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"wazmeow/internal/domain"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+const (
+	// s3UploadTimeout bounds a single object upload
+	s3UploadTimeout = 60 * time.Second
+	// s3DefaultRegion is used for endpoints without a configured region
+	s3DefaultRegion = "us-east-1"
+)
+
+// S3Uploader puts objects into an S3 compatible bucket (AWS, MinIO, R2...)
+type S3Uploader struct {
+	cfg      domain.S3Config
+	endpoint *url.URL
+	client   *minio.Client
+}
+
+// NewS3Uploader creates an uploader for a session's bucket settings
+func NewS3Uploader(cfg domain.S3Config) (*S3Uploader, error) {
+	if !cfg.Enabled {
+		return nil, domain.NewBusinessError("S3 is not enabled for this session")
+	}
+	if cfg.Endpoint == "" || cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, domain.NewValidationError("incomplete S3 configuration")
+	}
+	if cfg.Region == "" {
+		cfg.Region = s3DefaultRegion
+	}
+
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Host == "" || strings.Trim(endpoint.Path, "/") != "" {
+		return nil, domain.NewValidationError("invalid S3 endpoint: " + cfg.Endpoint)
+	}
+
+	lookup := minio.BucketLookupDNS
+	if cfg.PathStyle {
+		lookup = minio.BucketLookupPath
+	}
+
+	client, err := minio.New(endpoint.Host, &minio.Options{
+		Creds:        credentials.NewStaticV4(cfg.AccessKey, cfg.SecretKey, ""),
+		Secure:       endpoint.Scheme == "https",
+		Region:       cfg.Region,
+		BucketLookup: lookup,
+	})
+	if err != nil {
+		return nil, domain.NewValidationError(fmt.Sprintf("invalid S3 configuration: %v", err))
+	}
+
+	return &S3Uploader{
+		cfg:      cfg,
+		endpoint: endpoint,
+		client:   client,
+	}, nil
+}
+
+// Upload stores data under key and returns the URL it is served from:
+// below the public URL when one is configured, the object URL otherwise
+func (u *S3Uploader) Upload(ctx context.Context, key string, data []byte, contentType string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, s3UploadTimeout)
+	defer cancel()
+
+	_, err := u.client.PutObject(ctx, u.cfg.Bucket, key, bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{
+		ContentType: contentType,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to upload to S3: %w", err)
+	}
+
+	if u.cfg.PublicURL != "" {
+		return u.cfg.PublicURL + "/" + s3EscapePath(key), nil
+	}
+	return u.objectURL(key), nil
+}
+
+// objectURL addresses key in the bucket, as a path below the endpoint in
+// path style and on the bucket subdomain otherwise
+func (u *S3Uploader) objectURL(key string) string {
+	host := u.endpoint.Host
+	objectPath := "/" + key
+	if u.cfg.PathStyle {
+		objectPath = "/" + u.cfg.Bucket + objectPath
+	} else {
+		host = u.cfg.Bucket + "." + host
+	}
+
+	return u.endpoint.Scheme + "://" + host + s3EscapePath(objectPath)
+}
+
+// s3EscapePath escapes an object key for a URL, every byte but the
+// unreserved characters and the slash
+func s3EscapePath(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if ('A' <= c && c <= 'Z') || ('a' <= c && c <= 'z') || ('0' <= c && c <= '9') ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
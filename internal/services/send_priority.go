@@ -0,0 +1,79 @@
+package services
+
+import (
+	"wazmeow/internal/domain"
+)
+
+// SendPriority orders queued sends: workers take every queued high priority
+// send before a normal one, and every normal one before a low one
+type SendPriority string
+
+const (
+	SendPriorityHigh   SendPriority = "high"
+	SendPriorityNormal SendPriority = "normal"
+	SendPriorityLow    SendPriority = "low"
+)
+
+// sendPriorities lists the priorities in the order workers drain them
+var sendPriorities = []SendPriority{SendPriorityHigh, SendPriorityNormal, SendPriorityLow}
+
+// ParseSendPriority validates a send priority, empty meaning normal
+func ParseSendPriority(s string) (SendPriority, error) {
+	if s == "" {
+		return SendPriorityNormal, nil
+	}
+	for _, p := range sendPriorities {
+		if SendPriority(s) == p {
+			return p, nil
+		}
+	}
+	return "", domain.NewValidationError("invalid priority: " + s + " (expected high, normal or low)")
+}
+
+// newSendQueues creates one bounded queue per priority
+func newSendQueues() map[SendPriority]chan *SendJob {
+	queues := make(map[SendPriority]chan *SendJob, len(sendPriorities))
+	for _, p := range sendPriorities {
+		queues[p] = make(chan *SendJob, sendQueueSize)
+	}
+	return queues
+}
+
+// queueSendJob hands a job to the send workers at its priority, reporting
+// false when that queue is full
+func (msm *MultiSessionManager) queueSendJob(job *SendJob) bool {
+	queue, ok := msm.sendQueues[job.Priority]
+	if !ok {
+		queue = msm.sendQueues[SendPriorityNormal]
+	}
+
+	select {
+	case queue <- job:
+		return true
+	default:
+		return false
+	}
+}
+
+// nextSendJob waits for the next job to send, taking the highest priority
+// one queued. It returns false once the manager shuts down.
+func (msm *MultiSessionManager) nextSendJob() (*SendJob, bool) {
+	for _, p := range sendPriorities {
+		select {
+		case job := <-msm.sendQueues[p]:
+			return job, true
+		default:
+		}
+	}
+
+	select {
+	case job := <-msm.sendQueues[SendPriorityHigh]:
+		return job, true
+	case job := <-msm.sendQueues[SendPriorityNormal]:
+		return job, true
+	case job := <-msm.sendQueues[SendPriorityLow]:
+		return job, true
+	case <-msm.shutdown:
+		return nil, false
+	}
+}
@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"wazmeow/internal/domain"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/proto/waE2E"
+	"go.mau.fi/whatsmeow/types"
+)
+
+const (
+	// sendQueueSize bounds the number of pending asynchronous sends per priority
+	sendQueueSize = 1000
+	// sendWorkerCount is the number of workers draining the send queue
+	sendWorkerCount = 4
+)
+
+// SendJob is a message queued for asynchronous delivery
+type SendJob struct {
+	ID         string
+	Type       JobType
+	SessionID  domain.SessionID
+	Recipient  types.JID
+	Phone      string
+	MessageID  string
+	Message    *waE2E.Message
+	Priority   SendPriority
+	EnqueuedAt time.Time
+	ExpiresAt  time.Time // set for sends held while the session is offline
+	Attempts   int       // sends tried, counted once a worker takes the job off the pending list
+
+	// quota is the daily send quota reserved for the job, kept across
+	// attempts so a job is counted once
+	quota *SendQuotaUsage
+}
+
+// EnqueueSend queues a message for asynchronous delivery and returns its job ID.
+// The result is posted to the session webhook once the send completes.
+func (msm *MultiSessionManager) EnqueueSend(job *SendJob) (string, error) {
+	job.ID = uuid.New().String()
+	job.Type = JobTypeAsyncSend
+	job.EnqueuedAt = time.Now()
+	if job.Priority == "" {
+		job.Priority = SendPriorityNormal
+	}
+
+	msm.jobs.add(job)
+	if !msm.queueSendJob(job) {
+		msm.jobs.take(job.ID)
+		return "", domain.NewBusinessError("send queue is full")
+	}
+
+	log.Debug().
+		Str("session_id", job.SessionID.String()).
+		Str("job_id", job.ID).
+		Str("message_id", job.MessageID).
+		Str("priority", string(job.Priority)).
+		Msg("Send job enqueued")
+
+	return job.ID, nil
+}
+
+// startSendWorkers launches the workers that drain the send queue
+func (msm *MultiSessionManager) startSendWorkers() {
+	for i := 0; i < sendWorkerCount; i++ {
+		go func() {
+			for {
+				job, ok := msm.nextSendJob()
+				if !ok {
+					return
+				}
+				msm.processSendJob(job)
+			}
+		}()
+	}
+}
+
+// processSendJob sends a queued message and reports the result
+func (msm *MultiSessionManager) processSendJob(job *SendJob) {
+	if !msm.jobs.take(job.ID) {
+		log.Debug().
+			Str("session_id", job.SessionID.String()).
+			Str("job_id", job.ID).
+			Msg("Skipping cancelled send job")
+		return
+	}
+	job.Attempts++
+
+	result := domain.SendResultEvent{
+		SessionID: job.SessionID,
+		EventType: domain.EventTypeSendResult,
+		JobID:     job.ID,
+		MessageID: job.MessageID,
+		Phone:     job.Phone,
+	}
+
+	client, err := msm.GetClient(job.SessionID)
+	if err == nil && msm.CooldownRemaining(job.SessionID) > 0 {
+		err = domain.NewBusinessError("session is rate limited, send skipped during cooldown")
+	}
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		var resp whatsmeow.SendResponse
+		resp, err = msm.sendJob(ctx, job, client)
+		cancel()
+
+		if err == nil {
+			result.Status = "sent"
+			result.Timestamp = resp.Timestamp
+		} else if IsRateLimitError(err) {
+			msm.StartCooldown(job.SessionID)
+		}
+	}
+
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("session_id", job.SessionID.String()).
+			Str("job_id", job.ID).
+			Msg("Async send failed")
+		result.Status = "failed"
+		result.Error = err.Error()
+		result.Timestamp = time.Now()
+	}
+
+	msm.deliverSendResult(result)
+}
+
+// sendJob sends a queued message, reserving its daily send quota on the
+// first attempt only
+func (msm *MultiSessionManager) sendJob(ctx context.Context, job *SendJob, client *whatsmeow.Client) (whatsmeow.SendResponse, error) {
+	if job.quota == nil {
+		usage, err := msm.reserveSendOrFailOpen(ctx, job.SessionID)
+		if err != nil {
+			return whatsmeow.SendResponse{}, err
+		}
+		job.quota = usage
+	}
+
+	resp, err := msm.sendReserved(ctx, job.SessionID, client, job.Recipient, job.Message, job.MessageID)
+	if err != nil && job.quota != nil && !sendReachedWhatsApp(err) {
+		msm.releaseSend(job.SessionID, job.quota)
+		job.quota = nil
+	}
+	return resp, err
+}
+
+// failSendJob reports a job that could not be handed to the send workers
+func (msm *MultiSessionManager) failSendJob(job *SendJob, err error) {
+	if !msm.jobs.take(job.ID) {
+		return
+	}
+
+	log.Error().
+		Err(err).
+		Str("session_id", job.SessionID.String()).
+		Str("job_id", job.ID).
+		Msg("Async send failed")
+
+	msm.deliverSendResult(domain.SendResultEvent{
+		SessionID: job.SessionID,
+		EventType: domain.EventTypeSendResult,
+		JobID:     job.ID,
+		MessageID: job.MessageID,
+		Phone:     job.Phone,
+		Status:    "failed",
+		Error:     err.Error(),
+		Timestamp: time.Now(),
+	})
+}
+
+// WebhookDeadLetters returns the webhook deliveries that were given up on
+func (msm *MultiSessionManager) WebhookDeadLetters() []DeadLetter {
+	return msm.webhooks.DeadLetters()
+}
+
+// deliverSendResult posts a send result to the session webhook
+func (msm *MultiSessionManager) deliverSendResult(result domain.SendResultEvent) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	delivered, err := msm.dispatchToSessionWebhook(ctx, result.SessionID, result)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("session_id", result.SessionID.String()).
+			Str("job_id", result.JobID).
+			Msg("Failed to deliver send result")
+	} else if !delivered {
+		log.Warn().
+			Str("session_id", result.SessionID.String()).
+			Str("job_id", result.JobID).
+			Msg("No webhook configured, dropping send result")
+	}
+}
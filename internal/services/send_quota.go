@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"wazmeow/internal/domain"
+
+	"github.com/rs/zerolog/log"
+	"go.mau.fi/whatsmeow"
+)
+
+// SendQuotaUsage is how much of its daily send quota a session used today
+type SendQuotaUsage struct {
+	Used     int       `json:"used"`
+	Limit    int       `json:"limit"` // 0 when the session has no limit
+	ResetsAt time.Time `json:"resets_at"`
+}
+
+// SendQuotaError reports a send refused because the session reached its
+// daily send quota
+type SendQuotaError struct {
+	Usage SendQuotaUsage
+}
+
+func (e *SendQuotaError) Error() string {
+	return fmt.Sprintf("daily send quota of %d messages reached", e.Usage.Limit)
+}
+
+// SetDailySendQuota sets how many messages a session may send per UTC day,
+// unless it overrides it. 0 lifts the limit.
+func (msm *MultiSessionManager) SetDailySendQuota(quota int) {
+	if quota >= 0 {
+		msm.dailySendQuota = quota
+	}
+}
+
+// sendQuotaLimit resolves the daily send quota of a session, 0 for none. A
+// running session answers from the settings it caches, others are loaded.
+func (msm *MultiSessionManager) sendQuotaLimit(ctx context.Context, sessionID domain.SessionID) (int, error) {
+	msm.mutex.RLock()
+	sessionClient, running := msm.sessions[sessionID]
+	var quota int
+	if running {
+		quota = sessionClient.dailySendQuota
+	}
+	msm.mutex.RUnlock()
+
+	if !running {
+		session, err := msm.sessionRepo.GetByID(ctx, sessionID)
+		if err != nil {
+			return 0, err
+		}
+		quota = session.DailySendQuota
+	}
+
+	switch {
+	case quota > 0:
+		return quota, nil
+	case quota < 0:
+		return 0, nil
+	default:
+		return msm.dailySendQuota, nil
+	}
+}
+
+// sendQuotaDay returns the UTC day a send counts towards and when it ends
+func sendQuotaDay(now time.Time) (time.Time, time.Time) {
+	day := now.UTC().Truncate(24 * time.Hour)
+	return day, day.Add(24 * time.Hour)
+}
+
+// reserveSend counts a send against the session's daily send quota,
+// returning a *SendQuotaError once the quota is used up. Sends of sessions
+// without a limit are counted too, so their usage shows in the metrics.
+func (msm *MultiSessionManager) reserveSend(ctx context.Context, sessionID domain.SessionID) (*SendQuotaUsage, error) {
+	limit, err := msm.sendQuotaLimit(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	day, resetsAt := sendQuotaDay(time.Now())
+
+	counted := limit
+	if counted == 0 {
+		counted = math.MaxInt32
+	}
+
+	used, ok, err := msm.sendCounters.Increment(ctx, sessionID, day, counted)
+	if err != nil {
+		return nil, err
+	}
+
+	usage := &SendQuotaUsage{Used: used, Limit: limit, ResetsAt: resetsAt}
+	if !ok {
+		return nil, &SendQuotaError{Usage: *usage}
+	}
+	return usage, nil
+}
+
+// releaseSend gives back a reserved send that never reached WhatsApp, on the
+// day it was reserved for
+func (msm *MultiSessionManager) releaseSend(sessionID domain.SessionID, usage *SendQuotaUsage) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	day := usage.ResetsAt.Add(-24 * time.Hour)
+	if err := msm.sendCounters.Decrement(ctx, sessionID, day); err != nil {
+		log.Warn().Err(err).Str("session_id", sessionID.String()).Msg("Failed to release daily send quota")
+	}
+}
+
+// sendReachedWhatsApp reports whether a failed send was still handed to
+// WhatsApp, so it counts against the daily send quota
+func sendReachedWhatsApp(err error) bool {
+	return errors.Is(err, whatsmeow.ErrServerReturnedError) ||
+		errors.Is(err, whatsmeow.ErrMessageTimedOut) ||
+		IsRateLimitError(err)
+}
+
+// SendQuotaExhausted reports whether the session used up its daily send
+// quota, without counting a send. It answers false when the quota cannot
+// be checked.
+func (msm *MultiSessionManager) SendQuotaExhausted(ctx context.Context, sessionID domain.SessionID) (*SendQuotaUsage, bool) {
+	usage, err := msm.GetSendQuotaUsage(ctx, sessionID)
+	if err != nil {
+		log.Warn().Err(err).Str("session_id", sessionID.String()).Msg("Failed to check daily send quota")
+		return nil, false
+	}
+	return usage, usage.Limit > 0 && usage.Used >= usage.Limit
+}
+
+// GetSendQuotaUsage returns how much of its daily send quota a session used today
+func (msm *MultiSessionManager) GetSendQuotaUsage(ctx context.Context, sessionID domain.SessionID) (*SendQuotaUsage, error) {
+	limit, err := msm.sendQuotaLimit(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	day, resetsAt := sendQuotaDay(time.Now())
+	used, err := msm.sendCounters.Get(ctx, sessionID, day)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SendQuotaUsage{Used: used, Limit: limit, ResetsAt: resetsAt}, nil
+}
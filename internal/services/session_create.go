@@ -6,15 +6,38 @@ import (
 
 	"wazmeow/internal/domain"
 
+	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 )
 
 // CreateSessionRequest represents the request to create a new session
 type CreateSessionRequest struct {
-	Name     string `json:"name" validate:"required,min=1,max=255"`
-	ProxyURL string `json:"proxy_url,omitempty" validate:"omitempty,url"`
+	Name            string `json:"name,omitempty" validate:"omitempty,min=1,max=255"` // generated when omitted
+	ProxyURL        string `json:"proxy_url,omitempty" validate:"omitempty,url"`
+	Timezone        string `json:"timezone,omitempty"`
+	MediaDelivery   string `json:"media_delivery,omitempty"`
+	CaptureHistory  bool   `json:"capture_history,omitempty"`
+	WebhookCompress bool   `json:"webhook_compress,omitempty"`
+	// Event types the session emits, all when empty
+	Events []string `json:"events,omitempty"`
+	// Privacy flags, both enabled when omitted
+	SendReadReceipts  *bool `json:"send_read_receipts,omitempty"`
+	BroadcastPresence *bool `json:"broadcast_presence,omitempty"`
+	// Disappearing timer of outbound messages in chats without one
+	DefaultEphemeralSeconds int `json:"default_ephemeral_seconds,omitempty"`
+	// Messages sent per UTC day, the instance default when omitted, -1 for unlimited
+	DailySendQuota int `json:"daily_send_quota,omitempty"`
+	// Bucket inbound media is uploaded to with the s3 media delivery mode
+	S3 *S3Settings `json:"s3,omitempty"`
 }
 
+const (
+	// generatedNamePrefix starts the names given to sessions created without one
+	generatedNamePrefix = "session-"
+	// generatedNameAttempts bounds the tries at finding an unused generated name
+	generatedNameAttempts = 5
+)
+
 // CreateSessionResponse represents the response after creating a session
 type CreateSessionResponse struct {
 	ID        string `json:"id"`
@@ -37,10 +60,20 @@ func NewCreateSessionUseCase(sessionRepo domain.Repository) *CreateSessionUseCas
 
 // Execute creates a new session
 func (uc *CreateSessionUseCase) Execute(ctx context.Context, req CreateSessionRequest) (*CreateSessionResponse, error) {
+	// Name the session when no name is given
+	if strings.TrimSpace(req.Name) == "" {
+		name, err := uc.generateName(ctx)
+		if err != nil {
+			return nil, err
+		}
+		req.Name = name
+	}
+
 	// Validate request
 	if err := uc.validateRequest(req); err != nil {
 		return nil, err
 	}
+	req.Name = strings.TrimSpace(req.Name)
 
 	// Check if session with same name already exists
 	exists, err := uc.sessionRepo.ExistsByName(ctx, req.Name)
@@ -63,6 +96,62 @@ func (uc *CreateSessionUseCase) Execute(ctx context.Context, req CreateSessionRe
 		}
 	}
 
+	// Set response timezone if provided
+	if req.Timezone != "" {
+		if err := sess.SetTimezone(req.Timezone); err != nil {
+			return nil, err
+		}
+	}
+
+	// Set inbound media delivery mode if provided
+	if req.MediaDelivery != "" {
+		if err := sess.SetMediaDelivery(domain.MediaDelivery(req.MediaDelivery)); err != nil {
+			return nil, err
+		}
+	}
+
+	// Make outbound messages disappear by default if requested
+	if req.DefaultEphemeralSeconds != 0 {
+		if err := sess.SetDefaultEphemeral(req.DefaultEphemeralSeconds); err != nil {
+			return nil, err
+		}
+	}
+
+	// Override the instance daily send quota if requested
+	if req.DailySendQuota != 0 {
+		if err := sess.SetDailySendQuota(req.DailySendQuota); err != nil {
+			return nil, err
+		}
+	}
+
+	// Configure the media bucket if provided
+	if req.S3 != nil {
+		if err := req.S3.apply(sess); err != nil {
+			return nil, err
+		}
+	}
+
+	// Subscribe to the requested events only
+	if len(req.Events) > 0 {
+		if err := sess.SetSubscribedEvents(req.Events); err != nil {
+			return nil, err
+		}
+	}
+
+	// Persist history sync payloads for this session if requested
+	sess.CaptureHistory = req.CaptureHistory
+
+	// Gzip webhook payloads for this session if requested
+	sess.WebhookCompress = req.WebhookCompress
+
+	// Privacy flags applied after connect
+	if req.SendReadReceipts != nil {
+		sess.SendReadReceipts = *req.SendReadReceipts
+	}
+	if req.BroadcastPresence != nil {
+		sess.BroadcastPresence = *req.BroadcastPresence
+	}
+
 	// Save session to repository
 	if err := uc.sessionRepo.Create(ctx, sess); err != nil {
 		log.Error().Err(err).Str("session_id", sess.ID.String()).Msg("Failed to create session")
@@ -83,16 +172,29 @@ func (uc *CreateSessionUseCase) Execute(ctx context.Context, req CreateSessionRe
 	}, nil
 }
 
+// generateName returns an unused session name of the form session-<shortid>
+func (uc *CreateSessionUseCase) generateName(ctx context.Context) (string, error) {
+	for i := 0; i < generatedNameAttempts; i++ {
+		name := generatedNamePrefix + uuid.NewString()[:8]
+
+		exists, err := uc.sessionRepo.ExistsByName(ctx, name)
+		if err != nil {
+			log.Error().Err(err).Str("name", name).Msg("Failed to check generated session name")
+			return "", err
+		}
+		if !exists {
+			return name, nil
+		}
+	}
+
+	return "", domain.NewBusinessError("could not generate an unused session name")
+}
+
 // validateRequest validates the create session request
 func (uc *CreateSessionUseCase) validateRequest(req CreateSessionRequest) error {
 	// Validate name
-	name := strings.TrimSpace(req.Name)
-	if name == "" {
-		return domain.ErrInvalidSessionName("name cannot be empty")
-	}
-
-	if len(name) > 255 {
-		return domain.ErrInvalidSessionName("name cannot exceed 255 characters")
+	if _, err := domain.NormalizeSessionName(req.Name); err != nil {
+		return err
 	}
 
 	// Validate proxy URL if provided
@@ -0,0 +1,180 @@
+package services
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"wazmeow/internal/domain"
+
+	"github.com/rs/zerolog/log"
+)
+
+// SessionCredentialsUseCase exports and imports encrypted device credentials
+// so a paired session can be restored without scanning a new QR code
+type SessionCredentialsUseCase struct {
+	storeManager        *WhatsAppStoreManager
+	multiSessionManager *MultiSessionManager
+	sessionRepo         domain.Repository
+	key                 []byte
+}
+
+// NewSessionCredentialsUseCase creates a new instance of SessionCredentialsUseCase.
+// The encryption key is derived from the given secret; an empty secret disables the feature.
+func NewSessionCredentialsUseCase(
+	storeManager *WhatsAppStoreManager,
+	multiSessionManager *MultiSessionManager,
+	sessionRepo domain.Repository,
+	secret string,
+) *SessionCredentialsUseCase {
+	uc := &SessionCredentialsUseCase{
+		storeManager:        storeManager,
+		multiSessionManager: multiSessionManager,
+		sessionRepo:         sessionRepo,
+	}
+	if secret != "" {
+		sum := sha256.Sum256([]byte(secret))
+		uc.key = sum[:]
+	}
+	return uc
+}
+
+// Enabled reports whether an encryption key is configured
+func (uc *SessionCredentialsUseCase) Enabled() bool {
+	return uc.key != nil
+}
+
+// Export returns the encrypted credentials of a paired session
+func (uc *SessionCredentialsUseCase) Export(ctx context.Context, sessionID domain.SessionID) (string, error) {
+	if !uc.Enabled() {
+		return "", domain.NewBusinessError("credentials encryption key is not configured")
+	}
+
+	session, err := uc.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return "", err
+	}
+
+	if session.WAJID == "" {
+		return "", domain.NewBusinessError("session is not paired")
+	}
+
+	creds, err := uc.storeManager.ExportDevice(ctx, session.WAJID)
+	if err != nil {
+		return "", err
+	}
+
+	plaintext, err := json.Marshal(creds)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode credentials: %w", err)
+	}
+
+	blob, err := uc.encrypt(plaintext)
+	if err != nil {
+		return "", err
+	}
+
+	log.Info().
+		Str("session_id", sessionID.String()).
+		Str("jid", session.WAJID).
+		Msg("Session credentials exported")
+
+	return blob, nil
+}
+
+// Import restores encrypted credentials into a disconnected session and returns the device JID
+func (uc *SessionCredentialsUseCase) Import(ctx context.Context, sessionID domain.SessionID, blob string) (string, error) {
+	if !uc.Enabled() {
+		return "", domain.NewBusinessError("credentials encryption key is not configured")
+	}
+
+	session, err := uc.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return "", err
+	}
+
+	switch uc.multiSessionManager.GetSessionStatus(sessionID) {
+	case StatusNotInitialized, StatusDisconnected:
+	default:
+		return "", domain.NewBusinessError("session must be disconnected to import credentials")
+	}
+
+	plaintext, err := uc.decrypt(blob)
+	if err != nil {
+		return "", domain.NewValidationError("invalid credentials blob")
+	}
+
+	var creds DeviceCredentials
+	if err := json.Unmarshal(plaintext, &creds); err != nil {
+		return "", domain.NewValidationError("invalid credentials payload")
+	}
+
+	jid, err := uc.storeManager.ImportDevice(ctx, &creds)
+	if err != nil {
+		return "", err
+	}
+
+	// Drop any cached unpaired device so the next connect restores the imported one
+	uc.storeManager.RemoveDevice(sessionID)
+
+	if err := uc.sessionRepo.SetWAJID(ctx, session.ID, jid.String()); err != nil {
+		return "", err
+	}
+
+	log.Info().
+		Str("session_id", sessionID.String()).
+		Str("jid", jid.String()).
+		Msg("Session credentials imported")
+
+	return jid.String(), nil
+}
+
+// encrypt seals plaintext with AES-GCM and returns base64(nonce || ciphertext)
+func (uc *SessionCredentialsUseCase) encrypt(plaintext []byte) (string, error) {
+	gcm, err := uc.cipher()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	sealed := gcm.Seal(nonce, nonce, plaintext, nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// decrypt opens a blob produced by encrypt
+func (uc *SessionCredentialsUseCase) decrypt(blob string) ([]byte, error) {
+	gcm, err := uc.cipher()
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(blob)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode credentials: %w", err)
+	}
+
+	if len(data) < gcm.NonceSize() {
+		return nil, fmt.Errorf("credentials blob too short")
+	}
+
+	nonce, ciphertext := data[:gcm.NonceSize()], data[gcm.NonceSize():]
+	return gcm.Open(nil, nonce, ciphertext, nil)
+}
+
+func (uc *SessionCredentialsUseCase) cipher() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(uc.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -7,18 +7,23 @@ package services
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"wazmeow/internal/domain"
+	"wazmeow/pkg/logger"
 
 	"github.com/mdp/qrterminal/v3"
 	"github.com/rs/zerolog/log"
 	"github.com/skip2/go-qrcode"
 	"go.mau.fi/whatsmeow"
 	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
 )
 
@@ -26,10 +31,11 @@ import (
 type ConnectionStatus string
 
 const (
-	StatusDisconnected ConnectionStatus = "disconnected"
-	StatusConnecting   ConnectionStatus = "connecting"
-	StatusConnected    ConnectionStatus = "connected"
-	StatusError        ConnectionStatus = "error"
+	StatusNotInitialized ConnectionStatus = "not_initialized" // no in-memory client
+	StatusDisconnected   ConnectionStatus = "disconnected"
+	StatusConnecting     ConnectionStatus = "connecting"
+	StatusConnected      ConnectionStatus = "connected"
+	StatusError          ConnectionStatus = "error"
 )
 
 // SessionClient holds all components for a single WhatsApp session
@@ -39,6 +45,38 @@ type SessionClient struct {
 	KillChannel chan bool
 	Status      ConnectionStatus
 	LastSeen    time.Time
+
+	// LastMessageAt is when the session last received an inbound message
+	LastMessageAt time.Time
+
+	// ignoredChats holds chat JIDs whose events are not delivered
+	ignoredChats map[string]bool
+
+	// subscribedEvents holds the event types delivered, the instance default when empty
+	subscribedEvents map[string]bool
+
+	// dailySendQuota and defaultEphemeralSeconds cache the session settings
+	// read on every send, see domain.Session
+	dailySendQuota          int
+	defaultEphemeralSeconds int
+
+	// statusChanged is closed and replaced on every status change
+	statusChanged chan struct{}
+
+	// events stores messages and receipts off whatsmeow's read loop
+	events *sessionEventQueue
+	// deliveries sends webhook payloads, including inbound media, on their
+	// own workers so a slow endpoint never holds up storing messages
+	deliveries *sessionEventQueue
+
+	// qr is the running QR code generation, nil when none runs
+	qr *qrFlight
+
+	// phonePair is the last phone code pairing, nil when none was started
+	phonePair *phonePairing
+
+	// log tags every line about this session with its session_id
+	log *logger.Logger
 }
 
 // MultiSessionManager manages multiple WhatsApp sessions concurrently
@@ -49,36 +87,147 @@ type MultiSessionManager struct {
 	// Components
 	storeManager *WhatsAppStoreManager
 	sessionRepo  domain.Repository
+	messageRepo  domain.MessageRepository
+	webhooks     *WebhookDispatcher
+	publicURL    string
+	mediaSaveDir string
+	stateWriter  *sessionStateWriter
+	cooldowns    *cooldownTracker
+	offlineSends *offlineSendBuffer
+	jobs         *jobStore
+	receipts     *receiptTracker
+	dedup        *eventDeduper
+	chatTimers   *chatTimerStore
+	connHistory  *connectionHistory
+	sendCounters domain.SendCounterRepository
+
+	// Asynchronous send queues, one per priority
+	sendQueues   map[SendPriority]chan *SendJob
+	shutdown     chan struct{}
+	shutdownOnce sync.Once
+
+	// ready is closed by MarkReady, stored sessions reconnect only after it
+	ready     chan struct{}
+	readyOnce sync.Once
+
+	// maintenance rejects new sends while sessions stay connected
+	maintenance atomic.Bool
 
 	// Concurrency control
 	mutex sync.RWMutex
 
 	// Configuration
-	maxSessions int
+	maxSessions        int
+	sessionLimitPolicy SessionLimitPolicy
+	eventWorkers       int
+	eventBufferSize    int
+	pairClient         whatsmeow.PairClientType
+	pairDisplay        string
+	groupBatchSize     int
+	groupBatchDelay    time.Duration
+	proxyCheckURL      string
+	startupDelay       time.Duration
+	qrRotation         time.Duration
+
+	// dailySendQuota caps the messages a session sends per UTC day, 0 for no limit
+	dailySendQuota int
+
+	// captureUnknownEvents emits whatsmeow events without a mapping as generic notifications
+	captureUnknownEvents bool
+
+	// defaultEvents holds the event types delivered for sessions without their own list, all when empty
+	defaultEvents map[string]bool
 }
 
 // NewMultiSessionManager creates a new multi-session manager
 func NewMultiSessionManager(
 	storeManager *WhatsAppStoreManager,
 	sessionRepo domain.Repository,
+	messageRepo domain.MessageRepository,
+	jobRepo domain.JobRepository,
+	counterRepo domain.SendCounterRepository,
+	webhooks *WebhookDispatcher,
+	publicURL string,
 ) *MultiSessionManager {
 	msm := &MultiSessionManager{
 		sessions:     make(map[domain.SessionID]*SessionClient),
 		storeManager: storeManager,
 		sessionRepo:  sessionRepo,
+		messageRepo:  messageRepo,
+		webhooks:     webhooks,
+		publicURL:    publicURL,
+		cooldowns:    newCooldownTracker(),
+		offlineSends: newOfflineSendBuffer(),
+		jobs:         newJobStore(jobRepo),
+		sendCounters: counterRepo,
+		receipts:     newReceiptTracker(),
+		dedup:        newEventDeduper(defaultEventDedupTTL, defaultEventDedupSize),
+		chatTimers:   newChatTimerStore(),
+		connHistory:  newConnectionHistory(),
+		sendQueues:   newSendQueues(),
+		shutdown:     make(chan struct{}),
+		ready:        make(chan struct{}),
 		maxSessions:  50, // Default limit
+
+		sessionLimitPolicy: SessionLimitReject,
+
+		eventWorkers:    defaultEventWorkers,
+		eventBufferSize: defaultEventBufferSize,
+
+		pairClient:  defaultPairClient,
+		pairDisplay: defaultPairDisplay,
+
+		groupBatchSize:  defaultGroupBatchSize,
+		groupBatchDelay: defaultGroupBatchDelay,
+
+		proxyCheckURL: defaultProxyCheckURL,
+
+		qrRotation: defaultQRRotation,
 	}
 
+	msm.stateWriter = newSessionStateWriter(sessionRepo)
+	msm.startSendWorkers()
+	msm.startOfflineSweeper()
+
 	// Start automatic reconnection of previously connected sessions
 	go msm.connectOnStartup()
 
 	return msm
 }
 
+// MarkReady signals that the app is serving requests and its database
+// answers, letting previously connected sessions reconnect
+func (msm *MultiSessionManager) MarkReady() {
+	msm.readyOnce.Do(func() { close(msm.ready) })
+}
+
+// SetCaptureUnknownEvents enables emitting whatsmeow events that have no
+// dedicated mapping as notification events
+func (msm *MultiSessionManager) SetCaptureUnknownEvents(enabled bool) {
+	msm.captureUnknownEvents = enabled
+}
+
+// SetStartupDelay sets how long to wait after MarkReady before reconnecting sessions
+func (msm *MultiSessionManager) SetStartupDelay(delay time.Duration) {
+	if delay >= 0 {
+		msm.startupDelay = delay
+	}
+}
+
 // connectOnStartup connects to WhatsApp sessions that were previously connected
 func (msm *MultiSessionManager) connectOnStartup() {
-	// Wait a bit for the system to fully initialize
-	time.Sleep(2 * time.Second)
+	// Wait for the app to be ready instead of guessing how long startup takes
+	select {
+	case <-msm.ready:
+	case <-msm.shutdown:
+		return
+	}
+	if msm.startupDelay > 0 {
+		time.Sleep(msm.startupDelay)
+	}
+
+	// Held sends must be back in place before their sessions reconnect
+	msm.restorePendingJobs()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
@@ -102,6 +251,16 @@ func (msm *MultiSessionManager) connectOnStartup() {
 
 			// Start the session asynchronously
 			go func(sessionID domain.SessionID) {
+				// One bad session must not take the rest of startup down with it
+				defer func() {
+					if r := recover(); r != nil {
+						log.Error().
+							Str("session_id", sessionID.String()).
+							Interface("panic", r).
+							Msg("Panic while reconnecting session on startup")
+					}
+				}()
+
 				if err := msm.StartSession(context.Background(), sessionID); err != nil {
 					log.Error().
 						Err(err).
@@ -143,7 +302,9 @@ func (msm *MultiSessionManager) StartSession(ctx context.Context, sessionID doma
 
 	// Check session limit
 	if len(msm.sessions) >= msm.maxSessions {
-		return fmt.Errorf("maximum number of sessions (%d) reached", msm.maxSessions)
+		if msm.sessionLimitPolicy != SessionLimitEvictLRU || !msm.evictLRUSessionUnsafe() {
+			return fmt.Errorf("maximum number of sessions (%d) reached", msm.maxSessions)
+		}
 	}
 
 	// Get session from database
@@ -153,13 +314,18 @@ func (msm *MultiSessionManager) StartSession(ctx context.Context, sessionID doma
 	}
 
 	// Get or create device
-	device, err := msm.storeManager.GetOrCreateDevice(sessionID, session.WAJID)
+	device, err := msm.storeManager.GetOrCreateDevice(sessionID, session.WAJID, msm.isSessionIdleUnsafe)
 	if err != nil {
 		return fmt.Errorf("failed to get or create device: %w", err)
 	}
 
-	// Create WhatsApp client
+	// Create WhatsApp client, routed through the session's proxy if it has one
 	client := whatsmeow.NewClient(device, nil)
+	if session.ProxyURL != "" {
+		if err := client.SetProxyAddress(session.ProxyURL); err != nil {
+			return fmt.Errorf("failed to configure session proxy: %w", err)
+		}
+	}
 
 	// Create session client
 	sessionClient := &SessionClient{
@@ -168,6 +334,16 @@ func (msm *MultiSessionManager) StartSession(ctx context.Context, sessionID doma
 		KillChannel: make(chan bool, 1),
 		Status:      StatusDisconnected,
 		LastSeen:    time.Now(),
+
+		ignoredChats:     toSet(session.IgnoredChatList()),
+		subscribedEvents: toSet(session.SubscribedEvents()),
+		statusChanged:    make(chan struct{}),
+		events:           newSessionEventQueue(sessionID, msm.eventWorkers, msm.eventBufferSize),
+		deliveries:       newSessionEventQueue(sessionID, msm.eventWorkers, msm.eventBufferSize),
+		log:              logger.Global().WhatsApp().WithSessionID(sessionID.String()),
+
+		dailySendQuota:          session.DailySendQuota,
+		defaultEphemeralSeconds: session.DefaultEphemeralSeconds,
 	}
 
 	// Store session client
@@ -180,6 +356,73 @@ func (msm *MultiSessionManager) StartSession(ctx context.Context, sessionID doma
 	return nil
 }
 
+// isSessionIdleUnsafe reports whether a session has no live connection, so its
+// cached device can be dropped (must be called with mutex locked)
+func (msm *MultiSessionManager) isSessionIdleUnsafe(sessionID domain.SessionID) bool {
+	sessionClient, exists := msm.sessions[sessionID]
+	if !exists {
+		return true
+	}
+	return (sessionClient.Status == StatusDisconnected || sessionClient.Status == StatusError) &&
+		!sessionClient.Client.IsConnected()
+}
+
+// SessionLimitPolicy decides what StartSession does once maxSessions is reached
+type SessionLimitPolicy string
+
+const (
+	SessionLimitReject   SessionLimitPolicy = "reject"    // refuse to start the session
+	SessionLimitEvictLRU SessionLimitPolicy = "evict_lru" // clean up the least recently seen disconnected session
+)
+
+// SetSessionLimitPolicy sets what happens when a session is started at the limit
+func (msm *MultiSessionManager) SetSessionLimitPolicy(policy SessionLimitPolicy) {
+	msm.mutex.Lock()
+	defer msm.mutex.Unlock()
+	msm.sessionLimitPolicy = policy
+}
+
+// evictLRUSessionUnsafe cleans up the disconnected session with the oldest
+// LastSeen and reports whether one was found (must be called with mutex locked)
+func (msm *MultiSessionManager) evictLRUSessionUnsafe() bool {
+	var (
+		oldestID   domain.SessionID
+		oldestSeen time.Time
+		found      bool
+	)
+	for id, sessionClient := range msm.sessions {
+		if sessionClient.Status != StatusDisconnected && sessionClient.Status != StatusError {
+			continue
+		}
+		if !found || sessionClient.LastSeen.Before(oldestSeen) {
+			oldestID, oldestSeen, found = id, sessionClient.LastSeen, true
+		}
+	}
+
+	if !found {
+		return false
+	}
+
+	log.Warn().
+		Str("session_id", oldestID.String()).
+		Time("last_seen", oldestSeen).
+		Msg("Session limit reached, evicting least recently used disconnected session")
+
+	msm.cleanupSessionUnsafe(oldestID)
+	return true
+}
+
+// SetMaintenance turns maintenance mode on or off
+func (msm *MultiSessionManager) SetMaintenance(enabled bool) {
+	msm.maintenance.Store(enabled)
+	log.Warn().Bool("enabled", enabled).Msg("Maintenance mode changed")
+}
+
+// InMaintenance reports whether new sends are currently rejected
+func (msm *MultiSessionManager) InMaintenance() bool {
+	return msm.maintenance.Load()
+}
+
 // GetClient returns the WhatsApp client for a session
 func (msm *MultiSessionManager) GetClient(sessionID domain.SessionID) (*whatsmeow.Client, error) {
 	msm.mutex.RLock()
@@ -205,6 +448,42 @@ func (msm *MultiSessionManager) StopSession(ctx context.Context, sessionID domai
 	return msm.cleanupSessionUnsafe(sessionID)
 }
 
+// DisconnectAll stops every live client while keeping their pairing, so
+// each session can be connected again later, and returns how many were stopped
+func (msm *MultiSessionManager) DisconnectAll() int {
+	msm.mutex.Lock()
+	defer msm.mutex.Unlock()
+
+	count := 0
+	for sessionID := range msm.sessions {
+		if err := msm.cleanupSessionUnsafe(sessionID); err != nil {
+			log.Error().
+				Err(err).
+				Str("session_id", sessionID.String()).
+				Msg("Failed to disconnect session")
+			continue
+		}
+
+		msm.stateWriter.QueueStatus(sessionID, domain.StatusDisconnected)
+		count++
+	}
+
+	log.Warn().Int("count", count).Msg("All sessions disconnected")
+	return count
+}
+
+// RemoveSession stops a session's live client and evicts its cached device,
+// fully tearing it down in memory
+func (msm *MultiSessionManager) RemoveSession(sessionID domain.SessionID) error {
+	msm.mutex.Lock()
+	err := msm.cleanupSessionUnsafe(sessionID)
+	msm.mutex.Unlock()
+
+	msm.connHistory.forget(sessionID)
+	msm.storeManager.RemoveDevice(sessionID)
+	return err
+}
+
 // GetSessionStatus returns the current status of a session
 func (msm *MultiSessionManager) GetSessionStatus(sessionID domain.SessionID) ConnectionStatus {
 	msm.mutex.RLock()
@@ -213,7 +492,123 @@ func (msm *MultiSessionManager) GetSessionStatus(sessionID domain.SessionID) Con
 	if client, exists := msm.sessions[sessionID]; exists {
 		return client.Status
 	}
-	return StatusDisconnected
+	return StatusNotInitialized
+}
+
+// WaitForStatus blocks until the session reaches one of the given statuses or
+// ctx is done, returning the last observed status. The error is ctx.Err() on
+// timeout or cancellation.
+func (msm *MultiSessionManager) WaitForStatus(ctx context.Context, sessionID domain.SessionID, targets ...ConnectionStatus) (ConnectionStatus, error) {
+	for {
+		msm.mutex.RLock()
+		sessionClient, exists := msm.sessions[sessionID]
+		if !exists {
+			msm.mutex.RUnlock()
+			return StatusNotInitialized, fmt.Errorf("session not found: %s", sessionID)
+		}
+		status := sessionClient.Status
+		changed := sessionClient.statusChanged
+		msm.mutex.RUnlock()
+
+		for _, target := range targets {
+			if status == target {
+				return status, nil
+			}
+		}
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return status, ctx.Err()
+		}
+	}
+}
+
+// GetLastMessageAt returns when a session last received an inbound message.
+// The zero time means no message was received since the client started.
+func (msm *MultiSessionManager) GetLastMessageAt(sessionID domain.SessionID) time.Time {
+	msm.mutex.RLock()
+	defer msm.mutex.RUnlock()
+
+	if sessionClient, exists := msm.sessions[sessionID]; exists {
+		return sessionClient.LastMessageAt
+	}
+	return time.Time{}
+}
+
+// touchLastMessage records the arrival of an inbound message
+func (msm *MultiSessionManager) touchLastMessage(sessionClient *SessionClient) {
+	msm.mutex.Lock()
+	defer msm.mutex.Unlock()
+
+	sessionClient.LastMessageAt = time.Now()
+}
+
+// IsSessionInitialized reports whether a session has an in-memory client
+func (msm *MultiSessionManager) IsSessionInitialized(sessionID domain.SessionID) bool {
+	msm.mutex.RLock()
+	defer msm.mutex.RUnlock()
+
+	_, exists := msm.sessions[sessionID]
+	return exists
+}
+
+// GetAuthState reports whether a session is paired (its store has an ID) and
+// whether its socket is currently up. A paired session may be disconnected.
+func (msm *MultiSessionManager) GetAuthState(sessionID domain.SessionID) (authenticated, connected bool) {
+	msm.mutex.RLock()
+	defer msm.mutex.RUnlock()
+
+	sessionClient, exists := msm.sessions[sessionID]
+	if !exists || sessionClient.Client == nil {
+		return false, false
+	}
+
+	return sessionClient.Client.Store.ID != nil, sessionClient.Client.IsConnected()
+}
+
+// SessionStatusSummary is the compact live state of a session
+type SessionStatusSummary struct {
+	ID            domain.SessionID `json:"id"`
+	Status        ConnectionStatus `json:"status"`
+	Connected     bool             `json:"connected"`
+	Authenticated bool             `json:"authenticated"`
+	LastSeen      *time.Time       `json:"last_seen,omitempty"`
+}
+
+// GetSessionStatuses returns the live state of the given sessions from memory,
+// or of every session in memory when ids is empty. Sessions without a client
+// are reported as not initialized.
+func (msm *MultiSessionManager) GetSessionStatuses(ids []domain.SessionID) []SessionStatusSummary {
+	msm.mutex.RLock()
+	defer msm.mutex.RUnlock()
+
+	if len(ids) == 0 {
+		ids = make([]domain.SessionID, 0, len(msm.sessions))
+		for id := range msm.sessions {
+			ids = append(ids, id)
+		}
+		sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	}
+
+	summaries := make([]SessionStatusSummary, 0, len(ids))
+	for _, id := range ids {
+		summary := SessionStatusSummary{ID: id, Status: StatusNotInitialized}
+
+		if sessionClient, exists := msm.sessions[id]; exists {
+			lastSeen := sessionClient.LastSeen
+			summary.Status = sessionClient.Status
+			summary.LastSeen = &lastSeen
+			if sessionClient.Client != nil {
+				summary.Connected = sessionClient.Client.IsConnected()
+				summary.Authenticated = sessionClient.Client.Store.ID != nil
+			}
+		}
+
+		summaries = append(summaries, summary)
+	}
+
+	return summaries
 }
 
 // GetSessionClient returns the WhatsApp client for a session (thread-safe)
@@ -241,6 +636,26 @@ func (msm *MultiSessionManager) GetActiveSessions() []domain.SessionID {
 	return activeSessions
 }
 
+// IsRecipientAllowed checks whether a session's recipient allowlist permits the given JID
+func (msm *MultiSessionManager) IsRecipientAllowed(ctx context.Context, sessionID domain.SessionID, recipient string) (bool, error) {
+	session, err := msm.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get session from database: %w", err)
+	}
+
+	return session.IsRecipientAllowed(recipient), nil
+}
+
+// GetSessionLocation returns the timezone configured for a session
+func (msm *MultiSessionManager) GetSessionLocation(ctx context.Context, sessionID domain.SessionID) (*time.Location, error) {
+	session, err := msm.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session from database: %w", err)
+	}
+
+	return session.Location(), nil
+}
+
 // GetSessionCount returns the total number of managed sessions
 func (msm *MultiSessionManager) GetSessionCount() int {
 	msm.mutex.RLock()
@@ -249,74 +664,176 @@ func (msm *MultiSessionManager) GetSessionCount() int {
 	return len(msm.sessions)
 }
 
+// PeekQRCode returns the currently stored QR code for a session without
+// starting a connection. A nil state means no QR code is stored, an expired
+// one is cleared and reported as ErrQRCodeExpired.
+func (msm *MultiSessionManager) PeekQRCode(ctx context.Context, sessionID domain.SessionID) (*QRCodeState, error) {
+	session, err := msm.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	return msm.storedQRCode(ctx, session)
+}
+
+const (
+	// qrGenerationTimeout bounds a QR pairing attempt, enough for every code WhatsApp issues
+	qrGenerationTimeout = 3 * time.Minute
+)
+
 // GenerateQRCode generates a QR code for session authentication
-func (msm *MultiSessionManager) GenerateQRCode(ctx context.Context, sessionID domain.SessionID) (string, error) {
+func (msm *MultiSessionManager) GenerateQRCode(ctx context.Context, sessionID domain.SessionID) (*QRCodeState, error) {
 	msm.mutex.RLock()
 	sessionClient, exists := msm.sessions[sessionID]
 	msm.mutex.RUnlock()
 
 	if !exists {
-		return "", fmt.Errorf("session %s not found", sessionID)
+		return nil, fmt.Errorf("session %s not found", sessionID)
 	}
 
 	if sessionClient.Status == StatusConnected {
-		return "", fmt.Errorf("session %s is already connected", sessionID)
+		return nil, fmt.Errorf("session %s is already connected", sessionID)
 	}
 
 	// Check if session already has a QR code stored
 	session, err := msm.sessionRepo.GetByID(ctx, sessionID)
 	if err != nil {
-		return "", fmt.Errorf("failed to get session from database: %w", err)
+		return nil, fmt.Errorf("failed to get session from database: %w", err)
 	}
 
-	// If QR code exists and is not empty, return it
-	if session.QRCode != "" {
+	// Return the stored QR code while it is still valid, an expired one is
+	// cleared and replaced by a fresh code below
+	stored, err := msm.storedQRCode(ctx, session)
+	if err != nil && !errors.Is(err, ErrQRCodeExpired) {
+		return nil, fmt.Errorf("failed to check stored QR code: %w", err)
+	}
+	if stored != nil {
 		log.Info().
 			Str("session_id", sessionID.String()).
 			Msg("Returning existing QR code")
-		return session.QRCode, nil
+		return stored, nil
 	}
 
-	// Start QR code generation process asynchronously with background context
-	go msm.handleQRCodeGeneration(context.Background(), sessionID, sessionClient)
+	// Join the running QR code generation or start one. Concurrent callers
+	// share it, so the client is only paired once, and it outlives this
+	// request so later polls get the refreshed codes.
+	msm.mutex.Lock()
+	flight := msm.startQRGenerationUnsafe(sessionID, sessionClient)
+	flight.join()
+	msm.mutex.Unlock()
+
+	// Wait for a valid QR code, as long as the caller is still waiting. A
+	// code that expired before this caller joined is skipped for the next one.
+	for {
+		state, changed, err := flight.current()
+		if err != nil {
+			flight.leave()
+			return nil, err
+		}
+		if state != nil {
+			flight.leave()
+			log.Info().
+				Str("session_id", sessionID.String()).
+				Msg("QR code generated and retrieved")
+			return state, nil
+		}
 
-	// Wait a bit for QR code to be generated
-	time.Sleep(2 * time.Second)
+		select {
+		case <-ctx.Done():
+			// Nobody will scan a QR code no one received, so the last caller to
+			// give up stops pairing and releases the connection it holds
+			if flight.abandon() {
+				flight.cancel()
+			}
+			return nil, fmt.Errorf("waiting for QR code: %w", ctx.Err())
+		case <-changed:
+		}
+	}
+}
 
-	// Try to get QR code again
-	session, err = msm.sessionRepo.GetByID(ctx, sessionID)
-	if err == nil && session.QRCode != "" {
-		log.Info().
-			Str("session_id", sessionID.String()).
-			Msg("QR code generated and retrieved")
-		return session.QRCode, nil
+// startQRGenerationUnsafe returns the running QR code generation of a session,
+// starting one when none runs (caller must hold the lock)
+func (msm *MultiSessionManager) startQRGenerationUnsafe(sessionID domain.SessionID, sessionClient *SessionClient) *qrFlight {
+	if sessionClient.qr != nil {
+		return sessionClient.qr
 	}
 
-	// Return a message indicating QR code is being generated
-	return "", fmt.Errorf("QR code is being generated, please try again in a few seconds")
+	genCtx, cancelGeneration := context.WithTimeout(context.Background(), qrGenerationTimeout)
+	flight := newQRFlight(cancelGeneration)
+	sessionClient.qr = flight
+
+	go func() {
+		err := msm.handleQRCodeGeneration(genCtx, sessionID, sessionClient, flight)
+		if err == nil {
+			err = errQRGenerationEnded
+		}
+
+		msm.mutex.Lock()
+		if sessionClient.qr == flight {
+			sessionClient.qr = nil
+		}
+		msm.mutex.Unlock()
+
+		flight.finish(err)
+		cancelGeneration()
+	}()
+
+	return flight
 }
 
-// handleQRCodeGeneration handles the asynchronous QR code generation
-func (msm *MultiSessionManager) handleQRCodeGeneration(ctx context.Context, sessionID domain.SessionID, sessionClient *SessionClient) {
+// handleQRCodeGeneration handles the asynchronous QR code generation, handing
+// every code to the callers waiting on flight. It returns why no code could be
+// generated, or nil once pairing ended.
+func (msm *MultiSessionManager) handleQRCodeGeneration(ctx context.Context, sessionID domain.SessionID, sessionClient *SessionClient, flight *qrFlight) error {
 	// Get QR code channel BEFORE connecting (this is the correct order)
 	qrChan, err := sessionClient.Client.GetQRChannel(ctx)
 	if err != nil {
-		log.Error().
-			Err(err).
-			Str("session_id", sessionID.String()).
-			Msg("Failed to get QR channel")
-		return
+		switch {
+		case errors.Is(err, whatsmeow.ErrQRStoreContainsID):
+			// The device was paired in the meantime, so no QR code is needed
+			sessionClient.log.Info().Msg("Device already paired, connecting directly instead of generating QR code")
+
+			msm.stateWriter.QueueClearQRCode(sessionID)
+			if err := sessionClient.Client.Connect(); err != nil {
+				sessionClient.log.Error().
+					Err(err).
+					Msg("Failed to connect to WhatsApp")
+				msm.updateSessionStatus(sessionID, StatusError)
+				return fmt.Errorf("failed to connect paired session: %w", err)
+			}
+			return fmt.Errorf("session %s is already paired, connecting", sessionID)
+
+		case errors.Is(err, whatsmeow.ErrQRAlreadyConnected):
+			// The client is already connecting or connected, its events drive the status
+			sessionClient.log.Info().Msg("Client already connected, skipping QR code generation")
+			return fmt.Errorf("session %s is already connected", sessionID)
+
+		default:
+			sessionClient.log.Error().
+				Err(err).
+				Msg("Failed to get QR channel")
+			msm.updateSessionStatus(sessionID, StatusError)
+			return fmt.Errorf("failed to get QR channel: %w", err)
+		}
 	}
 
 	// Connect client AFTER getting QR channel
 	if err := sessionClient.Client.Connect(); err != nil {
-		log.Error().
+		sessionClient.log.Error().
 			Err(err).
-			Str("session_id", sessionID.String()).
 			Msg("Failed to connect client for QR generation")
-		return
+		msm.updateSessionStatus(sessionID, StatusError)
+		return fmt.Errorf("failed to connect client: %w", err)
 	}
 
+	// Cancelling ctx closes the channel without a final event
+	defer func() {
+		if ctx.Err() != nil {
+			sessionClient.log.Info().Msg("QR code generation cancelled")
+			msm.stateWriter.QueueClearQRCode(sessionID)
+		}
+	}()
+
 	// Wait for QR code events
 	for evt := range qrChan {
 		switch evt.Event {
@@ -324,66 +841,50 @@ func (msm *MultiSessionManager) handleQRCodeGeneration(ctx context.Context, sess
 			// Generate base64 QR code image
 			qrCodeBase64, err := msm.generateQRCodeImage(evt.Code)
 			if err != nil {
-				log.Error().
+				sessionClient.log.Error().
 					Err(err).
-					Str("session_id", sessionID.String()).
 					Msg("Failed to generate QR code image")
 				continue
 			}
 
 			// Store QR code in database
-			log.Info().
-				Str("session_id", sessionID.String()).
+			sessionClient.log.Info().
 				Str("qr_code_length", fmt.Sprintf("%d", len(qrCodeBase64))).
 				Msg("Attempting to store QR code in database")
 
-			if err := msm.sessionRepo.SetQRCode(ctx, sessionID, qrCodeBase64); err != nil {
-				log.Error().
-					Err(err).
-					Str("session_id", sessionID.String()).
-					Str("qr_code", qrCodeBase64).
-					Msg("Failed to store QR code in database")
-			} else {
-				log.Info().
-					Str("session_id", sessionID.String()).
-					Msg("QR code generated and stored successfully")
+			// WhatsApp keeps the first code valid longer than the following ones
+			validFor := evt.Timeout
+			if validFor <= 0 {
+				validFor = msm.qrRotation
 			}
+			expiresAt := time.Now().Add(validFor)
+
+			msm.stateWriter.QueueQRCode(sessionID, qrCodeBase64, expiresAt)
+			flight.publish(qrCodeBase64, expiresAt)
+			sessionClient.log.Info().Msg("QR code generated and queued for storage")
 
 		case "success":
-			log.Info().
-				Str("session_id", sessionID.String()).
-				Msg("QR code pairing successful")
+			sessionClient.log.Info().Msg("QR code pairing successful")
 
 			// Clear QR code from database
-			if err := msm.sessionRepo.SetQRCode(ctx, sessionID, ""); err != nil {
-				log.Error().
-					Err(err).
-					Str("session_id", sessionID.String()).
-					Msg("Failed to clear QR code from database")
-			}
-			return
+			msm.stateWriter.QueueClearQRCode(sessionID)
+			return nil
 
 		case "timeout":
-			log.Warn().
-				Str("session_id", sessionID.String()).
-				Msg("QR code timeout")
+			sessionClient.log.Warn().Msg("QR code timeout")
 
 			// Clear QR code from database
-			if err := msm.sessionRepo.SetQRCode(ctx, sessionID, ""); err != nil {
-				log.Error().
-					Err(err).
-					Str("session_id", sessionID.String()).
-					Msg("Failed to clear QR code from database")
-			}
-			return
+			msm.stateWriter.QueueClearQRCode(sessionID)
+			return nil
 
 		default:
-			log.Info().
-				Str("session_id", sessionID.String()).
+			sessionClient.log.Info().
 				Str("event", evt.Event).
 				Msg("QR code event")
 		}
 	}
+
+	return nil
 }
 
 // generateQRCodeImage generates a base64 encoded QR code image
@@ -414,6 +915,7 @@ func (msm *MultiSessionManager) IsSessionConnected(sessionID domain.SessionID) b
 func (msm *MultiSessionManager) PairPhone(ctx context.Context, sessionID domain.SessionID, phoneNumber string) (string, error) {
 	msm.mutex.RLock()
 	sessionClient, exists := msm.sessions[sessionID]
+	pairClient, pairDisplay := msm.pairClient, msm.pairDisplay
 	msm.mutex.RUnlock()
 
 	if !exists {
@@ -425,10 +927,11 @@ func (msm *MultiSessionManager) PairPhone(ctx context.Context, sessionID domain.
 	}
 
 	// Use whatsmeow's PairPhone method
-	linkingCode, err := sessionClient.Client.PairPhone(ctx, phoneNumber, true, whatsmeow.PairClientChrome, "Chrome (Linux)")
+	linkingCode, err := sessionClient.Client.PairPhone(ctx, phoneNumber, true, pairClient, pairDisplay)
 	if err != nil {
 		return "", fmt.Errorf("failed to initiate phone pairing: %w", err)
 	}
+	msm.startPhonePairing(sessionID, phoneNumber)
 
 	log.Info().
 		Str("session_id", sessionID.String()).
@@ -439,6 +942,99 @@ func (msm *MultiSessionManager) PairPhone(ctx context.Context, sessionID domain.
 	return linkingCode, nil
 }
 
+// CancelPairing aborts an unfinished QR code or phone code pairing, stopping
+// the client waiting for it and leaving the session disconnected
+func (msm *MultiSessionManager) CancelPairing(ctx context.Context, sessionID domain.SessionID) error {
+	if _, err := msm.sessionRepo.GetByID(ctx, sessionID); err != nil {
+		return err
+	}
+
+	msm.mutex.Lock()
+	defer msm.mutex.Unlock()
+
+	sessionClient, exists := msm.sessions[sessionID]
+	if !exists || sessionClient.Client == nil {
+		return domain.NewBusinessError("no pairing in progress")
+	}
+	if sessionClient.Client.Store.ID != nil {
+		return domain.NewBusinessError("session is already paired")
+	}
+
+	if err := msm.cleanupSessionUnsafe(sessionID); err != nil {
+		return err
+	}
+	msm.stateWriter.QueueClearQRCode(sessionID)
+	msm.stateWriter.QueueStatus(sessionID, domain.StatusDisconnected)
+
+	log.Info().Str("session_id", sessionID.String()).Msg("Pairing cancelled")
+	return nil
+}
+
+// SessionIdentity is the WhatsApp account a session is paired with
+type SessionIdentity struct {
+	JID      string `json:"jid"`
+	Phone    string `json:"phone"`
+	PushName string `json:"push_name"`
+	Platform string `json:"platform"`
+}
+
+// GetIdentity returns the account a session is paired with. Sessions that
+// are not started or not paired yet have no identity.
+func (msm *MultiSessionManager) GetIdentity(sessionID domain.SessionID) (*SessionIdentity, error) {
+	msm.mutex.RLock()
+	defer msm.mutex.RUnlock()
+
+	sessionClient, exists := msm.sessions[sessionID]
+	if !exists || sessionClient.Client == nil || sessionClient.Client.Store.ID == nil {
+		return nil, domain.NewBusinessError("session is not authenticated")
+	}
+
+	device := sessionClient.Client.Store
+	return &SessionIdentity{
+		JID:      device.ID.ToNonAD().String(),
+		Phone:    device.ID.User,
+		PushName: device.PushName,
+		Platform: device.Platform,
+	}, nil
+}
+
+// RefreshSessionSettings updates the settings a running session caches after
+// the session was changed in the database
+func (msm *MultiSessionManager) RefreshSessionSettings(session *domain.Session) {
+	msm.mutex.Lock()
+	defer msm.mutex.Unlock()
+
+	if sessionClient, running := msm.sessions[session.ID]; running {
+		sessionClient.dailySendQuota = session.DailySendQuota
+		sessionClient.defaultEphemeralSeconds = session.DefaultEphemeralSeconds
+	}
+}
+
+// IsBusinessAccount reports whether a session is paired with a WhatsApp
+// Business account, asking WhatsApp when the pairing did not record it
+func (msm *MultiSessionManager) IsBusinessAccount(sessionID domain.SessionID) (bool, error) {
+	client, err := msm.GetClient(sessionID)
+	if err != nil {
+		return false, err
+	}
+
+	if client.Store.ID == nil {
+		return false, domain.NewBusinessError("session is not authenticated")
+	}
+	if client.Store.BusinessName != "" {
+		return true, nil
+	}
+
+	profile, err := client.GetBusinessProfile(client.Store.ID.ToNonAD())
+	if errors.Is(err, whatsmeow.ErrIQNotFound) {
+		// WhatsApp has no business profile for personal accounts
+		return false, nil
+	} else if err != nil {
+		return false, fmt.Errorf("failed to get business profile: %w", err)
+	}
+	return profile != nil, nil
+}
+
 // GetSessionInfo returns detailed information about a session
 func (msm *MultiSessionManager) GetSessionInfo(sessionID domain.SessionID) map[string]any {
 	msm.mutex.RLock()
@@ -447,16 +1043,28 @@ func (msm *MultiSessionManager) GetSessionInfo(sessionID domain.SessionID) map[s
 	sessionClient, exists := msm.sessions[sessionID]
 	if !exists {
 		return map[string]any{
-			"session_id": sessionID.String(),
-			"exists":     false,
+			"session_id":  sessionID.String(),
+			"exists":      false,
+			"initialized": false,
+			"status":      string(StatusNotInitialized),
 		}
 	}
 
 	info := map[string]any{
-		"session_id": sessionID.String(),
-		"exists":     true,
-		"status":     string(sessionClient.Status),
-		"last_seen":  sessionClient.LastSeen,
+		"session_id":  sessionID.String(),
+		"exists":      true,
+		"initialized": true,
+		"status":      string(sessionClient.Status),
+		"last_seen":   sessionClient.LastSeen,
+	}
+
+	if sessionClient.Client != nil {
+		info["authenticated"] = sessionClient.Client.Store.ID != nil
+		info["connected"] = sessionClient.Client.IsConnected()
+	}
+
+	if !sessionClient.LastMessageAt.IsZero() {
+		info["last_message_at"] = sessionClient.LastMessageAt
 	}
 
 	// Add device info if available
@@ -496,6 +1104,17 @@ func (msm *MultiSessionManager) cleanupSessionUnsafe(sessionID domain.SessionID)
 		sessionClient.Client.Disconnect()
 	}
 
+	msm.chatTimers.forget(sessionID)
+
+	// Let the event and delivery workers exit once queued events are processed
+	sessionClient.events.close()
+	sessionClient.deliveries.close()
+
+	// Stop pairing, waiting callers are released with an error
+	if sessionClient.qr != nil {
+		sessionClient.qr.cancel()
+	}
+
 	// Remove from sessions map
 	delete(msm.sessions, sessionID)
 
@@ -507,8 +1126,7 @@ func (msm *MultiSessionManager) cleanupSessionUnsafe(sessionID domain.SessionID)
 func (msm *MultiSessionManager) handleSessionConnection(ctx context.Context, sessionID domain.SessionID, sessionClient *SessionClient) {
 	defer func() {
 		if r := recover(); r != nil {
-			log.Error().
-				Str("session_id", sessionID.String()).
+			sessionClient.log.Error().
 				Interface("panic", r).
 				Msg("Panic in session connection handler")
 		}
@@ -523,22 +1141,20 @@ func (msm *MultiSessionManager) handleSessionConnection(ctx context.Context, ses
 	// Check if device has stored ID (already logged in)
 	if sessionClient.Device.ID == nil {
 		// No ID stored, new login - need QR code
-		log.Info().
-			Str("session_id", sessionID.String()).
-			Msg("New device, QR code authentication required")
+		sessionClient.log.Info().Msg("New device, QR code authentication required")
 
-		// Start QR code process
-		msm.handleQRCodeGeneration(ctx, sessionID, sessionClient)
+		// Start QR code process, shared with any /qr request. The connection
+		// counts as a waiter so callers giving up do not stop it.
+		msm.mutex.Lock()
+		msm.startQRGenerationUnsafe(sessionID, sessionClient).join()
+		msm.mutex.Unlock()
 	} else {
 		// Device already has ID, try to connect directly
-		log.Info().
-			Str("session_id", sessionID.String()).
-			Msg("Device has stored ID, attempting direct connection")
+		sessionClient.log.Info().Msg("Device has stored ID, attempting direct connection")
 
 		if err := sessionClient.Client.Connect(); err != nil {
-			log.Error().
+			sessionClient.log.Error().
 				Err(err).
-				Str("session_id", sessionID.String()).
 				Msg("Failed to connect to WhatsApp")
 
 			msm.updateSessionStatus(sessionID, StatusError)
@@ -549,9 +1165,9 @@ func (msm *MultiSessionManager) handleSessionConnection(ctx context.Context, ses
 	// Wait for kill signal or context cancellation
 	select {
 	case <-sessionClient.KillChannel:
-		log.Info().Str("session_id", sessionID.String()).Msg("Session received kill signal")
+		sessionClient.log.Info().Msg("Session received kill signal")
 	case <-ctx.Done():
-		log.Info().Str("session_id", sessionID.String()).Msg("Session context cancelled")
+		sessionClient.log.Info().Msg("Session context cancelled")
 	}
 
 	// Cleanup
@@ -565,42 +1181,36 @@ func (msm *MultiSessionManager) updateSessionStatus(sessionID domain.SessionID,
 	defer msm.mutex.Unlock()
 
 	if sessionClient, exists := msm.sessions[sessionID]; exists {
+		if sessionClient.Status != status {
+			sessionClient.log.Debug().
+				Str("from", string(sessionClient.Status)).
+				Str("to", string(status)).
+				Msg("Session status changed")
+		}
 		sessionClient.Status = status
 		sessionClient.LastSeen = time.Now()
 
-		// Update status in database asynchronously to avoid blocking
-		go func() {
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-
-			// Convert ConnectionStatus to domain.Status
-			var domainStatus domain.Status
-			switch status {
-			case StatusDisconnected:
-				domainStatus = domain.StatusDisconnected
-			case StatusConnecting:
-				domainStatus = domain.StatusConnecting
-			case StatusConnected:
-				domainStatus = domain.StatusConnected
-			case StatusError:
-				domainStatus = domain.StatusError
-			default:
-				domainStatus = domain.StatusDisconnected
-			}
+		// Wake up anyone waiting for a status change
+		close(sessionClient.statusChanged)
+		sessionClient.statusChanged = make(chan struct{})
+
+		// Persist through the state writer so a slow database never blocks
+		// and rapid status flaps collapse into a single write
+		var domainStatus domain.Status
+		switch status {
+		case StatusDisconnected:
+			domainStatus = domain.StatusDisconnected
+		case StatusConnecting:
+			domainStatus = domain.StatusConnecting
+		case StatusConnected:
+			domainStatus = domain.StatusConnected
+		case StatusError:
+			domainStatus = domain.StatusError
+		default:
+			domainStatus = domain.StatusDisconnected
+		}
 
-			if err := msm.sessionRepo.UpdateStatus(ctx, sessionID, domainStatus); err != nil {
-				log.Error().
-					Err(err).
-					Str("session_id", sessionID.String()).
-					Str("status", string(status)).
-					Msg("Failed to update session status in database")
-			} else {
-				log.Info().
-					Str("session_id", sessionID.String()).
-					Str("status", string(status)).
-					Msg("Session status updated in database")
-			}
-		}()
+		msm.stateWriter.QueueStatus(sessionID, domainStatus)
 	}
 }
 
@@ -612,50 +1222,344 @@ func (msm *MultiSessionManager) setupEventHandlers(sessionID domain.SessionID, s
 	sessionClient.Client.AddEventHandler(func(evt any) {
 		switch v := evt.(type) {
 		case *events.Connected:
-			log.Info().Str("session_id", sessionID.String()).Msg("WhatsApp connected")
+			sessionClient.log.Info().Msg("WhatsApp connected")
+			msm.recordConnectionEvent(sessionID, v)
 			msm.updateSessionStatus(sessionID, StatusConnected)
+			msm.releaseOfflineSends(sessionID)
+			go msm.applyPrivacySettings(sessionID, sessionClient.Client)
 
 		case *events.Disconnected:
-			log.Info().Str("session_id", sessionID.String()).Msg("WhatsApp disconnected")
+			sessionClient.log.Info().Msg("WhatsApp disconnected")
+			msm.recordConnectionEvent(sessionID, v)
 			msm.updateSessionStatus(sessionID, StatusDisconnected)
 
+		case *events.StreamReplaced, *events.LoggedOut, *events.ConnectFailure, *events.ClientOutdated,
+			*events.TemporaryBan, *events.StreamError, *events.KeepAliveTimeout, *events.KeepAliveRestored:
+			msm.recordConnectionEvent(sessionID, v)
+
+		case *events.Message:
+			// WhatsApp may redeliver a message on reconnect
+			if msm.dedup.seen(sessionID, v.Info.ID) {
+				sessionClient.log.Debug().Str("message_id", v.Info.ID).Msg("Dropped redelivered message")
+				return
+			}
+
+			msm.recordChatTimer(sessionID, v)
+			if !v.Info.IsFromMe {
+				msm.touchLastMessage(sessionClient)
+			}
+
+			chat := v.Info.Chat.String()
+			sessionClient.events.submit(chat, func() {
+				msm.storeMessage(sessionID, v)
+
+				event := mapMessageEvent(sessionID, v)
+				sessionClient.deliveries.submit(chat, func() {
+					if msgEvent, ok := event.(domain.MessageEvent); ok && extractDownloadable(v.Message) != nil {
+						msm.deliverMediaMessage(sessionID, sessionClient.Client, v, msgEvent)
+					} else {
+						msm.emitEvent(event)
+					}
+				})
+			})
+
+		case *events.Receipt:
+			msm.recordReceipt(sessionID, v)
+			// Receipts from other people advance the stored status of our sent messages
+			if !v.IsFromMe {
+				sessionClient.events.submit(v.Chat.String(), func() { msm.recordReceiptStatus(sessionID, v) })
+			}
+
+		case *events.Archive, *events.Mute, *events.Pin, *events.MarkChatAsRead:
+			if event, ok := mapChatStateEvent(sessionID, v); ok {
+				sessionClient.deliveries.submit(event.Chat, func() { msm.emitEvent(event) })
+			}
+
+		case *events.HistorySync:
+			go msm.handleHistorySync(sessionID, sessionClient.Client, v)
+
+		case *events.PairError:
+			sessionClient.log.Error().Err(v.Error).Str("jid", v.ID.String()).Msg("WhatsApp pairing failed")
+			msm.finishPairing(sessionID, sessionClient, v)
+
 		case *events.PairSuccess:
+			msm.finishPairing(sessionID, sessionClient, v)
+
 			jid := v.ID.String()
-			log.Info().
-				Str("session_id", sessionID.String()).
+			sessionClient.log.Info().
 				Str("jid", jid).
 				Msg("WhatsApp pairing successful")
 
 			// Update session with JID in database
 			ctx := context.Background()
 			if err := msm.sessionRepo.SetWAJID(ctx, sessionID, jid); err != nil {
-				log.Error().
+				sessionClient.log.Error().
 					Err(err).
-					Str("session_id", sessionID.String()).
 					Str("jid", jid).
 					Msg("Failed to update session JID in database")
 			} else {
-				log.Info().
-					Str("session_id", sessionID.String()).
+				sessionClient.log.Info().
 					Str("jid", jid).
 					Msg("Session JID updated in database")
 			}
 
 		default:
-			// Handle other events as needed
-			_ = v
+			if !msm.captureUnknownEvents {
+				return
+			}
+			if event, ok := mapUnknownEvent(sessionID, v); ok {
+				sessionClient.deliveries.submit("", func() { msm.emitEvent(event) })
+			}
+		}
+	})
+}
+
+// handleHistorySync persists the conversations of a history sync payload
+// when the session has history capture enabled
+func (msm *MultiSessionManager) handleHistorySync(sessionID domain.SessionID, client *whatsmeow.Client, evt *events.HistorySync) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	session, err := msm.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionID.String()).Msg("Failed to get session for history sync")
+		return
+	}
+
+	if !session.CaptureHistory {
+		log.Debug().Str("session_id", sessionID.String()).Msg("History capture disabled, ignoring history sync")
+		return
+	}
+
+	conversations := evt.Data.GetConversations()
+	var messages []*domain.Message
+
+	for _, conversation := range conversations {
+		chatJID, err := types.ParseJID(conversation.GetID())
+		if err != nil {
+			log.Warn().
+				Err(err).
+				Str("session_id", sessionID.String()).
+				Str("chat", conversation.GetID()).
+				Msg("Skipping history sync conversation with invalid JID")
+			continue
 		}
+
+		for _, historyMsg := range conversation.GetMessages() {
+			msgEvt, err := client.ParseWebMessage(chatJID, historyMsg.GetMessage())
+			if err != nil {
+				log.Debug().Err(err).Str("session_id", sessionID.String()).Msg("Skipping unparseable history message")
+				continue
+			}
+
+			// Protocol messages (edits, revokes, key shares) are not conversation content
+			if msgEvt.Message == nil || msgEvt.Message.GetProtocolMessage() != nil {
+				continue
+			}
+
+			messages = append(messages, newStoredMessage(sessionID, msgEvt))
+		}
+	}
+
+	if err := msm.messageRepo.SaveBatch(ctx, messages); err != nil {
+		log.Error().
+			Err(err).
+			Str("session_id", sessionID.String()).
+			Int("messages", len(messages)).
+			Msg("Failed to persist history sync messages")
+		return
+	}
+
+	log.Info().
+		Str("session_id", sessionID.String()).
+		Str("sync_type", evt.Data.GetSyncType().String()).
+		Int("conversations", len(conversations)).
+		Int("messages", len(messages)).
+		Msg("History sync captured")
+
+	msm.emitEvent(domain.HistorySyncEvent{
+		SessionID:     sessionID,
+		EventType:     domain.EventTypeHistorySync,
+		SyncType:      evt.Data.GetSyncType().String(),
+		ChunkOrder:    evt.Data.GetChunkOrder(),
+		Progress:      evt.Data.GetProgress(),
+		Conversations: len(conversations),
+		Messages:      len(messages),
+		Timestamp:     time.Now(),
 	})
 }
 
-// Shutdown gracefully shuts down all sessions
+// isEventIgnored reports whether an event belongs to a chat the session ignores
+func (msm *MultiSessionManager) isEventIgnored(event domain.Event) bool {
+	var chat string
+	switch e := event.(type) {
+	case domain.MessageEvent:
+		chat = e.To
+	case domain.MessageEditEvent:
+		chat = e.To
+	case domain.MessageRevokeEvent:
+		chat = e.To
+	case domain.ChatStateEvent:
+		chat = e.Chat
+	default:
+		return false
+	}
+
+	msm.mutex.RLock()
+	defer msm.mutex.RUnlock()
+
+	sessionClient, exists := msm.sessions[event.GetSessionID()]
+	return exists && sessionClient.ignoredChats[chat]
+}
+
+// isEventSubscribed reports whether the session emits events of this type.
+// Sessions without their own list use the instance default.
+func (msm *MultiSessionManager) isEventSubscribed(event domain.Event) bool {
+	msm.mutex.RLock()
+	defer msm.mutex.RUnlock()
+
+	subscribed := msm.defaultEvents
+	if sessionClient, exists := msm.sessions[event.GetSessionID()]; exists && len(sessionClient.subscribedEvents) > 0 {
+		subscribed = sessionClient.subscribedEvents
+	}
+	if len(subscribed) == 0 {
+		return true
+	}
+	return subscribed[string(event.GetEventType())]
+}
+
+// SetChatIgnored adds or removes a chat from a session's ignore list and
+// returns the resulting list
+func (msm *MultiSessionManager) SetChatIgnored(ctx context.Context, sessionID domain.SessionID, chat string, ignored bool) ([]string, error) {
+	session, err := msm.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if ignored {
+		session.IgnoreChat(chat)
+	} else {
+		session.UnignoreChat(chat)
+	}
+
+	chats := session.IgnoredChatList()
+	if err := msm.sessionRepo.SetIgnoredChats(ctx, sessionID, chats); err != nil {
+		return nil, err
+	}
+
+	msm.mutex.Lock()
+	if sessionClient, exists := msm.sessions[sessionID]; exists {
+		sessionClient.ignoredChats = toSet(chats)
+	}
+	msm.mutex.Unlock()
+
+	return chats, nil
+}
+
+// SetSubscribedEvents replaces the event types a session emits and returns
+// the resulting list. A running session applies it to the next event.
+func (msm *MultiSessionManager) SetSubscribedEvents(ctx context.Context, sessionID domain.SessionID, events []string) ([]string, error) {
+	session, err := msm.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := session.SetSubscribedEvents(events); err != nil {
+		return nil, err
+	}
+
+	subscribed := session.SubscribedEvents()
+	if err := msm.sessionRepo.SetEvents(ctx, sessionID, subscribed); err != nil {
+		return nil, err
+	}
+
+	msm.mutex.Lock()
+	if sessionClient, exists := msm.sessions[sessionID]; exists {
+		sessionClient.subscribedEvents = toSet(subscribed)
+	}
+	msm.mutex.Unlock()
+
+	return subscribed, nil
+}
+
+// toSet converts a list of strings into a lookup set
+func toSet(items []string) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		set[item] = true
+	}
+	return set
+}
+
+// emitEvent publishes a domain event produced by a session to its webhook
+func (msm *MultiSessionManager) emitEvent(event domain.Event) {
+	if msm.isEventIgnored(event) {
+		log.Debug().
+			Str("session_id", event.GetSessionID().String()).
+			Str("event_type", string(event.GetEventType())).
+			Msg("Event from ignored chat suppressed")
+		return
+	}
+
+	if !msm.isEventSubscribed(event) {
+		log.Debug().
+			Str("session_id", event.GetSessionID().String()).
+			Str("event_type", string(event.GetEventType())).
+			Msg("Event type not subscribed, suppressed")
+		return
+	}
+
+	log.Info().
+		Str("session_id", event.GetSessionID().String()).
+		Str("event_type", string(event.GetEventType())).
+		Time("timestamp", event.GetTimestamp()).
+		Msg("Session event emitted")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+
+	delivered, err := msm.dispatchToSessionWebhook(ctx, event.GetSessionID(), event)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("session_id", event.GetSessionID().String()).
+			Str("event_type", string(event.GetEventType())).
+			Msg("Failed to deliver session event")
+	} else if !delivered {
+		log.Debug().
+			Str("session_id", event.GetSessionID().String()).
+			Str("event_type", string(event.GetEventType())).
+			Msg("No webhook configured, dropping session event")
+	}
+}
+
+// dispatchToSessionWebhook posts a payload to the session's webhook, or the
+// global one when the session has none, reporting false when neither is set
+func (msm *MultiSessionManager) dispatchToSessionWebhook(ctx context.Context, sessionID domain.SessionID, payload any) (bool, error) {
+	// A session that cannot be loaded falls back to the global webhook settings
+	session, _ := msm.sessionRepo.GetByID(ctx, sessionID)
+
+	target := msm.webhooks.ResolveTarget(session)
+	if target.URL == "" {
+		return false, nil
+	}
+
+	return true, msm.webhooks.Dispatch(ctx, target, payload)
+}
+
+// Shutdown gracefully shuts down all sessions, then writes the session state
+// they left behind. It waits for that write until ctx is done and is safe to
+// call more than once.
 func (msm *MultiSessionManager) Shutdown(ctx context.Context) error {
 	msm.mutex.Lock()
-	defer msm.mutex.Unlock()
 
 	log.Info().Int("session_count", len(msm.sessions)).Msg("Shutting down all sessions")
 
-	for sessionID := range msm.sessions {
+	msm.shutdownOnce.Do(func() { close(msm.shutdown) })
+
+	queues := make([]*sessionEventQueue, 0, 2*len(msm.sessions))
+	for sessionID, sessionClient := range msm.sessions {
+		queues = append(queues, sessionClient.events, sessionClient.deliveries)
 		if err := msm.cleanupSessionUnsafe(sessionID); err != nil {
 			log.Error().
 				Err(err).
@@ -664,5 +1568,23 @@ func (msm *MultiSessionManager) Shutdown(ctx context.Context) error {
 		}
 	}
 
+	msm.mutex.Unlock()
+
+	// Events still queued may update the session state, so they are processed
+	// before the state writer flushes. Queued webhook deliveries are sent too.
+	for _, queue := range queues {
+		if err := queue.wait(ctx); err != nil {
+			log.Warn().
+				Err(err).
+				Str("session_id", queue.sessionID.String()).
+				Msg("Gave up waiting for session events during shutdown")
+			break
+		}
+	}
+
+	if err := msm.stateWriter.Stop(ctx); err != nil {
+		return fmt.Errorf("failed to write session state before shutdown: %w", err)
+	}
+
 	return nil
 }
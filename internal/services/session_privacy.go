@@ -0,0 +1,70 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"wazmeow/internal/domain"
+
+	"github.com/rs/zerolog/log"
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types"
+)
+
+// applyPrivacySettings applies the session's privacy flags after it connects.
+// A session that does not broadcast its presence is marked unavailable, one
+// that does is left to whatsmeow. Disabling read receipts turns them off
+// account-wide; enabling them again restores them only if the session was
+// the one that turned them off, leaving a choice made on the phone alone.
+func (msm *MultiSessionManager) applyPrivacySettings(sessionID domain.SessionID, client *whatsmeow.Client) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	session, err := msm.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionID.String()).Msg("Failed to get session for privacy settings")
+		return
+	}
+
+	if !session.BroadcastPresence {
+		if err := client.SendPresence(types.PresenceUnavailable); err != nil {
+			log.Warn().Err(err).Str("session_id", sessionID.String()).Msg("Failed to send unavailable presence")
+		}
+	}
+
+	current := client.GetPrivacySettings(ctx).ReadReceipts
+	switch {
+	case !session.SendReadReceipts && current != types.PrivacySettingNone:
+		if _, err := client.SetPrivacySetting(ctx, types.PrivacySettingTypeReadReceipts, types.PrivacySettingNone); err != nil {
+			log.Warn().
+				Err(err).
+				Str("session_id", sessionID.String()).
+				Msg("Failed to disable read receipts")
+			return
+		}
+		msm.setReadReceiptsDisabled(ctx, sessionID, true)
+
+		log.Info().Str("session_id", sessionID.String()).Msg("Read receipts disabled")
+
+	case session.SendReadReceipts && session.ReadReceiptsDisabled:
+		if current == types.PrivacySettingNone {
+			if _, err := client.SetPrivacySetting(ctx, types.PrivacySettingTypeReadReceipts, types.PrivacySettingAll); err != nil {
+				log.Warn().
+					Err(err).
+					Str("session_id", sessionID.String()).
+					Msg("Failed to restore read receipts")
+				return
+			}
+
+			log.Info().Str("session_id", sessionID.String()).Msg("Read receipts restored")
+		}
+		msm.setReadReceiptsDisabled(ctx, sessionID, false)
+	}
+}
+
+// setReadReceiptsDisabled records whether the session turned read receipts off
+func (msm *MultiSessionManager) setReadReceiptsDisabled(ctx context.Context, sessionID domain.SessionID, disabled bool) {
+	if err := msm.sessionRepo.SetReadReceiptsDisabled(ctx, sessionID, disabled); err != nil {
+		log.Warn().Err(err).Str("session_id", sessionID.String()).Msg("Failed to record read receipts state")
+	}
+}
@@ -0,0 +1,63 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"wazmeow/internal/domain"
+
+	"github.com/rs/zerolog/log"
+)
+
+// ProxyUpdateResult reports a session proxy change
+type ProxyUpdateResult struct {
+	SessionID domain.SessionID `json:"session_id"`
+	ProxyURL  string           `json:"proxy_url"` // credentials are redacted, empty when cleared
+	// The session is connected and keeps its current route until it reconnects
+	ReconnectRequired bool `json:"reconnect_required"`
+}
+
+// SetSessionProxy stores the proxy a session connects through and applies it
+// to the session's client. whatsmeow only uses the proxy for new connections,
+// so a connected session switches over on its next reconnect. An empty URL
+// clears the proxy.
+func (msm *MultiSessionManager) SetSessionProxy(ctx context.Context, sessionID domain.SessionID, proxyURL string) (*ProxyUpdateResult, error) {
+	session, err := msm.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := session.SetProxyURL(proxyURL); err != nil {
+		return nil, err
+	}
+	if err := msm.sessionRepo.SetProxyURL(ctx, sessionID, session.ProxyURL); err != nil {
+		return nil, err
+	}
+
+	result := &ProxyUpdateResult{SessionID: sessionID}
+	if session.ProxyURL != "" {
+		if parsed, err := url.Parse(session.ProxyURL); err == nil {
+			result.ProxyURL = parsed.Redacted()
+		}
+	}
+
+	msm.mutex.RLock()
+	sessionClient, exists := msm.sessions[sessionID]
+	msm.mutex.RUnlock()
+
+	if exists {
+		if err := sessionClient.Client.SetProxyAddress(session.ProxyURL); err != nil {
+			return nil, fmt.Errorf("failed to apply session proxy: %w", err)
+		}
+		result.ReconnectRequired = sessionClient.Client.IsConnected()
+	}
+
+	log.Info().
+		Str("session_id", sessionID.String()).
+		Str("proxy", result.ProxyURL).
+		Bool("reconnect_required", result.ReconnectRequired).
+		Msg("Session proxy updated")
+
+	return result, nil
+}
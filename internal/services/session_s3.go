@@ -0,0 +1,85 @@
+package services
+
+import (
+	"context"
+
+	"wazmeow/internal/domain"
+
+	"github.com/rs/zerolog/log"
+)
+
+// maskedSecret stands in for a stored S3 secret key in responses
+const maskedSecret = "[redacted]"
+
+// S3ConfigView is a session's media bucket configuration as returned by the
+// API, with the secret key masked
+type S3ConfigView struct {
+	SessionID     domain.SessionID `json:"session_id"`
+	Enabled       bool             `json:"enabled"`
+	Endpoint      string           `json:"endpoint"`
+	Region        string           `json:"region"`
+	Bucket        string           `json:"bucket"`
+	AccessKey     string           `json:"access_key"`
+	SecretKey     string           `json:"secret_key"` // masked, empty when unset
+	PathStyle     bool             `json:"path_style"`
+	PublicURL     string           `json:"public_url"`
+	RetentionDays int              `json:"retention_days"`
+	MediaDelivery string           `json:"media_delivery"`
+}
+
+// NewS3ConfigView builds the API view of a session's bucket configuration
+func NewS3ConfigView(session *domain.Session) *S3ConfigView {
+	cfg := session.S3Config()
+
+	view := &S3ConfigView{
+		SessionID:     session.ID,
+		Enabled:       cfg.Enabled,
+		Endpoint:      cfg.Endpoint,
+		Region:        cfg.Region,
+		Bucket:        cfg.Bucket,
+		AccessKey:     cfg.AccessKey,
+		PathStyle:     cfg.PathStyle,
+		PublicURL:     cfg.PublicURL,
+		RetentionDays: cfg.RetentionDays,
+		MediaDelivery: string(session.MediaDelivery),
+	}
+	if cfg.SecretKey != "" {
+		view.SecretKey = maskedSecret
+	}
+	return view
+}
+
+// SetSessionS3 replaces the media bucket settings of a session, keeping its
+// stored keys when none are given, and optionally its media delivery mode.
+// The s3 delivery mode requires S3 to be enabled. Inbound media picks up the
+// change with the next message.
+func (msm *MultiSessionManager) SetSessionS3(ctx context.Context, sessionID domain.SessionID, settings S3Settings, mediaDelivery string) (*S3ConfigView, error) {
+	session, err := msm.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := settings.apply(session); err != nil {
+		return nil, err
+	}
+	if mediaDelivery != "" {
+		if err := session.SetMediaDelivery(domain.MediaDelivery(mediaDelivery)); err != nil {
+			return nil, err
+		}
+	}
+	if session.MediaDelivery == domain.MediaDeliveryS3 && !session.S3Enabled {
+		return nil, domain.NewValidationError("s3 media delivery requires S3 to be enabled")
+	}
+
+	if err := msm.sessionRepo.SetS3Config(ctx, sessionID, session.S3Config(), session.MediaDelivery); err != nil {
+		return nil, err
+	}
+
+	log.Info().
+		Str("session_id", sessionID.String()).
+		Bool("enabled", session.S3Enabled).
+		Str("media_delivery", string(session.MediaDelivery)).
+		Msg("Session S3 configuration updated")
+
+	return NewS3ConfigView(session), nil
+}
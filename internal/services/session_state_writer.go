@@ -0,0 +1,160 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"wazmeow/internal/domain"
+
+	"github.com/rs/zerolog/log"
+)
+
+// maxPendingStateWrites bounds the number of sessions with unwritten state
+const maxPendingStateWrites = 1000
+
+// pendingState holds the latest unwritten status and QR code of a session
+type pendingState struct {
+	status      *domain.Status
+	qrCode      *string
+	qrExpiresAt time.Time
+}
+
+// sessionStateWriter persists session status and QR code changes in the
+// background. Callers never block on the database: writes are buffered per
+// session and coalesced, so only the latest value of each field is written.
+type sessionStateWriter struct {
+	sessionRepo domain.Repository
+
+	pending map[domain.SessionID]*pendingState
+	mutex   sync.Mutex
+	notify  chan struct{}
+
+	stop     chan struct{} // closed by Stop
+	stopOnce sync.Once
+	done     chan struct{} // closed once the final flush completed
+}
+
+// newSessionStateWriter creates a writer and starts its background loop,
+// which runs until Stop is called
+func newSessionStateWriter(sessionRepo domain.Repository) *sessionStateWriter {
+	sw := &sessionStateWriter{
+		sessionRepo: sessionRepo,
+		pending:     make(map[domain.SessionID]*pendingState),
+		notify:      make(chan struct{}, 1),
+		stop:        make(chan struct{}),
+		done:        make(chan struct{}),
+	}
+
+	go sw.run()
+
+	return sw
+}
+
+// Stop flushes the remaining state and stops the background loop, waiting
+// for the final flush until ctx is done. Updates queued after Stop are not
+// written.
+func (sw *sessionStateWriter) Stop(ctx context.Context) error {
+	sw.stopOnce.Do(func() { close(sw.stop) })
+
+	select {
+	case <-sw.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// QueueStatus schedules a status write, replacing any unwritten status
+func (sw *sessionStateWriter) QueueStatus(sessionID domain.SessionID, status domain.Status) {
+	sw.queue(sessionID, func(state *pendingState) { state.status = &status })
+}
+
+// QueueQRCode schedules a QR code write, replacing any unwritten QR code
+func (sw *sessionStateWriter) QueueQRCode(sessionID domain.SessionID, qrCode string, expiresAt time.Time) {
+	sw.queue(sessionID, func(state *pendingState) {
+		state.qrCode = &qrCode
+		state.qrExpiresAt = expiresAt
+	})
+}
+
+// QueueClearQRCode schedules clearing the QR code, replacing any unwritten QR code
+func (sw *sessionStateWriter) QueueClearQRCode(sessionID domain.SessionID) {
+	sw.QueueQRCode(sessionID, "", time.Time{})
+}
+
+func (sw *sessionStateWriter) queue(sessionID domain.SessionID, apply func(*pendingState)) {
+	sw.mutex.Lock()
+	state, exists := sw.pending[sessionID]
+	if !exists {
+		if len(sw.pending) >= maxPendingStateWrites {
+			sw.mutex.Unlock()
+			log.Warn().
+				Str("session_id", sessionID.String()).
+				Msg("Session state write buffer full, dropping update")
+			return
+		}
+		state = &pendingState{}
+		sw.pending[sessionID] = state
+	}
+	apply(state)
+	sw.mutex.Unlock()
+
+	select {
+	case sw.notify <- struct{}{}:
+	default:
+		// A flush is already scheduled and will pick this update up
+	}
+}
+
+func (sw *sessionStateWriter) run() {
+	defer close(sw.done)
+
+	for {
+		select {
+		case <-sw.notify:
+			sw.flush()
+		case <-sw.stop:
+			sw.flush()
+			return
+		}
+	}
+}
+
+// flush writes every pending session state to the database
+func (sw *sessionStateWriter) flush() {
+	sw.mutex.Lock()
+	batch := sw.pending
+	sw.pending = make(map[domain.SessionID]*pendingState)
+	sw.mutex.Unlock()
+
+	for sessionID, state := range batch {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+
+		if state.status != nil {
+			if err := sw.sessionRepo.UpdateStatus(ctx, sessionID, *state.status); err != nil {
+				log.Error().
+					Err(err).
+					Str("session_id", sessionID.String()).
+					Str("status", string(*state.status)).
+					Msg("Failed to update session status in database")
+			} else {
+				log.Info().
+					Str("session_id", sessionID.String()).
+					Str("status", string(*state.status)).
+					Msg("Session status updated in database")
+			}
+		}
+
+		if state.qrCode != nil {
+			if err := sw.sessionRepo.SetQRCode(ctx, sessionID, *state.qrCode, state.qrExpiresAt); err != nil {
+				log.Error().
+					Err(err).
+					Str("session_id", sessionID.String()).
+					Msg("Failed to store QR code in database")
+			}
+		}
+
+		cancel()
+	}
+}
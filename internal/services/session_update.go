@@ -0,0 +1,175 @@
+package services
+
+import (
+	"context"
+	"net/url"
+
+	"wazmeow/internal/domain"
+
+	"github.com/rs/zerolog/log"
+)
+
+// UpdateSessionRequest represents a partial update of a session's settings.
+// Nil fields are left unchanged.
+type UpdateSessionRequest struct {
+	Name            *string `json:"name,omitempty"`
+	WebhookURL      *string `json:"webhook_url,omitempty"`
+	Timezone        *string `json:"timezone,omitempty"`
+	MediaDelivery   *string `json:"media_delivery,omitempty"`
+	CaptureHistory  *bool   `json:"capture_history,omitempty"`
+	WebhookCompress *bool   `json:"webhook_compress,omitempty"`
+
+	SendReadReceipts  *bool `json:"send_read_receipts,omitempty"`
+	BroadcastPresence *bool `json:"broadcast_presence,omitempty"`
+
+	DefaultEphemeralSeconds *int `json:"default_ephemeral_seconds,omitempty"`
+	DailySendQuota          *int `json:"daily_send_quota,omitempty"`
+
+	// Replaces the bucket settings, keys omitted keep the stored ones
+	S3 *S3Settings `json:"s3,omitempty"`
+}
+
+// S3Settings configures the bucket inbound media is uploaded to when the
+// media delivery mode is s3
+type S3Settings struct {
+	Enabled   bool   `json:"enabled"`
+	Endpoint  string `json:"endpoint"`
+	Region    string `json:"region,omitempty"`
+	Bucket    string `json:"bucket"`
+	AccessKey string `json:"access_key,omitempty"`
+	SecretKey string `json:"secret_key,omitempty"`
+	PathStyle bool   `json:"path_style,omitempty"`
+	PublicURL string `json:"public_url,omitempty"`
+	// Days uploaded media is meant to be kept, 0 keeps it
+	RetentionDays int `json:"retention_days,omitempty"`
+}
+
+// apply sets the settings on the session, keeping its stored keys when
+// none are given
+func (s S3Settings) apply(sess *domain.Session) error {
+	current := sess.S3Config()
+	cfg := domain.S3Config{
+		Enabled:   s.Enabled,
+		Endpoint:  s.Endpoint,
+		Region:    s.Region,
+		Bucket:    s.Bucket,
+		AccessKey: s.AccessKey,
+		SecretKey: s.SecretKey,
+		PathStyle: s.PathStyle,
+		PublicURL: s.PublicURL,
+
+		RetentionDays: s.RetentionDays,
+	}
+	if cfg.AccessKey == "" {
+		cfg.AccessKey = current.AccessKey
+	}
+	if cfg.SecretKey == "" {
+		cfg.SecretKey = current.SecretKey
+	}
+	return sess.SetS3Config(cfg)
+}
+
+// UpdateSessionUseCase handles updates of session settings
+type UpdateSessionUseCase struct {
+	sessionRepo domain.Repository
+}
+
+// NewUpdateSessionUseCase creates a new instance of UpdateSessionUseCase
+func NewUpdateSessionUseCase(sessionRepo domain.Repository) *UpdateSessionUseCase {
+	return &UpdateSessionUseCase{
+		sessionRepo: sessionRepo,
+	}
+}
+
+// Execute applies the request to the session and returns the updated session
+func (uc *UpdateSessionUseCase) Execute(ctx context.Context, id domain.SessionID, req UpdateSessionRequest) (*domain.Session, error) {
+	sess, err := uc.sessionRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Name != nil {
+		name, err := domain.NormalizeSessionName(*req.Name)
+		if err != nil {
+			return nil, err
+		}
+		if name != sess.Name {
+			exists, err := uc.sessionRepo.ExistsByName(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			if exists {
+				return nil, domain.ErrSessionAlreadyExists(name)
+			}
+			if err := sess.UpdateName(name); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if req.WebhookURL != nil {
+		if *req.WebhookURL != "" {
+			if _, err := url.ParseRequestURI(*req.WebhookURL); err != nil {
+				return nil, domain.NewValidationError("invalid webhook URL format")
+			}
+		}
+		sess.WebhookURL = *req.WebhookURL
+	}
+
+	if req.Timezone != nil {
+		if err := sess.SetTimezone(*req.Timezone); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.MediaDelivery != nil {
+		if err := sess.SetMediaDelivery(domain.MediaDelivery(*req.MediaDelivery)); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.CaptureHistory != nil {
+		sess.CaptureHistory = *req.CaptureHistory
+	}
+
+	if req.WebhookCompress != nil {
+		sess.WebhookCompress = *req.WebhookCompress
+	}
+
+	if req.SendReadReceipts != nil {
+		sess.SendReadReceipts = *req.SendReadReceipts
+	}
+
+	if req.BroadcastPresence != nil {
+		sess.BroadcastPresence = *req.BroadcastPresence
+	}
+
+	if req.DefaultEphemeralSeconds != nil {
+		if err := sess.SetDefaultEphemeral(*req.DefaultEphemeralSeconds); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.DailySendQuota != nil {
+		if err := sess.SetDailySendQuota(*req.DailySendQuota); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.S3 != nil {
+		if err := req.S3.apply(sess); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := uc.sessionRepo.Update(ctx, sess); err != nil {
+		log.Error().Err(err).Str("session_id", id.String()).Msg("Failed to update session")
+		return nil, err
+	}
+
+	log.Info().
+		Str("session_id", id.String()).
+		Msg("Session updated successfully")
+
+	return sess, nil
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+
+	"wazmeow/internal/domain"
+
+	"github.com/rs/zerolog/log"
+)
+
+// WebhookUpdateResult reports a session webhook change
+type WebhookUpdateResult struct {
+	SessionID  domain.SessionID `json:"session_id"`
+	WebhookURL string           `json:"webhook_url"` // empty when the global webhook is used
+	Events     []string         `json:"events"`      // empty means every event type
+	Signed     bool             `json:"signed"`      // payloads carry an X-Wazmeow-Signature header
+}
+
+// SetDefaultEvents sets the event types delivered for sessions that have no
+// list of their own. An empty list delivers every event type.
+func (msm *MultiSessionManager) SetDefaultEvents(events []string) {
+	msm.mutex.Lock()
+	defer msm.mutex.Unlock()
+
+	msm.defaultEvents = toSet(events)
+	delete(msm.defaultEvents, "")
+}
+
+// SetSessionWebhook replaces the webhook URL of a session and the event types
+// posted to it. A running session applies both to the next event. A nil
+// secret keeps the session's signing secret, an empty one falls back to the
+// global secret.
+func (msm *MultiSessionManager) SetSessionWebhook(ctx context.Context, sessionID domain.SessionID, webhookURL string, events []string, secret *string) (*WebhookUpdateResult, error) {
+	session, err := msm.sessionRepo.GetByID(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := session.SetWebhookURL(webhookURL); err != nil {
+		return nil, err
+	}
+	if err := session.SetSubscribedEvents(events); err != nil {
+		return nil, err
+	}
+
+	if secret != nil {
+		session.WebhookSecret = *secret
+	}
+
+	subscribed := session.SubscribedEvents()
+	if err := msm.sessionRepo.SetWebhook(ctx, sessionID, session.WebhookURL, subscribed, session.WebhookSecret); err != nil {
+		return nil, err
+	}
+
+	msm.mutex.Lock()
+	if sessionClient, exists := msm.sessions[sessionID]; exists {
+		sessionClient.subscribedEvents = toSet(subscribed)
+	}
+	msm.mutex.Unlock()
+
+	log.Info().
+		Str("session_id", sessionID.String()).
+		Strs("events", subscribed).
+		Msg("Session webhook updated")
+
+	return &WebhookUpdateResult{
+		SessionID:  sessionID,
+		WebhookURL: session.WebhookURL,
+		Events:     subscribed,
+		Signed:     msm.webhooks.ResolveTarget(session).Secret != "",
+	}, nil
+}
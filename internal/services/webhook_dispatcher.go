@@ -0,0 +1,296 @@
+package services
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"sync"
+	"time"
+
+	"wazmeow/internal/domain"
+
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// webhookBaseBackoff is the upper bound of the first retry delay
+	webhookBaseBackoff = 1 * time.Second
+	// webhookMaxBackoff caps the delay between two retries
+	webhookMaxBackoff = 30 * time.Second
+	// maxDeadLetters bounds the failed deliveries kept for inspection
+	maxDeadLetters = 1000
+	// maxDeadLetterBytes bounds the payload bytes the dead letters hold together
+	maxDeadLetterBytes = 16 << 20
+	// maxDeadLetterPayload is the largest payload a dead letter keeps, larger
+	// ones, such as inline media, are dropped and only their size is kept
+	maxDeadLetterPayload = 64 << 10
+
+	// WebhookSignatureHeader carries "sha256=" followed by the hex HMAC-SHA256
+	// of the JSON body, keyed with the webhook secret. The body is signed
+	// before gzip compression, so receivers verify the decompressed bytes.
+	WebhookSignatureHeader = "X-Wazmeow-Signature"
+)
+
+// WebhookDispatcher posts JSON payloads to webhook URLs with retries.
+// Deliveries that fail permanently or run out of retries are kept in a
+// bounded dead-letter list.
+type WebhookDispatcher struct {
+	client     *http.Client
+	globalURL  string
+	secret     string
+	compress   bool
+	retries    int
+	maxElapsed time.Duration
+
+	deadLetters     []DeadLetter
+	deadLetterBytes int // payload bytes held by deadLetters
+	deadMutex       sync.Mutex
+}
+
+// WebhookTarget describes where and how a session's webhooks are delivered
+type WebhookTarget struct {
+	URL      string
+	Compress bool   // gzip the JSON body and send Content-Encoding: gzip
+	Secret   string // sign the JSON body, unsigned when empty
+}
+
+// DeadLetter is a webhook delivery that was given up on
+type DeadLetter struct {
+	URL            string          `json:"url"`
+	Payload        json.RawMessage `json:"payload,omitempty"` // omitted when larger than maxDeadLetterPayload
+	PayloadBytes   int             `json:"payload_bytes"`
+	PayloadDropped bool            `json:"payload_dropped,omitempty"` // the payload was too large to keep
+	StatusCode     int             `json:"status_code,omitempty"`
+	Error          string          `json:"error"`
+	Attempts       int             `json:"attempts"`
+	FailedAt       time.Time       `json:"failed_at"`
+}
+
+// webhookStatusError is a non-2xx response from a webhook endpoint
+type webhookStatusError struct {
+	StatusCode int
+}
+
+func (e *webhookStatusError) Error() string {
+	return fmt.Sprintf("webhook returned status %d", e.StatusCode)
+}
+
+// NewWebhookDispatcher creates a new webhook dispatcher. globalURL and secret
+// are used for sessions that have none of their own, and compress gzips
+// payloads for every session. A delivery is retried up to retries times,
+// as long as maxElapsed has not passed since the first attempt.
+func NewWebhookDispatcher(globalURL string, timeout time.Duration, retries int, maxElapsed time.Duration, compress bool, secret string) *WebhookDispatcher {
+	if retries < 0 {
+		retries = 0
+	}
+	return &WebhookDispatcher{
+		client:     &http.Client{Timeout: timeout},
+		globalURL:  globalURL,
+		secret:     secret,
+		compress:   compress,
+		retries:    retries,
+		maxElapsed: maxElapsed,
+	}
+}
+
+// ResolveTarget returns the webhook target of a session. session may be nil,
+// in which case the global settings apply.
+func (wd *WebhookDispatcher) ResolveTarget(session *domain.Session) WebhookTarget {
+	target := WebhookTarget{
+		URL:      wd.globalURL,
+		Compress: wd.compress,
+		Secret:   wd.secret,
+	}
+	if session != nil {
+		if session.WebhookURL != "" {
+			target.URL = session.WebhookURL
+		}
+		if session.WebhookSecret != "" {
+			target.Secret = session.WebhookSecret
+		}
+		target.Compress = target.Compress || session.WebhookCompress
+	}
+	return target
+}
+
+// Dispatch posts payload as JSON to the target. Timeouts, connection errors
+// and 5xx responses are retried with exponential backoff and full jitter;
+// any other failure is permanent. Failed deliveries are dead-lettered.
+func (wd *WebhookDispatcher) Dispatch(ctx context.Context, target WebhookTarget, payload any) error {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode webhook payload: %w", err)
+	}
+
+	var signature string
+	if target.Secret != "" {
+		signature = signWebhookBody(target.Secret, raw)
+	}
+
+	body := raw
+	if target.Compress {
+		if body, err = gzipBody(raw); err != nil {
+			return fmt.Errorf("failed to compress webhook payload: %w", err)
+		}
+	}
+
+	start := time.Now()
+	attempts := 0
+	var lastErr error
+	for {
+		attempts++
+		if lastErr = wd.post(ctx, target, body, signature); lastErr == nil {
+			return nil
+		}
+
+		log.Warn().
+			Err(lastErr).
+			Str("url", target.URL).
+			Int("attempt", attempts).
+			Msg("Webhook delivery attempt failed")
+
+		if !isRetryableWebhookError(lastErr) || attempts > wd.retries {
+			break
+		}
+
+		delay := webhookBackoff(attempts)
+		if wd.maxElapsed > 0 && time.Since(start)+delay > wd.maxElapsed {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			wd.deadLetter(target, raw, lastErr, attempts)
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+	}
+
+	wd.deadLetter(target, raw, lastErr, attempts)
+	return fmt.Errorf("webhook delivery failed after %d attempts: %w", attempts, lastErr)
+}
+
+// DeadLetters returns the failed deliveries, oldest first
+func (wd *WebhookDispatcher) DeadLetters() []DeadLetter {
+	wd.deadMutex.Lock()
+	defer wd.deadMutex.Unlock()
+
+	letters := make([]DeadLetter, len(wd.deadLetters))
+	copy(letters, wd.deadLetters)
+	return letters
+}
+
+// deadLetter records a delivery that was given up on, dropping the oldest
+// entries once the list holds too many letters or payload bytes
+func (wd *WebhookDispatcher) deadLetter(target WebhookTarget, payload []byte, err error, attempts int) {
+	letter := DeadLetter{
+		URL:          target.URL,
+		PayloadBytes: len(payload),
+		Error:        err.Error(),
+		Attempts:     attempts,
+		FailedAt:     time.Now(),
+	}
+	if len(payload) > maxDeadLetterPayload {
+		letter.PayloadDropped = true
+	} else {
+		letter.Payload = payload
+	}
+	var statusErr *webhookStatusError
+	if errors.As(err, &statusErr) {
+		letter.StatusCode = statusErr.StatusCode
+	}
+
+	wd.deadMutex.Lock()
+	for len(wd.deadLetters) > 0 &&
+		(len(wd.deadLetters) >= maxDeadLetters || wd.deadLetterBytes+len(letter.Payload) > maxDeadLetterBytes) {
+		wd.deadLetterBytes -= len(wd.deadLetters[0].Payload)
+		wd.deadLetters[0] = DeadLetter{} // release the payload
+		wd.deadLetters = wd.deadLetters[1:]
+	}
+	wd.deadLetters = append(wd.deadLetters, letter)
+	wd.deadLetterBytes += len(letter.Payload)
+	wd.deadMutex.Unlock()
+
+	log.Error().
+		Err(err).
+		Str("url", target.URL).
+		Int("attempts", attempts).
+		Msg("Webhook delivery dead-lettered")
+}
+
+// post performs a single webhook delivery attempt
+func (wd *WebhookDispatcher) post(ctx context.Context, target WebhookTarget, body []byte, signature string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if target.Compress {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if signature != "" {
+		req.Header.Set(WebhookSignatureHeader, signature)
+	}
+
+	resp, err := wd.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return &webhookStatusError{StatusCode: resp.StatusCode}
+	}
+
+	return nil
+}
+
+// isRetryableWebhookError reports whether a failed attempt may succeed on retry.
+// Only 5xx responses and transport errors such as timeouts are retried.
+func isRetryableWebhookError(err error) bool {
+	var statusErr *webhookStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode >= 500
+	}
+	return !errors.Is(err, context.Canceled)
+}
+
+// webhookBackoff returns the delay before the given retry, drawn uniformly
+// from zero up to an exponentially growing, capped bound
+func webhookBackoff(attempt int) time.Duration {
+	bound := webhookMaxBackoff
+	if attempt < 16 {
+		if exp := webhookBaseBackoff << (attempt - 1); exp < bound {
+			bound = exp
+		}
+	}
+	return rand.N(bound) + 1
+}
+
+// signWebhookBody returns the signature header value of a JSON body
+func signWebhookBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// gzipBody compresses a webhook body
+func gzipBody(body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write(body); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -72,8 +72,7 @@ func (w *WhatsAppClientWrapper) GetJID(ctx context.Context, sessionID domain.Ses
 
 // SetProxy implements the domain interface
 func (w *WhatsAppClientWrapper) SetProxy(ctx context.Context, sessionID domain.SessionID, proxyURL string) error {
-	// This would need to be implemented based on whatsmeow's proxy configuration
-	return nil
+	return w.client.SetProxyAddress(proxyURL)
 }
 
 // IsAuthenticated implements the domain interface
@@ -487,8 +486,7 @@ func (cw *ClientWrapper) GetJID(ctx context.Context, sessionID domain.SessionID)
 
 // SetProxy configures proxy for the session
 func (cw *ClientWrapper) SetProxy(ctx context.Context, sessionID domain.SessionID, proxyURL string) error {
-	// Implementation would go here
-	return fmt.Errorf("not implemented")
+	return cw.client.SetProxyAddress(proxyURL)
 }
 
 // GetConnectionStatus returns the current connection status
@@ -5,15 +5,19 @@ import (
 	"database/sql"
 	"fmt"
 	"sync"
+	"time"
 
 	"wazmeow/internal/domain"
 
 	"github.com/lib/pq"
 	"github.com/rs/zerolog/log"
+	"go.mau.fi/whatsmeow/proto/waAdv"
 	"go.mau.fi/whatsmeow/store"
 	"go.mau.fi/whatsmeow/store/sqlstore"
 	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/util/keys"
 	waLog "go.mau.fi/whatsmeow/util/log"
+	"google.golang.org/protobuf/proto"
 )
 
 // WhatsAppStoreManager manages WhatsApp store containers and devices for multiple sessions
@@ -23,13 +27,22 @@ type WhatsAppStoreManager struct {
 	logger    waLog.Logger
 
 	// Thread-safe device cache
-	devices map[domain.SessionID]*store.Device
+	devices map[domain.SessionID]*cachedDevice
 	mutex   sync.RWMutex
 
 	// Configuration
 	maxDevices int
 }
 
+// defaultMaxDevices bounds the devices kept in memory
+const defaultMaxDevices = 100
+
+// cachedDevice is a device in the cache with the last time a session used it
+type cachedDevice struct {
+	device   *store.Device
+	lastUsed time.Time
+}
+
 // NewWhatsAppStoreManager creates a new WhatsApp store manager
 func NewWhatsAppStoreManager(db *sql.DB, logger waLog.Logger) (*WhatsAppStoreManager, error) {
 	// Set up PostgreSQL array wrapper for whatsmeow
@@ -49,27 +62,41 @@ func NewWhatsAppStoreManager(db *sql.DB, logger waLog.Logger) (*WhatsAppStoreMan
 	return &WhatsAppStoreManager{
 		container:  container,
 		logger:     logger,
-		devices:    make(map[domain.SessionID]*store.Device),
-		maxDevices: 100, // Default limit
+		devices:    make(map[domain.SessionID]*cachedDevice),
+		maxDevices: defaultMaxDevices,
 	}, nil
 }
 
-// GetOrCreateDevice gets an existing device or creates a new one for a session
-func (wsm *WhatsAppStoreManager) GetOrCreateDevice(sessionID domain.SessionID, jid string) (*store.Device, error) {
+// SetMaxDevices sets how many devices are kept in memory before idle ones are evicted
+func (wsm *WhatsAppStoreManager) SetMaxDevices(maxDevices int) {
+	wsm.mutex.Lock()
+	defer wsm.mutex.Unlock()
+
+	if maxDevices > 0 {
+		wsm.maxDevices = maxDevices
+	}
+}
+
+// GetOrCreateDevice gets an existing device or creates a new one for a session.
+// Once the cache is full, the least recently used device that idle reports as
+// idle is evicted from memory; its persisted copy is kept and restored when
+// its session starts again.
+func (wsm *WhatsAppStoreManager) GetOrCreateDevice(sessionID domain.SessionID, jid string, idle func(domain.SessionID) bool) (*store.Device, error) {
 	wsm.mutex.Lock()
 	defer wsm.mutex.Unlock()
 
 	// Check if device already exists in cache
-	if device, exists := wsm.devices[sessionID]; exists {
+	if cached, exists := wsm.devices[sessionID]; exists {
 		log.Debug().
 			Str("session_id", sessionID.String()).
 			Str("jid", jid).
 			Msg("Device found in cache")
-		return device, nil
+		cached.lastUsed = time.Now()
+		return cached.device, nil
 	}
 
 	// Check device limit
-	if len(wsm.devices) >= wsm.maxDevices {
+	if len(wsm.devices) >= wsm.maxDevices && !wsm.evictIdleDeviceUnsafe(idle) {
 		return nil, fmt.Errorf("maximum number of devices (%d) reached", wsm.maxDevices)
 	}
 
@@ -102,11 +129,39 @@ func (wsm *WhatsAppStoreManager) GetOrCreateDevice(sessionID domain.SessionID, j
 	}
 
 	// Cache the device
-	wsm.devices[sessionID] = device
+	wsm.devices[sessionID] = &cachedDevice{device: device, lastUsed: time.Now()}
 
 	return device, nil
 }
 
+// evictIdleDeviceUnsafe drops the least recently used idle device from the
+// cache and reports whether one was found (must be called with mutex locked)
+func (wsm *WhatsAppStoreManager) evictIdleDeviceUnsafe(idle func(domain.SessionID) bool) bool {
+	var (
+		oldestID   domain.SessionID
+		oldestUsed time.Time
+		found      bool
+	)
+	for sessionID, cached := range wsm.devices {
+		if !idle(sessionID) {
+			continue
+		}
+		if !found || cached.lastUsed.Before(oldestUsed) {
+			oldestID, oldestUsed, found = sessionID, cached.lastUsed, true
+		}
+	}
+	if !found {
+		return false
+	}
+
+	delete(wsm.devices, oldestID)
+	log.Info().
+		Str("session_id", oldestID.String()).
+		Time("last_used", oldestUsed).
+		Msg("Evicted idle device from cache")
+	return true
+}
+
 // restoreDevice attempts to restore a device from the database using JID
 func (wsm *WhatsAppStoreManager) restoreDevice(jid string) (*store.Device, error) {
 	parsedJID, err := types.ParseJID(jid)
@@ -128,8 +183,11 @@ func (wsm *WhatsAppStoreManager) GetDevice(sessionID domain.SessionID) (*store.D
 	wsm.mutex.RLock()
 	defer wsm.mutex.RUnlock()
 
-	device, exists := wsm.devices[sessionID]
-	return device, exists
+	cached, exists := wsm.devices[sessionID]
+	if !exists {
+		return nil, false
+	}
+	return cached.device, true
 }
 
 // RemoveDevice removes a device from the cache
@@ -145,6 +203,111 @@ func (wsm *WhatsAppStoreManager) RemoveDevice(sessionID domain.SessionID) {
 	}
 }
 
+// DeviceCredentials is the portable form of a paired device's identity and keys
+type DeviceCredentials struct {
+	JID                   string `json:"jid"`
+	LID                   string `json:"lid,omitempty"`
+	RegistrationID        uint32 `json:"registration_id"`
+	NoiseKey              []byte `json:"noise_key"`
+	IdentityKey           []byte `json:"identity_key"`
+	SignedPreKey          []byte `json:"signed_pre_key"`
+	SignedPreKeyID        uint32 `json:"signed_pre_key_id"`
+	SignedPreKeySig       []byte `json:"signed_pre_key_sig"`
+	AdvSecretKey          []byte `json:"adv_secret_key"`
+	Account               []byte `json:"account"`
+	Platform              string `json:"platform,omitempty"`
+	BusinessName          string `json:"business_name,omitempty"`
+	PushName              string `json:"push_name,omitempty"`
+	LIDMigrationTimestamp int64  `json:"lid_migration_timestamp,omitempty"`
+}
+
+// ExportDevice reads the stored device for a JID and returns its credentials
+func (wsm *WhatsAppStoreManager) ExportDevice(ctx context.Context, jid string) (*DeviceCredentials, error) {
+	parsedJID, err := types.ParseJID(jid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JID %s: %w", jid, err)
+	}
+
+	device, err := wsm.container.GetDevice(ctx, parsedJID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device for JID %s: %w", jid, err)
+	}
+	if device == nil || device.ID == nil || device.Account == nil {
+		return nil, fmt.Errorf("no paired device stored for JID %s", jid)
+	}
+
+	account, err := proto.Marshal(device.Account)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal device account: %w", err)
+	}
+
+	return &DeviceCredentials{
+		JID:                   device.ID.String(),
+		LID:                   device.LID.String(),
+		RegistrationID:        device.RegistrationID,
+		NoiseKey:              device.NoiseKey.Priv[:],
+		IdentityKey:           device.IdentityKey.Priv[:],
+		SignedPreKey:          device.SignedPreKey.Priv[:],
+		SignedPreKeyID:        device.SignedPreKey.KeyID,
+		SignedPreKeySig:       device.SignedPreKey.Signature[:],
+		AdvSecretKey:          device.AdvSecretKey,
+		Account:               account,
+		Platform:              device.Platform,
+		BusinessName:          device.BusinessName,
+		PushName:              device.PushName,
+		LIDMigrationTimestamp: device.LIDMigrationTimestamp,
+	}, nil
+}
+
+// ImportDevice stores a device rebuilt from exported credentials and returns its JID
+func (wsm *WhatsAppStoreManager) ImportDevice(ctx context.Context, creds *DeviceCredentials) (types.JID, error) {
+	jid, err := types.ParseJID(creds.JID)
+	if err != nil {
+		return types.JID{}, fmt.Errorf("failed to parse JID %s: %w", creds.JID, err)
+	}
+
+	var lid types.JID
+	if creds.LID != "" {
+		if lid, err = types.ParseJID(creds.LID); err != nil {
+			return types.JID{}, fmt.Errorf("failed to parse LID %s: %w", creds.LID, err)
+		}
+	}
+
+	if len(creds.NoiseKey) != 32 || len(creds.IdentityKey) != 32 || len(creds.SignedPreKey) != 32 || len(creds.SignedPreKeySig) != 64 {
+		return types.JID{}, fmt.Errorf("invalid key material in credentials")
+	}
+
+	var account waAdv.ADVSignedDeviceIdentity
+	if err := proto.Unmarshal(creds.Account, &account); err != nil {
+		return types.JID{}, fmt.Errorf("failed to unmarshal device account: %w", err)
+	}
+
+	device := wsm.container.NewDevice()
+	device.ID = &jid
+	device.LID = lid
+	device.RegistrationID = creds.RegistrationID
+	device.NoiseKey = keys.NewKeyPairFromPrivateKey(*(*[32]byte)(creds.NoiseKey))
+	device.IdentityKey = keys.NewKeyPairFromPrivateKey(*(*[32]byte)(creds.IdentityKey))
+	device.SignedPreKey = &keys.PreKey{
+		KeyPair:   *keys.NewKeyPairFromPrivateKey(*(*[32]byte)(creds.SignedPreKey)),
+		KeyID:     creds.SignedPreKeyID,
+		Signature: (*[64]byte)(creds.SignedPreKeySig),
+	}
+	device.AdvSecretKey = creds.AdvSecretKey
+	device.Account = &account
+	device.Platform = creds.Platform
+	device.BusinessName = creds.BusinessName
+	device.PushName = creds.PushName
+	device.LIDMigrationTimestamp = creds.LIDMigrationTimestamp
+
+	if err := device.Save(ctx); err != nil {
+		return types.JID{}, fmt.Errorf("failed to save imported device: %w", err)
+	}
+
+	log.Info().Str("jid", jid.String()).Msg("Imported WhatsApp device credentials")
+	return jid, nil
+}
+
 // GetContainer returns the sqlstore container
 func (wsm *WhatsAppStoreManager) GetContainer() *sqlstore.Container {
 	return wsm.container
@@ -156,7 +319,7 @@ func (wsm *WhatsAppStoreManager) Close() error {
 	defer wsm.mutex.Unlock()
 
 	// Clear device cache
-	wsm.devices = make(map[domain.SessionID]*store.Device)
+	wsm.devices = make(map[domain.SessionID]*cachedDevice)
 
 	// Close container
 	if wsm.container != nil {
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"reflect"
 	"time"
 
 	"wazmeow/internal/app/config"
@@ -79,10 +80,171 @@ func (d *Database) Migrate(ctx context.Context) error {
 		return fmt.Errorf("failed to create sessions table: %w", err)
 	}
 
+	// Bring tables created by older schemas in line with the bun model
+	if err := d.renameLegacyColumns(ctx, (*domain.Session)(nil), legacySessionColumns); err != nil {
+		return fmt.Errorf("failed to rename legacy session columns: %w", err)
+	}
+	if err := d.ensureColumns(ctx, (*domain.Session)(nil)); err != nil {
+		return fmt.Errorf("failed to reconcile sessions table: %w", err)
+	}
+
+	// Auto-create messages table
+	_, err = d.NewCreateTable().
+		Model((*domain.Message)(nil)).
+		IfNotExists().
+		Exec(ctx)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create messages table")
+		return fmt.Errorf("failed to create messages table: %w", err)
+	}
+
+	if err := d.ensureColumns(ctx, (*domain.Message)(nil)); err != nil {
+		return fmt.Errorf("failed to reconcile messages table: %w", err)
+	}
+
+	_, err = d.NewCreateIndex().
+		Model((*domain.Message)(nil)).
+		Index("messages_session_chat_timestamp_idx").
+		Column("session_id", "chat_jid", "timestamp").
+		IfNotExists().
+		Exec(ctx)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create messages index")
+		return fmt.Errorf("failed to create messages index: %w", err)
+	}
+
+	// Auto-create pending jobs table
+	_, err = d.NewCreateTable().
+		Model((*domain.PendingJob)(nil)).
+		IfNotExists().
+		Exec(ctx)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create pending jobs table")
+		return fmt.Errorf("failed to create pending jobs table: %w", err)
+	}
+
+	if err := d.ensureColumns(ctx, (*domain.PendingJob)(nil)); err != nil {
+		return fmt.Errorf("failed to reconcile pending jobs table: %w", err)
+	}
+
+	// Auto-create daily send counters table
+	_, err = d.NewCreateTable().
+		Model((*domain.SendCounter)(nil)).
+		IfNotExists().
+		Exec(ctx)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create send counters table")
+		return fmt.Errorf("failed to create send counters table: %w", err)
+	}
+
 	log.Info().Msg("Database migration completed successfully")
 	return nil
 }
 
+// legacySessionColumns maps column names used by the old SQL migrations to
+// the names declared in the domain.Session bun tags.
+var legacySessionColumns = map[string]string{
+	"jid":     "wa_jid",
+	"qrcode":  "qr_code",
+	"webhook": "webhook_url",
+}
+
+// existingColumns returns the set of columns currently present on a table
+func (d *Database) existingColumns(ctx context.Context, table string) (map[string]bool, error) {
+	var names []string
+	err := d.NewSelect().
+		Column("column_name").
+		TableExpr("information_schema.columns").
+		Where("table_schema = current_schema()").
+		Where("table_name = ?", table).
+		Scan(ctx, &names)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list columns of %s: %w", table, err)
+	}
+
+	columns := make(map[string]bool, len(names))
+	for _, name := range names {
+		columns[name] = true
+	}
+	return columns, nil
+}
+
+// renameLegacyColumns renames old column names to the ones used by the model,
+// skipping any rename whose target column already exists
+func (d *Database) renameLegacyColumns(ctx context.Context, model any, renames map[string]string) error {
+	table := d.Table(reflect.TypeOf(model).Elem())
+
+	columns, err := d.existingColumns(ctx, table.Name)
+	if err != nil {
+		return err
+	}
+
+	for oldName, newName := range renames {
+		if !columns[oldName] || columns[newName] {
+			continue
+		}
+
+		if _, err := d.ExecContext(ctx, "ALTER TABLE ? RENAME COLUMN ? TO ?",
+			table.SQLName, bun.Ident(oldName), bun.Ident(newName)); err != nil {
+			return fmt.Errorf("failed to rename column %s.%s: %w", table.Name, oldName, err)
+		}
+
+		log.Info().
+			Str("table", table.Name).
+			Str("from", oldName).
+			Str("to", newName).
+			Msg("Renamed legacy column")
+	}
+
+	return nil
+}
+
+// ensureColumns adds any column declared on the bun model that is missing
+// from the table, so the model remains the single source of truth for the schema
+func (d *Database) ensureColumns(ctx context.Context, model any) error {
+	table := d.Table(reflect.TypeOf(model).Elem())
+
+	columns, err := d.existingColumns(ctx, table.Name)
+	if err != nil {
+		return err
+	}
+
+	for _, field := range table.Fields {
+		if columns[field.Name] {
+			continue
+		}
+
+		definition := field.CreateTableSQLType
+		if field.SQLDefault != "" {
+			definition += " DEFAULT " + field.SQLDefault
+			// NOT NULL can only be added safely to populated tables when a default exists
+			if field.NotNull {
+				definition += " NOT NULL"
+			}
+		}
+
+		_, err := d.NewAddColumn().
+			Model(model).
+			ColumnExpr("? "+definition, bun.Ident(field.Name)).
+			IfNotExists().
+			Exec(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", table.Name, field.Name, err)
+		}
+
+		log.Info().
+			Str("table", table.Name).
+			Str("column", field.Name).
+			Msg("Added missing column")
+	}
+
+	return nil
+}
+
 // Close closes the database connection
 func (d *Database) Close() error {
 	log.Info().Msg("Closing database connection")
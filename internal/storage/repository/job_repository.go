@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"wazmeow/internal/domain"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uptrace/bun"
+)
+
+// jobRepository implements the domain.JobRepository interface
+type jobRepository struct {
+	db *bun.DB
+}
+
+// NewJobRepository creates a new pending job repository
+func NewJobRepository(db *bun.DB) domain.JobRepository {
+	return &jobRepository{db: db}
+}
+
+// Save stores a pending job, replacing it if already stored
+func (r *jobRepository) Save(ctx context.Context, job *domain.PendingJob) error {
+	_, err := r.db.NewInsert().
+		Model(job).
+		On("CONFLICT (id) DO UPDATE").
+		Set("attempts = EXCLUDED.attempts").
+		Set("expires_at = EXCLUDED.expires_at").
+		Exec(ctx)
+
+	if err != nil {
+		log.Error().Err(err).Str("job_id", job.ID).Msg("Failed to save pending job")
+		return fmt.Errorf("failed to save pending job: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a job once it ran or was cancelled
+func (r *jobRepository) Delete(ctx context.Context, id string) error {
+	_, err := r.db.NewDelete().
+		Model((*domain.PendingJob)(nil)).
+		Where("id = ?", id).
+		Exec(ctx)
+
+	if err != nil {
+		log.Error().Err(err).Str("job_id", id).Msg("Failed to delete pending job")
+		return fmt.Errorf("failed to delete pending job: %w", err)
+	}
+
+	return nil
+}
+
+// List returns every pending job, oldest first
+func (r *jobRepository) List(ctx context.Context) ([]*domain.PendingJob, error) {
+	var jobs []*domain.PendingJob
+	err := r.db.NewSelect().
+		Model(&jobs).
+		Order("enqueued_at ASC").
+		Scan(ctx)
+
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list pending jobs")
+		return nil, fmt.Errorf("failed to list pending jobs: %w", err)
+	}
+
+	return jobs, nil
+}
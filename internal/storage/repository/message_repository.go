@@ -0,0 +1,142 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"wazmeow/internal/domain"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uptrace/bun"
+)
+
+// messageRepository implements the domain.MessageRepository interface
+type messageRepository struct {
+	db *bun.DB
+}
+
+// NewMessageRepository creates a new message repository
+func NewMessageRepository(db *bun.DB) domain.MessageRepository {
+	return &messageRepository{db: db}
+}
+
+// Save stores a message, ignoring it if already stored
+func (r *messageRepository) Save(ctx context.Context, message *domain.Message) error {
+	return r.SaveBatch(ctx, []*domain.Message{message})
+}
+
+// SaveBatch stores multiple messages, ignoring the ones already stored
+func (r *messageRepository) SaveBatch(ctx context.Context, messages []*domain.Message) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	_, err := r.db.NewInsert().
+		Model(&messages).
+		On("CONFLICT (id, session_id) DO NOTHING").
+		Exec(ctx)
+
+	if err != nil {
+		log.Error().Err(err).Int("count", len(messages)).Msg("Failed to save messages")
+		return fmt.Errorf("failed to save messages: %w", err)
+	}
+
+	return nil
+}
+
+// GetByID retrieves a message of a session by its ID
+func (r *messageRepository) GetByID(ctx context.Context, sessionID domain.SessionID, id string) (*domain.Message, error) {
+	message := new(domain.Message)
+	err := r.db.NewSelect().
+		Model(message).
+		Where("session_id = ?", sessionID.String()).
+		Where("id = ?", id).
+		Scan(ctx)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, domain.NewNotFoundError("message", id)
+		}
+		log.Error().Err(err).Str("session_id", sessionID.String()).Str("message_id", id).Msg("Failed to get message")
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+
+	return message, nil
+}
+
+// ListByChat retrieves up to limit messages of a chat older than the
+// (before, beforeID) cursor, newest first. A zero time lists from the newest
+// message.
+func (r *messageRepository) ListByChat(ctx context.Context, sessionID domain.SessionID, chatJID string, limit int, before time.Time, beforeID string) ([]*domain.Message, error) {
+	messages := []*domain.Message{}
+	query := r.db.NewSelect().
+		Model(&messages).
+		Where("session_id = ?", sessionID.String()).
+		Where("chat_jid = ?", chatJID).
+		Order("timestamp DESC", "id DESC").
+		Limit(limit)
+
+	// WhatsApp timestamps have one second resolution, the ID breaks ties
+	switch {
+	case before.IsZero():
+	case beforeID != "":
+		query = query.Where("(timestamp, id) < (?, ?)", before, beforeID)
+	default:
+		query = query.Where("timestamp < ?", before)
+	}
+
+	if err := query.Scan(ctx); err != nil {
+		log.Error().Err(err).Str("session_id", sessionID.String()).Str("chat", chatJID).Msg("Failed to list chat messages")
+		return nil, fmt.Errorf("failed to list chat messages: %w", err)
+	}
+
+	return messages, nil
+}
+
+// AdvanceStatus moves sent messages to status unless they already progressed past it
+func (r *messageRepository) AdvanceStatus(ctx context.Context, sessionID domain.SessionID, ids []string, status domain.MessageStatus) error {
+	preceding := status.Preceding()
+	if len(ids) == 0 || len(preceding) == 0 {
+		return nil
+	}
+
+	_, err := r.db.NewUpdate().
+		Model((*domain.Message)(nil)).
+		Set("status = ?", status).
+		Where("session_id = ?", sessionID.String()).
+		Where("id IN (?)", bun.In(ids)).
+		Where("status IN (?)", bun.In(preceding)).
+		Exec(ctx)
+
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionID.String()).Str("status", string(status)).Msg("Failed to update message status")
+		return fmt.Errorf("failed to update message status: %w", err)
+	}
+
+	return nil
+}
+
+// CountByStatus counts the sent messages of a session in [from, to) by type and status
+func (r *messageRepository) CountByStatus(ctx context.Context, sessionID domain.SessionID, from, to time.Time) ([]domain.MessageStatusCount, error) {
+	var counts []domain.MessageStatusCount
+	err := r.db.NewSelect().
+		Model((*domain.Message)(nil)).
+		Column("type", "status").
+		ColumnExpr("COUNT(*) AS count").
+		Where("session_id = ?", sessionID.String()).
+		Where("from_me = TRUE").
+		Where("status <> ''").
+		Where("timestamp >= ?", from).
+		Where("timestamp < ?", to).
+		Group("type", "status").
+		Scan(ctx, &counts)
+
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionID.String()).Msg("Failed to count messages")
+		return nil, fmt.Errorf("failed to count messages: %w", err)
+	}
+
+	return counts, nil
+}
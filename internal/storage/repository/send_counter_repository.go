@@ -0,0 +1,100 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"wazmeow/internal/domain"
+
+	"github.com/rs/zerolog/log"
+	"github.com/uptrace/bun"
+)
+
+// sendCounterRepository implements the domain.SendCounterRepository interface
+type sendCounterRepository struct {
+	db *bun.DB
+}
+
+// NewSendCounterRepository creates a new daily send counter repository
+func NewSendCounterRepository(db *bun.DB) domain.SendCounterRepository {
+	return &sendCounterRepository{db: db}
+}
+
+// Increment counts one more send of a session on day unless limit sends were
+// already counted. The check and the increment are a single statement, so
+// concurrent sends cannot overshoot the limit.
+func (r *sendCounterRepository) Increment(ctx context.Context, sessionID domain.SessionID, day time.Time, limit int) (int, bool, error) {
+	counter := &domain.SendCounter{
+		SessionID: sessionID,
+		Day:       sendCounterDay(day),
+		Count:     1,
+	}
+
+	var count int
+	err := r.db.NewInsert().
+		Model(counter).
+		On("CONFLICT (session_id, day) DO UPDATE").
+		Set("count = sc.count + 1").
+		Where("sc.count < ?", limit).
+		Returning("count").
+		Scan(ctx, &count)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		// The limit was reached, nothing was updated
+		count, err = r.Get(ctx, sessionID, day)
+		return count, false, err
+	}
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionID.String()).Msg("Failed to count send")
+		return 0, false, fmt.Errorf("failed to count send: %w", err)
+	}
+
+	return count, true, nil
+}
+
+// Decrement gives back a send counted on day, never going below zero
+func (r *sendCounterRepository) Decrement(ctx context.Context, sessionID domain.SessionID, day time.Time) error {
+	_, err := r.db.NewUpdate().
+		Model((*domain.SendCounter)(nil)).
+		Set("count = count - 1").
+		Where("session_id = ?", sessionID.String()).
+		Where("day = ?", sendCounterDay(day)).
+		Where("count > 0").
+		Exec(ctx)
+
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionID.String()).Msg("Failed to release send")
+		return fmt.Errorf("failed to release send: %w", err)
+	}
+
+	return nil
+}
+
+// Get returns the sends counted for a session on day
+func (r *sendCounterRepository) Get(ctx context.Context, sessionID domain.SessionID, day time.Time) (int, error) {
+	var count int
+	err := r.db.NewSelect().
+		Model((*domain.SendCounter)(nil)).
+		Column("count").
+		Where("session_id = ?", sessionID.String()).
+		Where("day = ?", sendCounterDay(day)).
+		Scan(ctx, &count)
+
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, nil
+	}
+	if err != nil {
+		log.Error().Err(err).Str("session_id", sessionID.String()).Msg("Failed to get send count")
+		return 0, fmt.Errorf("failed to get send count: %w", err)
+	}
+
+	return count, nil
+}
+
+// sendCounterDay formats the UTC date a counter is kept for
+func sendCounterDay(day time.Time) string {
+	return day.UTC().Format("2006-01-02")
+}
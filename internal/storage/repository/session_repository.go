@@ -3,14 +3,27 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+	"time"
 
 	"wazmeow/internal/domain"
 
 	"github.com/rs/zerolog/log"
 	"github.com/uptrace/bun"
+	"github.com/uptrace/bun/driver/pgdriver"
 )
 
+// pgUniqueViolation is the SQLSTATE Postgres reports for a unique constraint violation
+const pgUniqueViolation = "23505"
+
+// isUniqueViolation reports whether err is a Postgres unique constraint violation
+func isUniqueViolation(err error) bool {
+	var pgErr pgdriver.Error
+	return errors.As(err, &pgErr) && pgErr.Field('C') == pgUniqueViolation
+}
+
 // sessionRepository implements the domain.Repository interface
 type sessionRepository struct {
 	db *bun.DB
@@ -25,6 +38,10 @@ func NewSessionRepository(db *bun.DB) domain.Repository {
 func (r *sessionRepository) Create(ctx context.Context, sess *domain.Session) error {
 	_, err := r.db.NewInsert().Model(sess).Exec(ctx)
 	if err != nil {
+		// A concurrent create can win the race past the ExistsByName check
+		if isUniqueViolation(err) {
+			return domain.ErrSessionAlreadyExists(sess.Name)
+		}
 		log.Error().Err(err).Str("session_id", sess.ID.String()).Msg("Failed to create session")
 		return fmt.Errorf("failed to create session: %w", err)
 	}
@@ -129,6 +146,9 @@ func (r *sessionRepository) Update(ctx context.Context, sess *domain.Session) er
 		Exec(ctx)
 
 	if err != nil {
+		if isUniqueViolation(err) {
+			return domain.ErrSessionAlreadyExists(sess.Name)
+		}
 		log.Error().Err(err).Str("session_id", sess.ID.String()).Msg("Failed to update session")
 		return fmt.Errorf("failed to update session: %w", err)
 	}
@@ -257,7 +277,7 @@ func (r *sessionRepository) UpdateStatus(ctx context.Context, id domain.SessionI
 	result, err := r.db.NewUpdate().
 		Model((*domain.Session)(nil)).
 		Set("status = ?", string(status)).
-		Set("updated_at = NOW()").
+		Set("updated_at = ?", time.Now()).
 		Where("id = ?", id.String()).
 		Exec(ctx)
 
@@ -290,7 +310,7 @@ func (r *sessionRepository) SetWAJID(ctx context.Context, id domain.SessionID, w
 	result, err := r.db.NewUpdate().
 		Model((*domain.Session)(nil)).
 		Set("wa_jid = ?", wajid).
-		Set("updated_at = NOW()").
+		Set("updated_at = ?", time.Now()).
 		Where("id = ?", id.String()).
 		Exec(ctx)
 
@@ -317,13 +337,23 @@ func (r *sessionRepository) SetWAJID(ctx context.Context, id domain.SessionID, w
 	return nil
 }
 
-// SetQRCode sets the QR code for a session
-func (r *sessionRepository) SetQRCode(ctx context.Context, id domain.SessionID, qrCode string) error {
+// SetQRCode sets the QR code for a session and when it expires
+func (r *sessionRepository) SetQRCode(ctx context.Context, id domain.SessionID, qrCode string, expiresAt time.Time) error {
+	// The expiry tells readers how long the code stays valid
+	var generatedAt, expires *time.Time
+	if qrCode != "" {
+		now := time.Now()
+		generatedAt = &now
+		expires = &expiresAt
+	}
+
 	// Use bun's query builder instead of raw SQL
 	result, err := r.db.NewUpdate().
 		Model((*domain.Session)(nil)).
 		Set("qr_code = ?", qrCode).
-		Set("updated_at = NOW()").
+		Set("qr_generated_at = ?", generatedAt).
+		Set("qr_expires_at = ?", expires).
+		Set("updated_at = ?", time.Now()).
 		Where("id = ?", id.String()).
 		Exec(ctx)
 
@@ -349,9 +379,284 @@ func (r *sessionRepository) SetQRCode(ctx context.Context, id domain.SessionID,
 	return nil
 }
 
+// SetAllowlist sets the recipient allowlist for a session
+func (r *sessionRepository) SetAllowlist(ctx context.Context, id domain.SessionID, recipients []string) error {
+	result, err := r.db.NewUpdate().
+		Model((*domain.Session)(nil)).
+		Set("recipient_allowlist = ?", strings.Join(recipients, ",")).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", id.String()).
+		Exec(ctx)
+
+	if err != nil {
+		log.Error().Err(err).Str("session_id", id.String()).Msg("Failed to set recipient allowlist")
+		return fmt.Errorf("failed to set recipient allowlist: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrSessionNotFound(id)
+	}
+
+	log.Info().
+		Str("session_id", id.String()).
+		Int("recipients", len(recipients)).
+		Msg("Recipient allowlist updated successfully")
+
+	return nil
+}
+
+// SetIgnoredChats sets the ignored chats for a session
+func (r *sessionRepository) SetIgnoredChats(ctx context.Context, id domain.SessionID, chats []string) error {
+	result, err := r.db.NewUpdate().
+		Model((*domain.Session)(nil)).
+		Set("ignored_chats = ?", strings.Join(chats, ",")).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", id.String()).
+		Exec(ctx)
+
+	if err != nil {
+		log.Error().Err(err).Str("session_id", id.String()).Msg("Failed to set ignored chats")
+		return fmt.Errorf("failed to set ignored chats: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrSessionNotFound(id)
+	}
+
+	log.Info().
+		Str("session_id", id.String()).
+		Int("chats", len(chats)).
+		Msg("Ignored chats updated successfully")
+
+	return nil
+}
+
+// SetEvents sets the subscribed event types for a session
+func (r *sessionRepository) SetEvents(ctx context.Context, id domain.SessionID, events []string) error {
+	result, err := r.db.NewUpdate().
+		Model((*domain.Session)(nil)).
+		Set("events = ?", strings.Join(events, ",")).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", id.String()).
+		Exec(ctx)
+
+	if err != nil {
+		log.Error().Err(err).Str("session_id", id.String()).Msg("Failed to set events")
+		return fmt.Errorf("failed to set events: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrSessionNotFound(id)
+	}
+
+	log.Info().
+		Str("session_id", id.String()).
+		Strs("events", events).
+		Msg("Subscribed events updated successfully")
+
+	return nil
+}
+
+// SetS3Config sets the media bucket settings and the media delivery mode for a session
+func (r *sessionRepository) SetS3Config(ctx context.Context, id domain.SessionID, cfg domain.S3Config, mediaDelivery domain.MediaDelivery) error {
+	result, err := r.db.NewUpdate().
+		Model((*domain.Session)(nil)).
+		Set("s3_enabled = ?", cfg.Enabled).
+		Set("s3_endpoint = ?", cfg.Endpoint).
+		Set("s3_region = ?", cfg.Region).
+		Set("s3_bucket = ?", cfg.Bucket).
+		Set("s3_access_key = ?", cfg.AccessKey).
+		Set("s3_secret_key = ?", cfg.SecretKey).
+		Set("s3_path_style = ?", cfg.PathStyle).
+		Set("s3_public_url = ?", cfg.PublicURL).
+		Set("s3_retention_days = ?", cfg.RetentionDays).
+		Set("media_delivery = ?", mediaDelivery).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", id.String()).
+		Exec(ctx)
+
+	if err != nil {
+		log.Error().Err(err).Str("session_id", id.String()).Msg("Failed to set S3 configuration")
+		return fmt.Errorf("failed to set S3 configuration: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrSessionNotFound(id)
+	}
+
+	log.Info().
+		Str("session_id", id.String()).
+		Bool("enabled", cfg.Enabled).
+		Str("media_delivery", string(mediaDelivery)).
+		Msg("Session S3 configuration updated successfully")
+
+	return nil
+}
+
+// SetTimezone sets the response timezone for a session
+func (r *sessionRepository) SetTimezone(ctx context.Context, id domain.SessionID, timezone string) error {
+	result, err := r.db.NewUpdate().
+		Model((*domain.Session)(nil)).
+		Set("timezone = ?", timezone).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", id.String()).
+		Exec(ctx)
+
+	if err != nil {
+		log.Error().Err(err).Str("session_id", id.String()).Msg("Failed to set timezone")
+		return fmt.Errorf("failed to set timezone: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrSessionNotFound(id)
+	}
+
+	log.Info().
+		Str("session_id", id.String()).
+		Str("timezone", timezone).
+		Msg("Session timezone updated successfully")
+
+	return nil
+}
+
+// SetProxyURL sets the proxy URL for a session
+func (r *sessionRepository) SetProxyURL(ctx context.Context, id domain.SessionID, proxyURL string) error {
+	result, err := r.db.NewUpdate().
+		Model((*domain.Session)(nil)).
+		Set("proxy_url = ?", proxyURL).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", id.String()).
+		Exec(ctx)
+
+	if err != nil {
+		log.Error().Err(err).Str("session_id", id.String()).Msg("Failed to set proxy URL")
+		return fmt.Errorf("failed to set proxy URL: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrSessionNotFound(id)
+	}
+
+	log.Info().Str("session_id", id.String()).Msg("Session proxy URL updated successfully")
+	return nil
+}
+
+// SetReadReceiptsDisabled records whether the session turned the account's read receipts off
+func (r *sessionRepository) SetReadReceiptsDisabled(ctx context.Context, id domain.SessionID, disabled bool) error {
+	result, err := r.db.NewUpdate().
+		Model((*domain.Session)(nil)).
+		Set("read_receipts_disabled = ?", disabled).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", id.String()).
+		Exec(ctx)
+
+	if err != nil {
+		log.Error().Err(err).Str("session_id", id.String()).Msg("Failed to set read receipts state")
+		return fmt.Errorf("failed to set read receipts state: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrSessionNotFound(id)
+	}
+
+	return nil
+}
+
+// SetWebhook sets the webhook URL, subscribed event types and signing secret for a session
+func (r *sessionRepository) SetWebhook(ctx context.Context, id domain.SessionID, webhookURL string, events []string, secret string) error {
+	result, err := r.db.NewUpdate().
+		Model((*domain.Session)(nil)).
+		Set("webhook_url = ?", webhookURL).
+		Set("events = ?", strings.Join(events, ",")).
+		Set("webhook_secret = ?", secret).
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", id.String()).
+		Exec(ctx)
+
+	if err != nil {
+		log.Error().Err(err).Str("session_id", id.String()).Msg("Failed to set webhook")
+		return fmt.Errorf("failed to set webhook: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return domain.ErrSessionNotFound(id)
+	}
+
+	log.Info().
+		Str("session_id", id.String()).
+		Strs("events", events).
+		Msg("Session webhook updated successfully")
+
+	return nil
+}
+
 // ClearQRCode clears the QR code for a session
 func (r *sessionRepository) ClearQRCode(ctx context.Context, id domain.SessionID) error {
-	return r.SetQRCode(ctx, id, "")
+	return r.SetQRCode(ctx, id, "", time.Time{})
+}
+
+// ClearExpiredQRCode clears the QR code for a session if it expired by now.
+// Codes stored without an expiry count as expired.
+func (r *sessionRepository) ClearExpiredQRCode(ctx context.Context, id domain.SessionID, now time.Time) error {
+	_, err := r.db.NewUpdate().
+		Model((*domain.Session)(nil)).
+		Set("qr_code = ''").
+		Set("qr_generated_at = NULL").
+		Set("qr_expires_at = NULL").
+		Set("updated_at = ?", time.Now()).
+		Where("id = ?", id.String()).
+		Where("qr_code <> ''").
+		WhereGroup(" AND ", func(q *bun.UpdateQuery) *bun.UpdateQuery {
+			return q.Where("qr_expires_at IS NULL").WhereOr("qr_expires_at <= ?", now)
+		}).
+		Exec(ctx)
+
+	if err != nil {
+		log.Error().Err(err).Str("session_id", id.String()).Msg("Failed to clear expired QR code")
+		return fmt.Errorf("failed to clear expired QR code: %w", err)
+	}
+
+	return nil
 }
 
 // GetConnectedSessions retrieves all connected sessions
@@ -375,7 +680,7 @@ func (r *sessionRepository) BulkUpdateStatus(ctx context.Context, ids []domain.S
 	result, err := r.db.NewUpdate().
 		Model((*domain.Session)(nil)).
 		Set("status = ?", string(status)).
-		Set("updated_at = NOW()").
+		Set("updated_at = ?", time.Now()).
 		Where("id IN (?)", bun.In(stringIDs)).
 		Exec(ctx)
 
@@ -90,6 +90,14 @@ func NewDevelopment() *Logger {
 	})
 }
 
+// Global wraps the current global logger, so component loggers can be
+// derived from the logger configured at startup
+func Global() *Logger {
+	return &Logger{
+		Logger: &log.Logger,
+	}
+}
+
 // SetGlobalLogger sets the global logger instance
 func SetGlobalLogger(logger *Logger) {
 	log.Logger = *logger.Logger